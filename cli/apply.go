@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/inago/controller"
+)
+
+var (
+	applyFlags struct {
+		Slices    int
+		Stopped   bool
+		MaxGrowth int
+		MinAlive  int
+		ReadySecs int
+		Rollback  bool
+	}
+
+	applyCmd = &cobra.Command{
+		Use:   "apply <group>",
+		Short: "Apply a group",
+		Long:  "Converge a group to the version on the local filesystem by submitting, starting, updating, scaling or destroying it as needed",
+		Run:   applyRun,
+	}
+)
+
+func init() {
+	applyCmd.PersistentFlags().IntVar(&applyFlags.Slices, "slices", -1, "number of group slices to converge to, keeps the current number if negative")
+	applyCmd.PersistentFlags().BoolVar(&applyFlags.Stopped, "stopped", false, "converge to stopped group slices instead of running ones")
+	applyCmd.PersistentFlags().IntVar(&applyFlags.MaxGrowth, "max-growth", 1, "maximum number of group slices added at a time when updating")
+	applyCmd.PersistentFlags().IntVar(&applyFlags.MinAlive, "min-alive", 1, "minimum number of group slices staying alive at a time when updating")
+	applyCmd.PersistentFlags().IntVar(&applyFlags.ReadySecs, "ready-secs", 30, "number of seconds to sleep before updating the next group slice")
+	applyCmd.PersistentFlags().BoolVar(&applyFlags.Rollback, "rollback", false, "restore the previous group slices if the update fails")
+}
+
+func applyRun(cmd *cobra.Command, args []string) {
+	newLogger.Debug(newCtx, "cli: starting apply")
+
+	if len(args) != 1 {
+		cmd.Help()
+		os.Exit(1)
+	}
+
+	newRequestConfig := controller.DefaultRequestConfig()
+	newRequestConfig.Group = args[0]
+	req := controller.NewRequest(newRequestConfig)
+
+	req, err := extendRequestWithContent(fs, req)
+	if err != nil {
+		newLogger.Error(newCtx, "%#v", maskAny(err))
+		os.Exit(1)
+	}
+	if ok, err := controller.ValidateRequest(req); !ok {
+		newLogger.Error(newCtx, "%#v", maskAny(err))
+		os.Exit(1)
+	}
+
+	opts := controller.ReconcileOptions{
+		DesiredSlices: applyFlags.Slices,
+		DesiredStatus: controller.StatusRunning,
+		UpdateOptions: controller.UpdateOptions{
+			MaxGrowth: applyFlags.MaxGrowth,
+			MinAlive:  applyFlags.MinAlive,
+			ReadySecs: applyFlags.ReadySecs,
+			Rollback:  applyFlags.Rollback,
+		},
+	}
+	if applyFlags.Stopped {
+		opts.DesiredStatus = controller.StatusStopped
+	}
+
+	if globalFlags.DryRun {
+		// Waiting for slices to become ready does not change the operations
+		// being planned.
+		opts.UpdateOptions.ReadySecs = 0
+	}
+
+	taskObject, err := newController.Reconcile(newCtx, req, opts)
+	if err != nil {
+		newLogger.Error(newCtx, "%#v", maskAny(err))
+		os.Exit(1)
+	}
+
+	// The slices of the group are not known before the task finished. Thus we
+	// report on the group as a whole.
+	req.SliceIDs = nil
+	maybeBlockWithFeedback(newCtx, blockWithFeedbackCtx{
+		Request:    req,
+		Descriptor: "apply",
+		NoBlock:    globalFlags.NoBlock,
+		TaskID:     taskObject.ID,
+		Closer:     nil,
+	})
+}
@@ -2,11 +2,16 @@ package cli
 
 import (
 	"bytes"
+	"fmt"
 	"net"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
 	"text/template"
 
+	"github.com/coreos/fleet/Godeps/_workspace/src/golang.org/x/crypto/ssh/terminal"
 	"golang.org/x/net/context"
 
 	"github.com/giantswarm/inago/controller"
@@ -74,6 +79,106 @@ func createStatus(group string, usl controller.UnitStatusList) ([]string, error)
 	return strings.Split(out.String(), "\n"), nil
 }
 
+// statusOutput represents the machine readable status of a group, as printed
+// by the status command using --output json or --output yaml. Fields are only
+// ever added to this schema, never changed or removed. See
+// docs/getting_started.md.
+type statusOutput struct {
+	Group string             `json:"group" yaml:"group"`
+	Units []unitStatusOutput `json:"units" yaml:"units"`
+
+	// UpdatePaused is true when updates of the group are paused. It is omitted
+	// otherwise.
+	UpdatePaused bool `json:"update_paused,omitempty" yaml:"update_paused,omitempty"`
+}
+
+// unitStatusOutput represents the status of one unit of a group.
+type unitStatusOutput struct {
+	Name    string `json:"name" yaml:"name"`
+	SliceID string `json:"slice_id" yaml:"slice_id"`
+	Desired string `json:"desired_state" yaml:"desired_state"`
+	Current string `json:"current_state" yaml:"current_state"`
+
+	// Status is the status aggregated from the fleet and systemd states of the
+	// unit. Units not scheduled to any machine have the status aggregated from
+	// their fleet states only.
+	Status   controller.Status     `json:"status" yaml:"status"`
+	Machines []machineStatusOutput `json:"machines" yaml:"machines"`
+}
+
+// machineStatusOutput represents the status of a unit on one machine.
+type machineStatusOutput struct {
+	ID            string            `json:"id" yaml:"id"`
+	IP            string            `json:"ip" yaml:"ip"`
+	SystemdActive string            `json:"systemd_active" yaml:"systemd_active"`
+	SystemdSub    string            `json:"systemd_sub" yaml:"systemd_sub"`
+	UnitHash      string            `json:"unit_hash" yaml:"unit_hash"`
+	Status        controller.Status `json:"status" yaml:"status"`
+}
+
+// createStatusOutput creates the machine readable status of the given group.
+// Unlike createStatus, unit statuses are never grouped. States not known to
+// controller.StatusIndex are reported as controller.StatusUnknown.
+func createStatusOutput(group string, usl controller.UnitStatusList) (statusOutput, error) {
+	aggregator := controller.Aggregator{
+		Logger: newLogger,
+	}
+
+	output := statusOutput{
+		Group: group,
+		Units: []unitStatusOutput{},
+	}
+
+	// Services activated by e.g. timers are expected to be inactive. Their
+	// status is aggregated from the activating unit, the same way the
+	// controller does it. ResolveTriggered keeps the order of the list.
+	resolved := usl.ResolveTriggered()
+
+	for i, us := range usl {
+		unitOutput := unitStatusOutput{
+			Name:     us.Name,
+			SliceID:  us.SliceID,
+			Desired:  us.Desired,
+			Current:  us.Current,
+			Machines: []machineStatusOutput{},
+		}
+
+		for j, ms := range us.Machine {
+			rms := resolved[i].Machine[j]
+			status, err := aggregator.AggregateStatus(resolved[i].Current, resolved[i].Desired, rms.SystemdActive, rms.SystemdSub)
+			if controller.IsInvalidUnitStatus(err) {
+				status = controller.StatusUnknown
+			} else if err != nil {
+				return statusOutput{}, maskAny(err)
+			}
+
+			machineOutput := machineStatusOutput{
+				ID:            ms.ID,
+				SystemdActive: ms.SystemdActive,
+				SystemdSub:    ms.SystemdSub,
+				UnitHash:      ms.UnitHash,
+				Status:        status,
+			}
+			if ms.IP != nil {
+				machineOutput.IP = ms.IP.String()
+			}
+			unitOutput.Machines = append(unitOutput.Machines, machineOutput)
+		}
+
+		status, err := aggregator.AggregateUnitStatus(resolved[i])
+		if controller.IsInvalidUnitStatus(err) {
+			status = controller.StatusUnknown
+		} else if err != nil {
+			return statusOutput{}, maskAny(err)
+		}
+		unitOutput.Status = status
+
+		output.Units = append(output.Units, unitOutput)
+	}
+
+	return output, nil
+}
+
 type blockWithFeedbackCtx struct {
 	Request    controller.Request
 	Descriptor string
@@ -88,18 +193,38 @@ func maybeBlockWithFeedback(ctx context.Context, bctx blockWithFeedbackCtx) {
 		sliceNoun = "slice"
 	}
 
-	if !bctx.NoBlock {
-		taskObject, err := newController.WaitForTask(ctx, bctx.TaskID, bctx.Closer)
+	if bctx.NoBlock && globalFlags.TaskWorker {
+		// This process is the detached worker of a command using --no-block.
+		// The process which started it exits once the task is announced. This
+		// process keeps executing the task until it finished.
+		announceTask(ctx, bctx)
+		bctx.NoBlock = false
+	}
+
+	// Operations of a dry run are only known once the task finished. Thus we
+	// always block.
+	if !bctx.NoBlock || newPlanFleet != nil {
+		taskObject, err := waitForTask(ctx, bctx.TaskID, bctx.Closer)
 		if err != nil {
 			newLogger.Error(ctx, "%#v", maskAny(err))
 			os.Exit(1)
 		}
 
+		if task.HasCancelledStatus(taskObject) {
+			newLogger.Error(ctx, "Cancelled %s of group '%s'.", bctx.Descriptor, bctx.Request.Group)
+			os.Exit(1)
+		}
+
 		if controller.IsUnitsAlreadyUpToDate(taskObject.Error) {
 			newLogger.Info(ctx, "Not updating group '%s'. (%s)", bctx.Request.Group, taskObject.Error.Error())
 			return
 		}
 
+		if controller.IsUpdateRolledBack(taskObject.Error) {
+			newLogger.Error(ctx, "Failed to %s group '%s'. Rolled back. (%s)", bctx.Descriptor, bctx.Request.Group, taskObject.Error.Error())
+			os.Exit(1)
+		}
+
 		if task.HasFailedStatus(taskObject) {
 			if bctx.Request.SliceIDs == nil {
 				newLogger.Error(ctx, "Failed to %s group '%s'. (%s)", bctx.Descriptor, bctx.Request.Group, taskObject.Error.Error())
@@ -121,6 +246,16 @@ func maybeBlockWithFeedback(ctx context.Context, bctx blockWithFeedbackCtx) {
 		}
 	}
 
+	if newPlanFleet != nil {
+		printPlan(bctx.Descriptor, bctx.Request.Group, newPlanFleet.Flush())
+		return
+	}
+
+	if bctx.NoBlock {
+		newLogger.Info(ctx, "Created task '%s' to %s group '%s'.", bctx.TaskID, bctx.Descriptor, bctx.Request.Group)
+		return
+	}
+
 	if bctx.Request.SliceIDs == nil {
 		newLogger.Info(ctx, "Succeeded to %s group '%s'.", bctx.Descriptor, bctx.Request.Group)
 	} else if len(bctx.Request.SliceIDs) == 0 {
@@ -137,3 +272,92 @@ func maybeBlockWithFeedback(ctx context.Context, bctx blockWithFeedbackCtx) {
 		)
 	}
 }
+
+// waitForTask waits for the given task to reach a final status. In case an
+// interrupt is received meanwhile, the task is cancelled, so it stops issuing
+// fleet operations, and waiting continues until the task has stopped. A
+// second interrupt exits immediately.
+func waitForTask(ctx context.Context, taskID string, closer <-chan struct{}) (*task.Task, error) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-signals:
+		case <-done:
+			return
+		}
+
+		newLogger.Info(ctx, "Cancelling task. Interrupt again to exit immediately.")
+		if err := newTaskService.Cancel(ctx, taskID); err != nil {
+			newLogger.Error(ctx, "%#v", maskAny(err))
+			os.Exit(1)
+		}
+
+		select {
+		case <-signals:
+			os.Exit(1)
+		case <-done:
+		}
+	}()
+
+	taskObject, err := newController.WaitForTask(ctx, taskID, closer)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	return taskObject, nil
+}
+
+// expandHome replaces a leading "~" of the given path with the home directory
+// of the current user.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	return filepath.Join(os.Getenv("HOME"), path[1:])
+}
+
+// identityPassphraseEnv is the environment variable the passphrase of an
+// encrypted SSH identity file is read from.
+const identityPassphraseEnv = "INAGOCTL_SSH_IDENTITY_PASSPHRASE"
+
+// identityPassphrase returns the passphrase of the SSH identity file. In case
+// identityPassphraseEnv is not set, the user is asked for the passphrase.
+func identityPassphrase() ([]byte, error) {
+	if passphrase := os.Getenv(identityPassphraseEnv); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return nil, maskAnyf(invalidArgumentsError, "SSH identity file is encrypted, set %s", identityPassphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, "Enter passphrase for SSH identity file: ")
+	passphrase, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	return passphrase, nil
+}
+
+// printPlan prints the given fleet operations recorded during a dry run.
+func printPlan(descriptor, group string, operations []fleet.Operation) {
+	if len(operations) == 0 {
+		fmt.Printf("Nothing to %s for group '%s'.\n", descriptor, group)
+		return
+	}
+
+	fmt.Printf("Plan to %s group '%s':\n", descriptor, group)
+	for _, o := range operations {
+		fmt.Printf("  %s\n", o)
+	}
+}
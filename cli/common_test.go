@@ -2,6 +2,7 @@ package cli
 
 import (
 	"net"
+	"os"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -215,3 +216,110 @@ func unloadedUnitStatus(name, sliceID, desiredState string) fleet.UnitStatus {
 		SliceID: sliceID,
 	}
 }
+
+func Test_Common_expandHome(t *testing.T) {
+	RegisterTestingT(t)
+
+	home := os.Getenv("HOME")
+	defer os.Setenv("HOME", home)
+	os.Setenv("HOME", "/home/test")
+
+	testCases := []struct {
+		Input    string
+		Expected string
+	}{
+		{Input: "~", Expected: "/home/test"},
+		{Input: "~/.inago/tasks", Expected: "/home/test/.inago/tasks"},
+		{Input: "/tmp/tasks", Expected: "/tmp/tasks"},
+		{Input: "tasks", Expected: "tasks"},
+		{Input: "~other/tasks", Expected: "~other/tasks"},
+	}
+
+	for _, testCase := range testCases {
+		Expect(expandHome(testCase.Input)).To(Equal(testCase.Expected))
+	}
+}
+
+func Test_Common_createStatusOutput(t *testing.T) {
+	RegisterTestingT(t)
+
+	timer := loadedUnitStatus("example-foo@1.timer", "1", "172.17.8.101", "505e0d7802d7439a924c269b76f34b5f", "launched", "launched")
+	timer.Machine[0].SystemdActive = "active"
+	timer.Machine[0].SystemdSub = "waiting"
+
+	usl := controller.UnitStatusList{
+		loadedUnitStatus("example-foo@1.service", "1", "172.17.8.101", "505e0d7802d7439a924c269b76f34b5f", "launched", "launched"),
+		timer,
+		unloadedUnitStatus("example-bar@2.service", "2", "loaded"),
+	}
+
+	output, err := createStatusOutput("example", usl)
+	Expect(err).To(BeNil())
+
+	Expect(output).To(Equal(statusOutput{
+		Group: "example",
+		Units: []unitStatusOutput{
+			{
+				Name:    "example-foo@1.service",
+				SliceID: "1",
+				Desired: "launched",
+				Current: "launched",
+				// The service is activated by the timer, so it has the status of the
+				// timer.
+				Status: controller.StatusRunning,
+				Machines: []machineStatusOutput{
+					{
+						ID:            "505e0d7802d7439a924c269b76f34b5f",
+						IP:            "172.17.8.101",
+						SystemdActive: "inactive",
+						SystemdSub:    "inactive",
+						UnitHash:      "4311",
+						Status:        controller.StatusRunning,
+					},
+				},
+			},
+			{
+				Name:    "example-foo@1.timer",
+				SliceID: "1",
+				Desired: "launched",
+				Current: "launched",
+				Status:  controller.StatusRunning,
+				Machines: []machineStatusOutput{
+					{
+						ID:            "505e0d7802d7439a924c269b76f34b5f",
+						IP:            "172.17.8.101",
+						SystemdActive: "active",
+						SystemdSub:    "waiting",
+						UnitHash:      "4311",
+						Status:        controller.StatusRunning,
+					},
+				},
+			},
+			{
+				Name:     "example-bar@2.service",
+				SliceID:  "2",
+				Desired:  "loaded",
+				Current:  "inactive",
+				Status:   controller.StatusStopped,
+				Machines: []machineStatusOutput{},
+			},
+		},
+	}))
+}
+
+func Test_Common_createStatusOutput_UnknownStatus(t *testing.T) {
+	RegisterTestingT(t)
+
+	// Systemd states not known to the StatusIndex must not prevent printing the
+	// status of the group.
+	us := loadedUnitStatus("example-foo@1.service", "1", "172.17.8.101", "505e0d7802d7439a924c269b76f34b5f", "launched", "launched")
+	us.Machine[0].SystemdActive = "maintenance"
+	us.Machine[0].SystemdSub = "unknown"
+
+	output, err := createStatusOutput("example", controller.UnitStatusList{us})
+	Expect(err).To(BeNil())
+	Expect(output.Units).To(HaveLen(1))
+	Expect(output.Units[0].Status).To(Equal(controller.StatusUnknown))
+	Expect(output.Units[0].Machines).To(HaveLen(1))
+	Expect(output.Units[0].Machines[0].Status).To(Equal(controller.StatusUnknown))
+}
@@ -10,9 +10,9 @@ import (
 
 var (
 	destroyCmd = &cobra.Command{
-		Use:   "destroy <group[@slice]...>",
+		Use:   "destroy <group[@slice]...> | destroy <group>...",
 		Short: "Destroy a group",
-		Long:  "Destroy the specified group, or slices",
+		Long:  "Destroy the specified group, or slices. Multiple groups are destroyed in the reverse order of their dependencies",
 		Run:   destroyRun,
 	}
 )
@@ -25,6 +25,11 @@ func destroyRun(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	if isMultipleGroupsArgs(args) {
+		destroyGroups(cmd, args)
+		return
+	}
+
 	var err error
 	newRequestConfig := controller.DefaultRequestConfig()
 	newRequestConfig.Group, newRequestConfig.SliceIDs, err = parseGroupCLIArgs(args)
@@ -37,7 +42,7 @@ func destroyRun(cmd *cobra.Command, args []string) {
 	// in case no slice id was provided, we extend the request with all
 	// slice ids seen in fleet
 	if len(newRequestConfig.SliceIDs) == 0 {
-		req, err = newController.ExtendWithExistingSliceIDs(req)
+		req, err = newController.ExtendWithExistingSliceIDs(newCtx, req)
 		if err != nil {
 			newLogger.Error(newCtx, "%#v", maskAny(err))
 			os.Exit(1)
@@ -58,3 +63,22 @@ func destroyRun(cmd *cobra.Command, args []string) {
 		Closer:     nil,
 	})
 }
+
+// destroyGroups destroys the given groups one after another, in the reverse
+// order of their dependencies. This way no group is destroyed before the
+// groups depending on it.
+func destroyGroups(cmd *cobra.Command, groups []string) {
+	newLogger.Debug(newCtx, "cli: starting destroy of multiple groups")
+
+	if globalFlags.NoBlock {
+		newLogger.Error(newCtx, "Destroying multiple groups cannot be combined with --no-block.")
+		os.Exit(1)
+	}
+
+	groups, _, err := orderGroups(fs, groups)
+	handleOrderGroupsError(err)
+
+	for i := len(groups) - 1; i >= 0; i-- {
+		destroyRun(cmd, []string{groups[i]})
+	}
+}
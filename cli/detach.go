@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+// taskWorkerFlag is the name of the hidden flag marking the process started
+// by runDetached.
+const taskWorkerFlag = "task-worker"
+
+// announceFD is the file descriptor the detached worker announces its task on.
+// It is the first entry of exec.Cmd.ExtraFiles.
+const announceFD = 3
+
+var (
+	// detachableCmds are the commands creating tasks, which are executed by a
+	// detached worker process in case --no-block is given.
+	detachableCmds []*cobra.Command
+
+	// taskAnnounced is true once the detached worker announced its task. Only
+	// the first task of a command is announced, e.g. submitting the group in
+	// case of up.
+	taskAnnounced bool
+)
+
+func init() {
+	detachableCmds = []*cobra.Command{
+		submitCmd,
+		startCmd,
+		stopCmd,
+		destroyCmd,
+		upCmd,
+		updateCmd,
+		scaleCmd,
+		applyCmd,
+	}
+}
+
+// isDetachable checks whether the given command is executed by a detached
+// worker process in case --no-block is given.
+func isDetachable(cmd *cobra.Command) bool {
+	for _, c := range detachableCmds {
+		if c == cmd {
+			return true
+		}
+	}
+
+	return false
+}
+
+// runDetached executes the current command again using a worker process which
+// is detached from the terminal, and exits as soon as the worker announced its
+// task. Tasks are executed by the process creating them. Without the worker,
+// tasks of commands using --no-block would die with the CLI and end up being
+// abandoned. The output of the worker is written to <task-id>.log within the
+// given task directory. In case the worker exits without creating a task, its
+// output is printed and the CLI exits with the exit code of the worker.
+func runDetached(ctx context.Context, taskDir string) error {
+	if err := os.MkdirAll(taskDir, 0700); err != nil {
+		return maskAny(err)
+	}
+	logFile, err := os.OpenFile(filepath.Join(taskDir, fmt.Sprintf(".worker-%d.log", os.Getpid())), os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0600)
+	if err != nil {
+		return maskAny(err)
+	}
+	defer logFile.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return maskAny(err)
+	}
+	defer r.Close()
+
+	worker := exec.Command(os.Args[0], append(os.Args[1:], "--"+taskWorkerFlag)...)
+	worker.Stdout = logFile
+	worker.Stderr = logFile
+	worker.ExtraFiles = []*os.File{w}
+	// The worker gets its own session, so it neither receives signals sent to
+	// the terminal, nor dies with it.
+	worker.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := worker.Start(); err != nil {
+		w.Close()
+		return maskAny(err)
+	}
+	// Only the worker holds the write end now. Reading returns EOF as soon as
+	// the worker announced its task, or exited.
+	w.Close()
+
+	announcement, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return maskAny(err)
+	}
+	if fields := strings.SplitN(strings.TrimSpace(announcement), " ", 2); len(fields) == 2 {
+		os.Rename(logFile.Name(), filepath.Join(taskDir, fields[0]+".log"))
+		newLogger.Info(ctx, "%s", fields[1])
+		os.Exit(0)
+	}
+
+	// The worker failed before creating a task.
+	waitErr := worker.Wait()
+	logFile.Seek(0, 0)
+	io.Copy(os.Stderr, logFile)
+	os.Remove(logFile.Name())
+	if exitErr, ok := waitErr.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			os.Exit(status.ExitStatus())
+		}
+	}
+	if waitErr != nil {
+		return maskAny(waitErr)
+	}
+	os.Exit(0)
+
+	return nil
+}
+
+// announceTask hands the task of the given context to the process waiting in
+// runDetached, which exits then. Only the first task is announced.
+func announceTask(ctx context.Context, bctx blockWithFeedbackCtx) {
+	if taskAnnounced {
+		return
+	}
+	taskAnnounced = true
+
+	message := fmt.Sprintf("Created task '%s' to %s group '%s'.", bctx.TaskID, bctx.Descriptor, bctx.Request.Group)
+	newLogger.Info(ctx, "%s", message)
+
+	announcer := os.NewFile(announceFD, "announce")
+	if announcer == nil {
+		return
+	}
+	defer announcer.Close()
+	fmt.Fprintf(announcer, "%s %s\n", bctx.TaskID, message)
+}
@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func Test_Detach_isDetachable(t *testing.T) {
+	for _, cmd := range []*cobra.Command{submitCmd, startCmd, stopCmd, destroyCmd, upCmd, updateCmd, scaleCmd, applyCmd} {
+		if !isDetachable(cmd) {
+			t.Fatalf("isDetachable(%s) = false, expected true", cmd.Name())
+		}
+	}
+
+	// Commands not creating tasks, or only waiting for them, are never
+	// detached.
+	for _, cmd := range []*cobra.Command{statusCmd, listCmd, diffCmd, taskCmd, updatePauseCmd, updateResumeCmd} {
+		if isDetachable(cmd) {
+			t.Fatalf("isDetachable(%s) = true, expected false", cmd.Name())
+		}
+	}
+}
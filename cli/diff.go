@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/inago/controller"
+)
+
+var (
+	diffCmd = &cobra.Command{
+		Use:   "diff <group>",
+		Short: "Diff a group",
+		Long:  "Show the changes between the units of a group deployed to the cluster and the version on the local filesystem",
+		Run:   diffRun,
+	}
+)
+
+func diffRun(cmd *cobra.Command, args []string) {
+	newLogger.Debug(newCtx, "cli: starting diff")
+
+	if len(args) != 1 {
+		cmd.Help()
+		os.Exit(1)
+	}
+
+	newRequestConfig := controller.DefaultRequestConfig()
+	newRequestConfig.Group = args[0]
+	req := controller.NewRequest(newRequestConfig)
+
+	req, err := extendRequestWithContent(fs, req)
+	if err != nil {
+		newLogger.Error(newCtx, "%#v", maskAny(err))
+		os.Exit(1)
+	}
+	req.SliceIDs = nil
+
+	diffs, err := newController.Diff(newCtx, req)
+	handleStatusCmdError(newCtx, req, err)
+
+	for _, d := range diffs {
+		fmt.Println(createDiffHeader(d))
+		if d.Diff != "" {
+			fmt.Print(d.Diff)
+		}
+	}
+}
+
+// createDiffHeader describes the state of the slices of the given diff, e.g.
+// "app@.service [1 2]: changed (drift)".
+func createDiffHeader(d controller.UnitDiff) string {
+	header := d.Name
+	if len(d.SliceIDs) > 0 {
+		header += fmt.Sprintf(" %v", d.SliceIDs)
+	}
+
+	switch {
+	case d.Missing:
+		header += ": missing"
+	case d.Diff != "":
+		header += ": changed"
+	default:
+		header += ": up to date"
+	}
+
+	if d.Drift {
+		header += " (drift)"
+	}
+
+	return header
+}
@@ -12,6 +12,18 @@ var (
 	maskAny = errgo.MaskFunc(errgo.Any)
 )
 
+func maskAnyf(err error, f string, v ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+
+	f = fmt.Sprintf("%s: %s", err.Error(), f)
+	newErr := errgo.WithCausef(nil, errgo.Cause(err), f, v...)
+	newErr.(*errgo.Err).SetLocation(1)
+
+	return newErr
+}
+
 var invalidArgumentsError = errgo.Newf("invalid arguments")
 
 // IsInvalidArgumentsError checks whether the given command line
@@ -20,6 +32,22 @@ func IsInvalidArgumentsError(err error) bool {
 	return errgo.Cause(err) == invalidArgumentsError
 }
 
+var invalidManifestError = errgo.New("invalid manifest")
+
+// IsInvalidManifest checks whether the given error indicates the manifest
+// file declaring group dependencies being malformed.
+func IsInvalidManifest(err error) bool {
+	return errgo.Cause(err) == invalidManifestError
+}
+
+var invalidValuesError = errgo.New("invalid values")
+
+// IsInvalidValues checks whether the given error indicates the values unit
+// files are rendered with being malformed.
+func IsInvalidValues(err error) bool {
+	return errgo.Cause(err) == invalidValuesError
+}
+
 // FormatValidationError returns the CausingErrors formatted:
 // Validation Error found:
 //		* unit slice not found
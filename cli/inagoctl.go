@@ -3,7 +3,9 @@
 package cli
 
 import (
+	"fmt"
 	"net/url"
+	"os"
 	"time"
 
 	"github.com/spf13/afero"
@@ -13,6 +15,7 @@ import (
 	"github.com/giantswarm/inago/controller"
 	"github.com/giantswarm/inago/fleet"
 	"github.com/giantswarm/inago/logging"
+	"github.com/giantswarm/inago/retry"
 	"github.com/giantswarm/inago/task"
 )
 
@@ -20,18 +23,37 @@ var (
 	globalFlags struct {
 		FleetEndpoint string
 		NoBlock       bool
+		DryRun        bool
 		Verbose       bool
+		LogFormat     string
+		TaskDir       string
+		Set           []string
+		TaskWorker    bool
+
+		RetryMaxAttempts int
+
+		CAFile             string
+		CertFile           string
+		KeyFile            string
+		InsecureSkipVerify bool
 
 		Tunnel                   string
 		SSHUsername              string
 		SSHTimeout               time.Duration
 		SSHStrictHostKeyChecking bool
 		SSHKnownHostsFile        string
+		SSHJumpHosts             []string
+		SSHMaxConnections        int
+		SSHIdentityFile          string
+		SSHAgentSocket           string
+		SSHForwardAgent          bool
+		SSHKeepAliveInterval     time.Duration
 	}
 
 	fs             afero.Afero
 	newLogger      logging.Logger
 	newFleet       fleet.Fleet
+	newPlanFleet   *fleet.PlanFleet
 	newTaskService task.Service
 	newController  controller.Controller
 
@@ -52,26 +74,72 @@ var (
 			if globalFlags.Verbose {
 				loggingConfig.LogLevel = "DEBUG"
 			}
+			switch globalFlags.LogFormat {
+			case logging.FormatText, logging.FormatJSON:
+				loggingConfig.Format = globalFlags.LogFormat
+			default:
+				fmt.Fprintf(os.Stderr, "invalid log format '%s': must be one of %s, %s\n", globalFlags.LogFormat, logging.FormatText, logging.FormatJSON)
+				os.Exit(1)
+			}
 			newLogger = logging.NewLogger(loggingConfig)
 
+			newCtx = context.Background()
+
+			if globalFlags.NoBlock && !globalFlags.DryRun && !globalFlags.TaskWorker && isDetachable(cmd) {
+				// The task has to outlive this process. See runDetached.
+				if err := runDetached(newCtx, expandHome(globalFlags.TaskDir)); err != nil {
+					newLogger.Warning(newCtx, "Failed to detach task worker. Blocking until the task finished. (%s)", err.Error())
+					globalFlags.NoBlock = false
+				}
+			}
+
 			URL, err := url.Parse(globalFlags.FleetEndpoint)
 			if err != nil {
 				panic(err)
 			}
 
 			newFleetConfig := fleet.DefaultConfig()
+			newFleetConfig.CAFile = expandHome(globalFlags.CAFile)
+			newFleetConfig.CertFile = expandHome(globalFlags.CertFile)
 			newFleetConfig.Endpoint = *URL
+			newFleetConfig.InsecureSkipVerify = globalFlags.InsecureSkipVerify
+			newFleetConfig.KeyFile = expandHome(globalFlags.KeyFile)
 			newFleetConfig.Logger = newLogger
+			newFleetRetryPolicyConfig := retry.DefaultConfig()
+			newFleetRetryPolicyConfig.Logger = newLogger
+			newFleetRetryPolicyConfig.MaxAttempts = globalFlags.RetryMaxAttempts
+			newFleetRetryPolicyConfig.InitialBackoff = 100 * time.Millisecond
+			newFleetRetryPolicyConfig.MaxBackoff = 2 * time.Second
+			newFleetRetryPolicyConfig.IsRetryable = fleet.IsRetryable
+			newFleetConfig.RetryPolicy = retry.NewPolicy(newFleetRetryPolicyConfig)
+			var newSSHTunnel fleet.SSHTunnel
 			if globalFlags.Tunnel != "" {
 				newSSHTunnelConfig := fleet.DefaultSSHTunnelConfig()
+				newSSHTunnelConfig.AgentSocket = globalFlags.SSHAgentSocket
 				newSSHTunnelConfig.Endpoint = *URL
+				newSSHTunnelConfig.ForwardAgent = globalFlags.SSHForwardAgent
+				newSSHTunnelConfig.IdentityFile = globalFlags.SSHIdentityFile
+				newSSHTunnelConfig.IdentityPassphrase = identityPassphrase
+				newSSHTunnelConfig.KeepAliveInterval = globalFlags.SSHKeepAliveInterval
+				for _, jumpHost := range globalFlags.SSHJumpHosts {
+					hop, err := fleet.ParseSSHHop(jumpHost, fleet.SSHHop{
+						Username:              globalFlags.SSHUsername,
+						StrictHostKeyChecking: globalFlags.SSHStrictHostKeyChecking,
+					})
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "invalid jump host: %s\n", err)
+						os.Exit(1)
+					}
+					newSSHTunnelConfig.JumpHosts = append(newSSHTunnelConfig.JumpHosts, hop)
+				}
 				newSSHTunnelConfig.KnownHostsFile = globalFlags.SSHKnownHostsFile
 				newSSHTunnelConfig.Logger = newLogger
+				newSSHTunnelConfig.MaxConnections = globalFlags.SSHMaxConnections
 				newSSHTunnelConfig.StrictHostKeyChecking = globalFlags.SSHStrictHostKeyChecking
 				newSSHTunnelConfig.Timeout = globalFlags.SSHTimeout
 				newSSHTunnelConfig.Tunnel = globalFlags.Tunnel
 				newSSHTunnelConfig.Username = globalFlags.SSHUsername
-				newSSHTunnel, err := fleet.NewSSHTunnel(newSSHTunnelConfig)
+				newSSHTunnel, err = fleet.NewSSHTunnel(newSSHTunnelConfig)
 				if err != nil {
 					panic(err)
 				}
@@ -81,41 +149,103 @@ var (
 			if err != nil {
 				panic(err)
 			}
-
 			newTaskServiceConfig := task.DefaultConfig()
 			newTaskServiceConfig.Logger = newLogger
-			newTaskService = task.NewTaskService(newTaskServiceConfig)
-
 			newControllerConfig := controller.DefaultConfig()
 			newControllerConfig.Logger = newLogger
+			if globalFlags.DryRun {
+				// All operations changing the fleet cluster are recorded instead of
+				// being executed. The recorded operations are printed by
+				// maybeBlockWithFeedback.
+				newPlanConfig := fleet.DefaultPlanConfig()
+				newPlanConfig.Fleet = newFleet
+				newPlanConfig.Logger = newLogger
+				newPlanFleet = fleet.NewPlanFleet(newPlanConfig)
+				newFleet = newPlanFleet
+
+				// Planned operations take effect immediately. There is no need to
+				// wait for the fleet cluster to settle. Planned tasks only exist in
+				// memory.
+				newTaskServiceConfig.WaitSleep = 10 * time.Millisecond
+				newControllerConfig.WaitCount = 1
+				newControllerConfig.WaitSleep = 10 * time.Millisecond
+			} else {
+				// Tasks are stored on disk, so they can be looked up by other
+				// processes using the task command.
+				newFileStorageConfig := task.DefaultFileStorageConfig()
+				newFileStorageConfig.Dir = expandHome(globalFlags.TaskDir)
+				newFileStorage, err := task.NewFileStorage(newFileStorageConfig)
+				if err != nil {
+					newLogger.Warning(newCtx, "Failed to use task directory '%s'. Tasks cannot be looked up by other processes. (%s)", newFileStorageConfig.Dir, err.Error())
+				} else {
+					newTaskServiceConfig.Storage = newFileStorage
+				}
+			}
+			newTaskService = task.NewTaskService(newTaskServiceConfig)
+
 			newControllerConfig.Fleet = newFleet
 			newControllerConfig.TaskService = newTaskService
+			if newSSHTunnel != nil {
+				// Units are health checked from the tunnel host, as the machines
+				// are usually not reachable otherwise.
+				newControllerConfig.Dial = newSSHTunnel.Dial
+			}
+			// Paused updates are marked within the fleet cluster, so updates
+			// executed by other processes, on any machine, can be paused. During a
+			// dry run marking is recorded like any other fleet operation.
+			newFleetPauseStorageConfig := controller.DefaultFleetPauseStorageConfig()
+			newFleetPauseStorageConfig.Fleet = newFleet
+			newFleetPauseStorage, err := controller.NewFleetPauseStorage(newFleetPauseStorageConfig)
+			if err != nil {
+				panic(err)
+			}
+			newControllerConfig.PauseStorage = newFleetPauseStorage
 
 			newController = controller.NewController(newControllerConfig)
-
-			newCtx = context.Background()
 		},
 	}
 )
 
 func init() {
 	MainCmd.PersistentFlags().StringVar(&globalFlags.FleetEndpoint, "fleet-endpoint", "unix:///var/run/fleet.sock", "endpoint used to connect to fleet")
+	MainCmd.PersistentFlags().StringVar(&globalFlags.CAFile, "ca-file", "", "PEM encoded CA certificate bundle used to verify https fleet endpoints")
+	MainCmd.PersistentFlags().StringVar(&globalFlags.CertFile, "cert-file", "", "PEM encoded client certificate presented to https fleet endpoints")
+	MainCmd.PersistentFlags().StringVar(&globalFlags.KeyFile, "key-file", "", "PEM encoded private key of the client certificate")
+	MainCmd.PersistentFlags().BoolVar(&globalFlags.InsecureSkipVerify, "insecure-skip-verify", false, "do not verify the certificate of https fleet endpoints")
 	MainCmd.PersistentFlags().BoolVar(&globalFlags.NoBlock, "no-block", false, "block on synchronous actions")
+	MainCmd.PersistentFlags().BoolVar(&globalFlags.DryRun, "dry-run", false, "print the fleet operations a command would execute instead of executing them")
 	MainCmd.PersistentFlags().BoolVarP(&globalFlags.Verbose, "verbose", "v", false, "verbose output")
+	MainCmd.PersistentFlags().StringVar(&globalFlags.LogFormat, "log-format", logging.FormatText, "format of log output, either text or json")
+	MainCmd.PersistentFlags().StringVar(&globalFlags.TaskDir, "task-dir", "~/.inago/tasks", "directory used to store tasks, so they can be looked up using the task command")
+	MainCmd.PersistentFlags().StringSliceVar(&globalFlags.Set, "set", nil, "values used to render unit files, given as key=value")
+	MainCmd.PersistentFlags().BoolVar(&globalFlags.TaskWorker, taskWorkerFlag, false, "execute the task of a command using --no-block as detached worker")
+	MainCmd.PersistentFlags().MarkHidden(taskWorkerFlag)
+	MainCmd.PersistentFlags().IntVar(&globalFlags.RetryMaxAttempts, "retry-max-attempts", 3, "maximum number of attempts for fleet requests failing temporarily")
 
 	MainCmd.PersistentFlags().StringVar(&globalFlags.Tunnel, "tunnel", "", "use a tunnel to communicate with fleet")
 	MainCmd.PersistentFlags().StringVar(&globalFlags.SSHUsername, "ssh-username", "core", "username to use when connecting to CoreOS machine")
 	MainCmd.PersistentFlags().DurationVar(&globalFlags.SSHTimeout, "ssh-timeout", time.Duration(10*time.Second), "timeout in seconds when establishing the connection via SSH")
 	MainCmd.PersistentFlags().BoolVar(&globalFlags.SSHStrictHostKeyChecking, "ssh-strict-host-key-checking", true, "verify host keys presented by remote machines before initiating SSH connections")
 	MainCmd.PersistentFlags().StringVar(&globalFlags.SSHKnownHostsFile, "ssh-known-hosts-file", "~/.fleetctl/known_hosts", "file used to store remote machine fingerprints")
+	MainCmd.PersistentFlags().StringSliceVar(&globalFlags.SSHJumpHosts, "ssh-jump-hosts", nil, "jump hosts given as [user@]host[:port][?option=value] to connect through in order before connecting to the tunnel, options being strict-host-key-checking and known-hosts-file")
+	MainCmd.PersistentFlags().IntVar(&globalFlags.SSHMaxConnections, "ssh-max-connections", 4, "maximum number of SSH connections used to send requests to fleet in parallel")
+	MainCmd.PersistentFlags().StringVar(&globalFlags.SSHIdentityFile, "ssh-identity-file", "", "PEM encoded private key used to authenticate SSH connections, next to the keys of the ssh agent; the passphrase of encrypted keys is read from "+identityPassphraseEnv+" or asked for")
+	MainCmd.PersistentFlags().StringVar(&globalFlags.SSHAgentSocket, "ssh-agent-socket", "", "socket of the ssh agent used to authenticate SSH connections, defaults to SSH_AUTH_SOCK")
+	MainCmd.PersistentFlags().BoolVar(&globalFlags.SSHForwardAgent, "ssh-forward-agent", true, "forward the ssh agent to the tunnel host")
+	MainCmd.PersistentFlags().DurationVar(&globalFlags.SSHKeepAliveInterval, "ssh-keepalive-interval", 30*time.Second, "interval keepalive probes are sent in to detect broken SSH connections, 0 disables them")
 
 	MainCmd.AddCommand(submitCmd)
 	MainCmd.AddCommand(statusCmd)
+	MainCmd.AddCommand(listCmd)
 	MainCmd.AddCommand(startCmd)
 	MainCmd.AddCommand(stopCmd)
 	MainCmd.AddCommand(destroyCmd)
 	MainCmd.AddCommand(upCmd)
 	MainCmd.AddCommand(updateCmd)
+	MainCmd.AddCommand(scaleCmd)
+	MainCmd.AddCommand(applyCmd)
+	MainCmd.AddCommand(diffCmd)
+	MainCmd.AddCommand(taskCmd)
 	MainCmd.AddCommand(validateCmd)
 	MainCmd.AddCommand(versionCmd)
 }
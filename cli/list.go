@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/inago/controller"
+)
+
+var (
+	listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List groups",
+		Long:  "List all groups deployed to the cluster",
+		Run:   listRun,
+	}
+)
+
+func listRun(cmd *cobra.Command, args []string) {
+	newLogger.Debug(newCtx, "cli: starting list")
+
+	if len(args) != 0 {
+		cmd.Help()
+		os.Exit(1)
+	}
+
+	summaries, err := newController.List(newCtx)
+	if err != nil {
+		newLogger.Error(newCtx, "%#v", maskAny(err))
+		os.Exit(1)
+	}
+
+	fmt.Println(columnize.SimpleFormat(createList(summaries)))
+}
+
+// createList creates the rows of the table printed by the list command. Groups
+// without slices are unsliced groups, which is why their number of slices is
+// printed as "-".
+func createList(summaries []controller.GroupSummary) []string {
+	rows := []string{"Group | Slices | Status | Hashes", ""}
+
+	for _, s := range summaries {
+		slices := "-"
+		if len(s.SliceIDs) > 0 {
+			slices = fmt.Sprintf("%d", len(s.SliceIDs))
+		}
+
+		var statuses []string
+		for _, status := range s.Statuses {
+			statuses = append(statuses, string(status))
+		}
+
+		hashes := "consistent"
+		if !s.HashesEqual {
+			hashes = "inconsistent"
+		}
+
+		rows = append(rows, fmt.Sprintf("%s | %s | %s | %s", s.Group, slices, strings.Join(statuses, ","), hashes))
+	}
+
+	return rows
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/giantswarm/inago/controller"
+)
+
+func Test_List_createList(t *testing.T) {
+	RegisterTestingT(t)
+
+	summaries := []controller.GroupSummary{
+		{
+			Group:       "app",
+			SliceIDs:    []string{"1", "2"},
+			Statuses:    []controller.Status{controller.StatusFailed, controller.StatusRunning},
+			HashesEqual: false,
+		},
+		{
+			Group:       "db",
+			Statuses:    []controller.Status{controller.StatusRunning},
+			HashesEqual: true,
+		},
+	}
+
+	Expect(createList(summaries)).To(Equal([]string{
+		"Group | Slices | Status | Hashes",
+		"",
+		"app | 2 | failed,running | inconsistent",
+		"db | - | running | consistent",
+	}))
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+
+	"github.com/giantswarm/inago/controller"
+)
+
+// manifestFile is the name of the manifest file declaring the dependencies
+// between groups. It is placed next to the group directories, e.g.
+//
+//     groups:
+//       k8s-master:
+//         depends_on:
+//           - k8s-network
+//
+const manifestFile = "inago.yaml"
+
+type manifest struct {
+	Groups map[string]manifestGroup `yaml:"groups"`
+}
+
+type manifestGroup struct {
+	DependsOn []string `yaml:"depends_on"`
+}
+
+// readDependencies reads the group dependencies declared in the manifest file
+// of the current directory. In case there is no manifest file, no
+// dependencies are returned.
+func readDependencies(fs afero.Afero) (controller.Dependencies, error) {
+	raw, err := fs.ReadFile(manifestFile)
+	if os.IsNotExist(err) {
+		return controller.Dependencies{}, nil
+	} else if err != nil {
+		return nil, maskAny(err)
+	}
+
+	var m manifest
+	if err := yaml.Unmarshal(raw, &m); err != nil {
+		return nil, maskAnyf(invalidManifestError, "%s: %s", manifestFile, err.Error())
+	}
+
+	dependencies := controller.Dependencies{}
+	for group, g := range m.Groups {
+		dependencies[group] = g.DependsOn
+	}
+
+	return dependencies, nil
+}
+
+// orderGroups returns the given groups ordered such that each group comes
+// after the groups it depends on, together with the dependencies declared in
+// the manifest file.
+func orderGroups(fs afero.Afero, groups []string) ([]string, controller.Dependencies, error) {
+	dependencies, err := readDependencies(fs)
+	if err != nil {
+		return nil, nil, maskAny(err)
+	}
+
+	var requests []controller.Request
+	for _, group := range groups {
+		newRequestConfig := controller.DefaultRequestConfig()
+		newRequestConfig.Group = group
+		requests = append(requests, controller.NewRequest(newRequestConfig))
+	}
+
+	if ok, err := controller.ValidateMultipleRequestWithDependencies(requests, dependencies); !ok {
+		return nil, nil, err
+	}
+
+	requests, err = controller.OrderByDependencies(requests, dependencies)
+	if err != nil {
+		return nil, nil, maskAny(err)
+	}
+
+	var ordered []string
+	for _, req := range requests {
+		ordered = append(ordered, req.Group)
+	}
+
+	return ordered, dependencies, nil
+}
+
+// handleOrderGroupsError prints the given error returned by orderGroups and
+// exits.
+func handleOrderGroupsError(err error) {
+	if validationErr, ok := err.(controller.ValidationError); ok {
+		newLogger.Error(newCtx, "Groups are not valid globally: %v", FormatValidationError(validationErr))
+		os.Exit(1)
+	} else if err != nil {
+		newLogger.Error(newCtx, "%#v", maskAny(err))
+		os.Exit(1)
+	}
+}
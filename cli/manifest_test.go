@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+
+	"github.com/giantswarm/inago/controller"
+)
+
+func givenFileSystemWithManifest(content string) afero.Afero {
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	fs.WriteFile(manifestFile, []byte(content), os.FileMode(0644))
+	return fs
+}
+
+func TestReadDependencies_NoManifest(t *testing.T) {
+	RegisterTestingT(t)
+
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+
+	dependencies, err := readDependencies(fs)
+	Expect(err).To(BeNil())
+	Expect(dependencies).To(BeEmpty())
+}
+
+func TestReadDependencies_InvalidManifest(t *testing.T) {
+	RegisterTestingT(t)
+
+	fs := givenFileSystemWithManifest("groups: [")
+
+	_, err := readDependencies(fs)
+	Expect(IsInvalidManifest(err)).To(BeTrue())
+}
+
+func TestOrderGroups(t *testing.T) {
+	RegisterTestingT(t)
+
+	fs := givenFileSystemWithManifest(`groups:
+  k8s-master:
+    depends_on:
+      - k8s-network
+  k8s-node:
+    depends_on:
+      - k8s-master
+`)
+
+	groups, dependencies, err := orderGroups(fs, []string{"k8s-node", "k8s-network", "k8s-master"})
+	Expect(err).To(BeNil())
+	Expect(groups).To(Equal([]string{"k8s-network", "k8s-master", "k8s-node"}))
+	Expect(dependencies).To(Equal(controller.Dependencies{
+		"k8s-master": {"k8s-network"},
+		"k8s-node":   {"k8s-master"},
+	}))
+}
+
+func TestOrderGroups_Cycle(t *testing.T) {
+	RegisterTestingT(t)
+
+	fs := givenFileSystemWithManifest(`groups:
+  a:
+    depends_on: [b]
+  b:
+    depends_on: [a]
+`)
+
+	_, _, err := orderGroups(fs, []string{"a", "b"})
+	validationErr, ok := err.(controller.ValidationError)
+	Expect(ok).To(BeTrue())
+	Expect(validationErr.Contains(controller.IsGroupDependencyCycle)).To(BeTrue())
+}
+
+func TestIsMultipleGroupsArgs(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(isMultipleGroupsArgs([]string{"a"})).To(BeFalse())
+	Expect(isMultipleGroupsArgs([]string{"a", "3"})).To(BeFalse())
+	Expect(isMultipleGroupsArgs([]string{"a@1", "a@2"})).To(BeFalse())
+	Expect(isMultipleGroupsArgs([]string{"a", "a"})).To(BeFalse())
+	Expect(isMultipleGroupsArgs([]string{"a", "b"})).To(BeTrue())
+	Expect(isMultipleGroupsArgs([]string{"a", "b", "c"})).To(BeTrue())
+}
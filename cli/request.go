@@ -39,7 +39,9 @@ func readUnitFiles(fs afero.Afero, dir string) (map[string]string, error) {
 }
 
 // extendRequestWithContent reads all unitfiles for the given group and returns
-// a new Request with the Units filled.
+// a new Request with the Units filled. In case the group has a values file, or
+// values are given by --set, the Request renders unit files as templates. See
+// readValues.
 func extendRequestWithContent(fs afero.Afero, req controller.Request) (controller.Request, error) {
 	unitFiles, err := readUnitFiles(fs, req.Group)
 	if err != nil {
@@ -53,6 +55,22 @@ func extendRequestWithContent(fs afero.Afero, req controller.Request) (controlle
 		return controller.Request{}, errgo.Newf("No unit files found for group '%s'", req.Group)
 	}
 
+	values, err := readValues(fs, req.Group, globalFlags.Set)
+	if err != nil {
+		return controller.Request{}, maskAny(err)
+	}
+	if values != nil {
+		req.Renderer = newUnitRenderer(req.Group, values)
+
+		// Render each unit once, so broken templates are reported before any
+		// fleet operation is issued. Units are rendered for each slice later on.
+		for _, u := range req.Units {
+			if _, err := req.Renderer(u, controller.SliceValues{}); err != nil {
+				return controller.Request{}, maskAnyf(invalidValuesError, "unit '%s': %s", u.Name, err.Error())
+			}
+		}
+	}
+
 	return req, nil
 }
 
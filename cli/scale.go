@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/inago/controller"
+)
+
+var (
+	scaleCmd = &cobra.Command{
+		Use:   "scale <group> <scale>",
+		Short: "Scale a group",
+		Long:  "Change the number of slices of a group. Failed and stopped slices are removed first.",
+		Run:   scaleRun,
+	}
+)
+
+func scaleRun(cmd *cobra.Command, args []string) {
+	newLogger.Debug(newCtx, "cli: starting scale")
+
+	if len(args) != 2 {
+		cmd.Help()
+		os.Exit(1)
+	}
+
+	group := args[0]
+	scale, err := strconv.Atoi(args[1])
+	if err != nil {
+		newLogger.Error(newCtx, "%#v", maskAny(err))
+		os.Exit(1)
+	}
+
+	newRequestConfig := controller.DefaultRequestConfig()
+	newRequestConfig.Group = group
+	req := controller.NewRequest(newRequestConfig)
+	req, err = extendRequestWithContent(fs, req)
+	if err != nil {
+		newLogger.Error(newCtx, "%#v", maskAny(err))
+		os.Exit(1)
+	}
+
+	taskObject, err := newController.Scale(newCtx, req, scale)
+	if err != nil {
+		newLogger.Error(newCtx, "%#v", maskAny(err))
+		os.Exit(1)
+	}
+
+	// The slices being added or removed are not known before the task
+	// finished. Thus we report on the group as a whole.
+	req.SliceIDs = nil
+	maybeBlockWithFeedback(newCtx, blockWithFeedbackCtx{
+		Request:    req,
+		Descriptor: "scale",
+		NoBlock:    globalFlags.NoBlock,
+		TaskID:     taskObject.ID,
+		Closer:     nil,
+	})
+}
@@ -1,17 +1,27 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/mattn/go-isatty"
 	"github.com/ryanuber/columnize"
 	"github.com/spf13/cobra"
 	"golang.org/x/net/context"
+	"gopkg.in/yaml.v2"
 
 	"github.com/giantswarm/inago/controller"
 )
 
 var (
+	statusFlags struct {
+		Output string
+		Watch  bool
+	}
+
 	statusCmd = &cobra.Command{
 		Use:   "status <group>",
 		Short: "Get group status",
@@ -20,6 +30,21 @@ var (
 	}
 )
 
+func init() {
+	statusCmd.PersistentFlags().StringVarP(&statusFlags.Output, "output", "o", "table", "output format, either table, json or yaml")
+	statusCmd.PersistentFlags().BoolVarP(&statusFlags.Watch, "watch", "w", false, "keep polling the status and print status changes of slices")
+}
+
+const (
+	// watchHistory is the number of status changes printed below the status
+	// table while watching.
+	watchHistory = 10
+
+	ansiClearScreen = "\033[H\033[2J"
+	ansiHighlight   = "\033[1;33m"
+	ansiReset       = "\033[0m"
+)
+
 func statusRun(cmd *cobra.Command, args []string) {
 	newLogger.Debug(newCtx, "cli: starting status")
 
@@ -32,19 +57,58 @@ func statusRun(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	switch statusFlags.Output {
+	case "table", "json", "yaml":
+	default:
+		newLogger.Error(newCtx, "Invalid output format '%s'. Must be one of table, json, yaml.", statusFlags.Output)
+		os.Exit(1)
+	}
+
 	newRequestConfig := controller.DefaultRequestConfig()
 	newRequestConfig.Group = group
 	req := controller.NewRequest(newRequestConfig)
 
-	req, err := newController.ExtendWithExistingSliceIDs(req)
+	if statusFlags.Watch {
+		if statusFlags.Output != "table" {
+			newLogger.Error(newCtx, "Watching status only supports table output.")
+			os.Exit(1)
+		}
+		watchStatus(newCtx, req)
+		return
+	}
+
+	req, err := newController.ExtendWithExistingSliceIDs(newCtx, req)
 	handleStatusCmdError(newCtx, req, err)
 
 	statusList, err := newController.GetStatus(newCtx, req)
 	handleStatusCmdError(newCtx, req, err)
 
-	data, err := createStatus(req.Group, statusList)
+	paused, err := newController.IsUpdatePaused(newCtx, req)
 	handleStatusCmdError(newCtx, req, err)
-	fmt.Println(columnize.SimpleFormat(data))
+
+	if statusFlags.Output == "table" {
+		data, err := createStatus(req.Group, statusList)
+		handleStatusCmdError(newCtx, req, err)
+		fmt.Println(columnize.SimpleFormat(data))
+		if paused {
+			fmt.Println(formatUpdatePaused(req.Group))
+		}
+		return
+	}
+
+	output, err := createStatusOutput(req.Group, statusList)
+	handleStatusCmdError(newCtx, req, err)
+	output.UpdatePaused = paused
+
+	var raw []byte
+	if statusFlags.Output == "json" {
+		raw, err = json.MarshalIndent(output, "", "  ")
+		raw = append(raw, '\n')
+	} else {
+		raw, err = yaml.Marshal(output)
+	}
+	handleStatusCmdError(newCtx, req, err)
+	fmt.Print(string(raw))
 }
 
 func handleStatusCmdError(ctx context.Context, req controller.Request, err error) {
@@ -62,3 +126,110 @@ func handleStatusCmdError(ctx context.Context, req controller.Request, err error
 		os.Exit(1)
 	}
 }
+
+// watchStatus prints the status table of the given group on each poll, until
+// the process is interrupted. On terminals the table is redrawn, highlighting
+// slices whose status changed, followed by the most recent status changes.
+// Otherwise the table is printed once, followed by one line per status
+// change. Pausing and resuming updates is printed like a status change.
+func watchStatus(ctx context.Context, req controller.Request) {
+	terminal := isatty.IsTerminal(os.Stdout.Fd())
+
+	var history []string
+	first := true
+	paused := false
+	for event := range newController.WatchStatus(ctx, req) {
+		if event.Error != nil {
+			newLogger.Warning(ctx, "Failed to fetch status of group '%s': %v", req.Group, event.Error)
+			continue
+		}
+
+		var lines []string
+		for _, change := range event.Changes {
+			lines = append(lines, formatStatusChange(req.Group, event.Time, change))
+		}
+		if !first && event.UpdatePaused != paused {
+			state := "resumed"
+			if event.UpdatePaused {
+				state = "paused"
+			}
+			lines = append(lines, fmt.Sprintf("%s update %s", event.Time.Format("15:04:05"), state))
+		}
+		paused = event.UpdatePaused
+
+		if !terminal {
+			if first {
+				data, err := createStatus(req.Group, event.UnitStatusList)
+				handleStatusCmdError(ctx, req, err)
+				fmt.Println(columnize.SimpleFormat(data))
+			}
+			for _, line := range lines {
+				fmt.Println(line)
+			}
+			first = false
+			continue
+		}
+
+		history = append(history, lines...)
+		if len(history) > watchHistory {
+			history = history[len(history)-watchHistory:]
+		}
+
+		data, err := createStatus(req.Group, event.UnitStatusList)
+		handleStatusCmdError(ctx, req, err)
+		table := highlightStatusChanges(req.Group, columnize.SimpleFormat(data), event.Changes)
+
+		fmt.Print(ansiClearScreen)
+		fmt.Printf("Status of group '%s' at %s\n\n", req.Group, event.Time.Format(time.RFC1123))
+		fmt.Println(table)
+		if event.UpdatePaused {
+			fmt.Println(formatUpdatePaused(req.Group))
+		}
+		for _, line := range history {
+			fmt.Println(line)
+		}
+		first = false
+	}
+}
+
+// formatUpdatePaused returns the line printed below the status table in case
+// updates of the given group are paused.
+func formatUpdatePaused(group string) string {
+	return fmt.Sprintf("\nUpdate paused. Run 'inagoctl update resume %s' to continue.", group)
+}
+
+// formatStatusChange returns a timestamped line describing the given status
+// change, e.g. "15:04:05 abc starting -> running". Unsliced groups are
+// described by the group name.
+func formatStatusChange(group string, t time.Time, change controller.StatusChange) string {
+	name := change.SliceID
+	if name == "" {
+		name = group
+	}
+
+	return fmt.Sprintf("%s %s %s -> %s", t.Format("15:04:05"), name, change.From, change.To)
+}
+
+// highlightStatusChanges highlights the rows of the given status table which
+// belong to slices contained in changes.
+func highlightStatusChanges(group, table string, changes []controller.StatusChange) string {
+	if len(changes) == 0 {
+		return table
+	}
+
+	lines := strings.Split(table, "\n")
+	for i, line := range lines {
+		for _, change := range changes {
+			prefix := group
+			if change.SliceID != "" {
+				prefix += "@" + change.SliceID
+			}
+			if strings.HasPrefix(line, prefix+" ") {
+				lines[i] = ansiHighlight + line + ansiReset
+				break
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/giantswarm/inago/controller"
+)
+
+func Test_Status_formatStatusChange(t *testing.T) {
+	RegisterTestingT(t)
+
+	now := time.Date(2016, 4, 1, 15, 4, 5, 0, time.UTC)
+
+	Expect(formatStatusChange("app", now, controller.StatusChange{
+		SliceID: "abc",
+		From:    controller.StatusStarting,
+		To:      controller.StatusRunning,
+	})).To(Equal("15:04:05 abc starting -> running"))
+
+	Expect(formatStatusChange("app", now, controller.StatusChange{
+		From: controller.StatusRunning,
+		To:   controller.StatusFailed,
+	})).To(Equal("15:04:05 app running -> failed"))
+}
+
+func Test_Status_highlightStatusChanges(t *testing.T) {
+	RegisterTestingT(t)
+
+	table := "Group    | Units\n\napp@abc  | app-server@abc.service\napp@abcd | app-server@abcd.service"
+	changes := []controller.StatusChange{
+		{SliceID: "abc", From: controller.StatusStarting, To: controller.StatusRunning},
+	}
+
+	Expect(highlightStatusChanges("app", table, nil)).To(Equal(table))
+	Expect(highlightStatusChanges("app", table, changes)).To(Equal(
+		"Group    | Units\n\n" +
+			ansiHighlight + "app@abc  | app-server@abc.service" + ansiReset + "\n" +
+			"app@abcd | app-server@abcd.service",
+	))
+}
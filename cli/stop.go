@@ -35,7 +35,7 @@ func stopRun(cmd *cobra.Command, args []string) {
 	req := controller.NewRequest(newRequestConfig)
 
 	if len(newRequestConfig.SliceIDs) == 0 {
-		req, err = newController.ExtendWithExistingSliceIDs(req)
+		req, err = newController.ExtendWithExistingSliceIDs(newCtx, req)
 		if err != nil {
 			newLogger.Error(newCtx, "%#v", maskAny(err))
 			os.Exit(1)
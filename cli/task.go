@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/inago/task"
+)
+
+var (
+	taskCmd = &cobra.Command{
+		Use:   "task",
+		Short: "Inspect tasks",
+		Long:  "Inspect tasks created by inagoctl processes, e.g. when using --no-block",
+		Run:   taskRun,
+	}
+
+	taskStatusCmd = &cobra.Command{
+		Use:   "status <task-id>",
+		Short: "Get task status",
+		Long:  "Print the status of a task",
+		Run:   taskStatusRun,
+	}
+
+	taskWaitCmd = &cobra.Command{
+		Use:   "wait <task-id>",
+		Short: "Wait for a task",
+		Long:  "Wait for a task to finish. Exits non-zero in case the task did not succeed",
+		Run:   taskWaitRun,
+	}
+)
+
+func init() {
+	taskCmd.AddCommand(taskStatusCmd)
+	taskCmd.AddCommand(taskWaitCmd)
+}
+
+func taskRun(cmd *cobra.Command, args []string) {
+	cmd.Help()
+}
+
+func taskStatusRun(cmd *cobra.Command, args []string) {
+	newLogger.Debug(newCtx, "cli: starting task status")
+
+	if len(args) != 1 {
+		cmd.Help()
+		os.Exit(1)
+	}
+
+	taskObject, err := newTaskService.FetchState(newCtx, args[0])
+	handleTaskCmdError(args[0], err)
+
+	fmt.Println(createTaskStatus(taskObject))
+}
+
+func taskWaitRun(cmd *cobra.Command, args []string) {
+	newLogger.Debug(newCtx, "cli: starting task wait")
+
+	if len(args) != 1 {
+		cmd.Help()
+		os.Exit(1)
+	}
+
+	// The task is executed by another process. Thus it is not cancelled on
+	// interrupt, which is why the controller is used directly.
+	taskObject, err := newController.WaitForTask(newCtx, args[0], nil)
+	handleTaskCmdError(args[0], err)
+
+	fmt.Println(createTaskStatus(taskObject))
+	if !task.HasSucceededStatus(taskObject) {
+		os.Exit(1)
+	}
+}
+
+// createTaskStatus describes the status of the given task, e.g.
+// "Task '1234' failed. (unit not found)".
+func createTaskStatus(taskObject *task.Task) string {
+	switch {
+	case task.HasSucceededStatus(taskObject):
+		return fmt.Sprintf("Task '%s' succeeded.", taskObject.ID)
+	case task.HasCancelledStatus(taskObject):
+		return fmt.Sprintf("Task '%s' cancelled.", taskObject.ID)
+	case task.HasFailedStatus(taskObject):
+		return fmt.Sprintf("Task '%s' failed. (%s)", taskObject.ID, taskObject.Error.Error())
+	default:
+		return fmt.Sprintf("Task '%s' is running.", taskObject.ID)
+	}
+}
+
+func handleTaskCmdError(taskID string, err error) {
+	if task.IsTaskObjectNotFound(err) {
+		newLogger.Error(newCtx, "Failed to find task '%s'.", taskID)
+		os.Exit(1)
+	} else if err != nil {
+		newLogger.Error(newCtx, "%#v", maskAny(err))
+		os.Exit(1)
+	}
+}
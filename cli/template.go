@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+
+	"github.com/giantswarm/inago/controller"
+)
+
+// valuesFile is the name of the file within a group directory holding the
+// values unit files are rendered with.
+const valuesFile = "values.yaml"
+
+// templateData is the data unit files are rendered with.
+type templateData struct {
+	// Group is the name of the group.
+	Group string
+
+	// SliceID is the ID of the slice the unit is rendered for. This is empty
+	// for unsliced groups.
+	SliceID string
+
+	// Values holds the values read from the values file of the group, and the
+	// values given by --set.
+	Values map[string]interface{}
+}
+
+// readValues reads the values of the given group from its values file and
+// merges the given key=value pairs into them. In case there is neither a
+// values file nor any pair given, nil is returned, meaning unit files are not
+// rendered at all.
+func readValues(fs afero.Afero, group string, sets []string) (map[string]interface{}, error) {
+	var values map[string]interface{}
+
+	raw, err := fs.ReadFile(filepath.Join(group, valuesFile))
+	if os.IsNotExist(err) {
+		// There are no values for this group.
+	} else if err != nil {
+		return nil, maskAny(err)
+	} else {
+		values = map[string]interface{}{}
+		if err := yaml.Unmarshal(raw, &values); err != nil {
+			return nil, maskAnyf(invalidValuesError, "%s: %s", filepath.Join(group, valuesFile), err.Error())
+		}
+	}
+
+	for _, set := range sets {
+		split := strings.SplitN(set, "=", 2)
+		if len(split) != 2 || split[0] == "" {
+			return nil, maskAnyf(invalidValuesError, "expected key=value, got '%s'", set)
+		}
+		if values == nil {
+			values = map[string]interface{}{}
+		}
+		values[split[0]] = split[1]
+	}
+
+	return values, nil
+}
+
+// newUnitRenderer returns a controller.UnitRenderer rendering unit contents
+// as text/template, using the given group name and values. Referencing
+// missing values is an error.
+func newUnitRenderer(group string, values map[string]interface{}) controller.UnitRenderer {
+	return func(u controller.Unit, sliceValues controller.SliceValues) (string, error) {
+		tmpl, err := template.New(u.Name).Option("missingkey=error").Parse(u.Content)
+		if err != nil {
+			return "", maskAny(err)
+		}
+
+		data := templateData{
+			Group:   group,
+			SliceID: sliceValues.SliceID,
+			Values:  values,
+		}
+
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, data); err != nil {
+			return "", maskAny(err)
+		}
+
+		return out.String(), nil
+	}
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+
+	"github.com/giantswarm/inago/controller"
+)
+
+func TestReadValues(t *testing.T) {
+	RegisterTestingT(t)
+
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+
+	// Without values file and --set, templating is disabled.
+	values, err := readValues(fs, "foo", nil)
+	Expect(err).To(BeNil())
+	Expect(values).To(BeNil())
+
+	values, err = readValues(fs, "foo", []string{"tag=1.2=3"})
+	Expect(err).To(BeNil())
+	Expect(values).To(Equal(map[string]interface{}{"tag": "1.2=3"}))
+
+	fs.WriteFile("foo/"+valuesFile, []byte("tag: latest\nport: 8080\n"), os.FileMode(0644))
+	values, err = readValues(fs, "foo", []string{"tag=1.2"})
+	Expect(err).To(BeNil())
+	Expect(values).To(Equal(map[string]interface{}{"tag": "1.2", "port": 8080}))
+
+	_, err = readValues(fs, "foo", []string{"tag"})
+	Expect(IsInvalidValues(err)).To(BeTrue())
+
+	fs.WriteFile("foo/"+valuesFile, []byte("tag: ["), os.FileMode(0644))
+	_, err = readValues(fs, "foo", nil)
+	Expect(IsInvalidValues(err)).To(BeTrue())
+}
+
+func TestNewUnitRenderer(t *testing.T) {
+	RegisterTestingT(t)
+
+	renderer := newUnitRenderer("foo", map[string]interface{}{"tag": "1.2", "port": 8080})
+	u := controller.Unit{
+		Name:    "foo-server@.service",
+		Content: "ExecStart=/bin/{{.Group}}:{{.Values.tag}} --id={{.SliceID}} --port={{.Values.port}}",
+	}
+
+	content, err := renderer(u, controller.SliceValues{SliceID: "abc"})
+	Expect(err).To(BeNil())
+	Expect(content).To(Equal("ExecStart=/bin/foo:1.2 --id=abc --port=8080"))
+
+	u.Content = "ExecStart=/bin/foo:{{.Values.missing}}"
+	_, err = renderer(u, controller.SliceValues{})
+	Expect(err).NotTo(BeNil())
+}
+
+func TestExtendRequestWithContent_Values(t *testing.T) {
+	RegisterTestingT(t)
+
+	fs := givenFileSystemWithSliceableUnitGroup("foo")
+	fs.WriteFile("foo/foo-1@.service", []byte("ExecStart=/bin/foo:{{.Values.tag}}"), os.FileMode(0644))
+
+	newRequestConfig := controller.DefaultRequestConfig()
+	newRequestConfig.Group = "foo"
+
+	// Without values, unit files are not rendered.
+	req, err := extendRequestWithContent(fs, controller.NewRequest(newRequestConfig))
+	Expect(err).To(BeNil())
+	Expect(req.Renderer).To(BeNil())
+
+	// Referencing missing values fails early.
+	fs.WriteFile("foo/"+valuesFile, []byte("other: value\n"), os.FileMode(0644))
+	_, err = extendRequestWithContent(fs, controller.NewRequest(newRequestConfig))
+	Expect(IsInvalidValues(err)).To(BeTrue())
+
+	fs.WriteFile("foo/"+valuesFile, []byte("tag: latest\n"), os.FileMode(0644))
+	req, err = extendRequestWithContent(fs, controller.NewRequest(newRequestConfig))
+	Expect(err).To(BeNil())
+	Expect(req.Renderer).NotTo(BeNil())
+
+	req.SliceIDs = []string{"1"}
+	req, err = req.ExtendSlices()
+	Expect(err).To(BeNil())
+	Expect(req.Units[0].Content).To(Equal("ExecStart=/bin/foo:latest"))
+}
@@ -1,19 +1,30 @@
 package cli
 
 import (
+	"os"
+	"strconv"
+	"strings"
+
 	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/inago/controller"
 )
 
 var (
 	upCmd = &cobra.Command{
-		Use:   "up <group> [scale]",
+		Use:   "up <group> [scale] | up <group>...",
 		Short: "Bring a group up",
-		Long:  "Submit a group, with an optional scale, and start it",
+		Long:  "Submit a group, with an optional scale, and start it. Multiple groups are brought up in the order of their dependencies",
 		Run:   upRun,
 	}
 )
 
 func upRun(cmd *cobra.Command, args []string) {
+	if isMultipleGroupsArgs(args) {
+		upGroups(cmd, args)
+		return
+	}
+
 	submitRun(cmd, args)
 
 	// If a scale argument has been passed to submit,
@@ -24,3 +35,62 @@ func upRun(cmd *cobra.Command, args []string) {
 
 	startRun(cmd, args)
 }
+
+// upGroups brings up the given groups one after another, in the order of their
+// dependencies. Before a group is submitted, all groups it depends on have to
+// be running, including groups not given.
+func upGroups(cmd *cobra.Command, groups []string) {
+	newLogger.Debug(newCtx, "cli: starting up of multiple groups")
+
+	if globalFlags.NoBlock {
+		newLogger.Error(newCtx, "Bringing up multiple groups cannot be combined with --no-block.")
+		os.Exit(1)
+	}
+
+	groups, dependencies, err := orderGroups(fs, groups)
+	handleOrderGroupsError(err)
+
+	for _, group := range groups {
+		for _, dependency := range dependencies[group] {
+			newRequestConfig := controller.DefaultRequestConfig()
+			newRequestConfig.Group = dependency
+			req := controller.NewRequest(newRequestConfig)
+
+			err := newController.WaitForStatus(newCtx, req, nil, controller.StatusRunning)
+			if controller.IsUnitNotFound(err) {
+				newLogger.Error(newCtx, "Failed to find group '%s' required by group '%s'.", dependency, group)
+				os.Exit(1)
+			} else if err != nil {
+				newLogger.Error(newCtx, "%#v", maskAny(err))
+				os.Exit(1)
+			}
+		}
+
+		submitRun(cmd, []string{group})
+		startRun(cmd, []string{group})
+	}
+}
+
+// isMultipleGroupsArgs checks whether the given arguments name multiple
+// groups, rather than a single group and an optional scale, or slices of a
+// single group.
+func isMultipleGroupsArgs(args []string) bool {
+	if len(args) < 2 {
+		return false
+	}
+	if _, err := strconv.Atoi(args[1]); err == nil && len(args) == 2 {
+		return false
+	}
+
+	multiple := false
+	for _, arg := range args {
+		if strings.Contains(arg, "@") {
+			return false
+		}
+		if arg != args[0] {
+			multiple = true
+		}
+	}
+
+	return multiple
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/giantswarm/inago/controller"
+	"github.com/giantswarm/inago/task"
 )
 
 var (
@@ -14,6 +15,19 @@ var (
 		MaxGrowth int
 		MinAlive  int
 		ReadySecs int
+		Rollback  bool
+		Strategy  string
+
+		HealthCheck                 string
+		HealthCheckPath             string
+		HealthCheckTimeoutSecs      int
+		HealthCheckSuccessThreshold int
+		HealthCheckGraceSecs        int
+
+		Canary         int
+		CanaryGateSecs int
+		Promote        bool
+		Abort          bool
 	}
 
 	updateCmd = &cobra.Command{
@@ -22,12 +36,40 @@ var (
 		Long:  "Update a group to the latest version on the local filesystem",
 		Run:   updateRun,
 	}
+
+	updatePauseCmd = &cobra.Command{
+		Use:   "pause <group>",
+		Short: "Pause updates of a group",
+		Long:  "Pause updates of a group. Slices currently being updated are finished, further slices are not updated until resumed",
+		Run:   updatePauseRun,
+	}
+
+	updateResumeCmd = &cobra.Command{
+		Use:   "resume <group>",
+		Short: "Resume updates of a group",
+		Long:  "Resume updates of a group paused using update pause",
+		Run:   updateResumeRun,
+	}
 )
 
 func init() {
+	updateCmd.AddCommand(updatePauseCmd)
+	updateCmd.AddCommand(updateResumeCmd)
+
 	updateCmd.PersistentFlags().IntVar(&updateFlags.MaxGrowth, "max-growth", 1, "maximum number of group slices added at a time")
 	updateCmd.PersistentFlags().IntVar(&updateFlags.MinAlive, "min-alive", 1, "minimum number of group slices staying alive at a time")
 	updateCmd.PersistentFlags().IntVar(&updateFlags.ReadySecs, "ready-secs", 30, "number of seconds to sleep before updating the next group slice")
+	updateCmd.PersistentFlags().BoolVar(&updateFlags.Rollback, "rollback", false, "restore the previous group slices if the update fails")
+	updateCmd.PersistentFlags().StringVar(&updateFlags.Strategy, "strategy", string(controller.StrategyRolling), "strategy used to replace group slices: rolling or blue-green")
+	updateCmd.PersistentFlags().StringVar(&updateFlags.HealthCheck, "health-check", "", "health check new group slices need to pass: http or tcp, disabled if empty")
+	updateCmd.PersistentFlags().StringVar(&updateFlags.HealthCheckPath, "health-check-path", "/", "path requested by http health checks")
+	updateCmd.PersistentFlags().IntVar(&updateFlags.HealthCheckTimeoutSecs, "health-check-timeout-secs", 5, "number of seconds a single health check may take")
+	updateCmd.PersistentFlags().IntVar(&updateFlags.HealthCheckSuccessThreshold, "health-check-success-threshold", 1, "number of health checks in a row a new group slice needs to pass")
+	updateCmd.PersistentFlags().IntVar(&updateFlags.HealthCheckGraceSecs, "health-check-grace-secs", 60, "number of seconds a new group slice has to pass the health check")
+	updateCmd.PersistentFlags().IntVar(&updateFlags.Canary, "canary", 0, "number of group slices updated first, before the update pauses")
+	updateCmd.PersistentFlags().IntVar(&updateFlags.CanaryGateSecs, "canary-gate-secs", 0, "number of seconds canary slices need to be running to continue the update automatically, rolling them back otherwise")
+	updateCmd.PersistentFlags().BoolVar(&updateFlags.Promote, "promote", false, "update the remaining group slices of a paused canary update")
+	updateCmd.PersistentFlags().BoolVar(&updateFlags.Abort, "abort", false, "roll back the canary slices of a paused canary update")
 }
 
 func updateRun(cmd *cobra.Command, args []string) {
@@ -48,42 +90,163 @@ func updateRun(cmd *cobra.Command, args []string) {
 
 	req, err := extendRequestWithContent(fs, req)
 	handleUpdateCmdError(err)
-	req, err = newController.ExtendWithExistingSliceIDs(req)
+	req, err = newController.ExtendWithExistingSliceIDs(newCtx, req)
 	handleUpdateCmdError(err)
 
+	if updateFlags.Promote && updateFlags.Abort {
+		newLogger.Error(newCtx, "Cannot combine --promote and --abort.")
+		os.Exit(1)
+	}
+
 	opts := controller.UpdateOptions{
-		MaxGrowth: updateFlags.MaxGrowth,
-		MinAlive:  updateFlags.MinAlive,
-		ReadySecs: updateFlags.ReadySecs,
+		MaxGrowth:      updateFlags.MaxGrowth,
+		MinAlive:       updateFlags.MinAlive,
+		ReadySecs:      updateFlags.ReadySecs,
+		Rollback:       updateFlags.Rollback,
+		Strategy:       controller.UpdateStrategy(updateFlags.Strategy),
+		Canary:         updateFlags.Canary,
+		CanaryGateSecs: updateFlags.CanaryGateSecs,
+		HealthCheck: controller.HealthCheck{
+			Type:             controller.HealthCheckType(updateFlags.HealthCheck),
+			Path:             updateFlags.HealthCheckPath,
+			TimeoutSecs:      updateFlags.HealthCheckTimeoutSecs,
+			SuccessThreshold: updateFlags.HealthCheckSuccessThreshold,
+			GraceSecs:        updateFlags.HealthCheckGraceSecs,
+		},
 
 		// TODO Verbosity flag for displaying feedback about the current update steps?
 		// TODO Force flag for forcing the update even if the unit hashes do not differ?
 	}
 
-	taskObject, err := newController.Update(newCtx, req, opts)
+	if globalFlags.DryRun {
+		// Waiting for slices to become ready does not change the operations
+		// being planned. Planned slices cannot be health checked.
+		opts.ReadySecs = 0
+		opts.HealthCheck.Type = controller.HealthCheckNone
+
+		// A paused update would wait until resumed, so no operations can be
+		// planned.
+		paused, err := newController.IsUpdatePaused(newCtx, req)
+		handleUpdateCmdError(err)
+		if paused {
+			newLogger.Error(newCtx, "Updates of group '%s' are paused. Resume them to plan the update.", req.Group)
+			os.Exit(1)
+		}
+	}
+
+	var taskObject *task.Task
+	switch {
+	case updateFlags.Promote:
+		taskObject, err = newController.PromoteCanary(newCtx, req, opts)
+	case updateFlags.Abort:
+		taskObject, err = newController.AbortCanary(newCtx, req, opts)
+	default:
+		taskObject, err = newController.Update(newCtx, req, opts)
+	}
 	handleUpdateCmdError(err)
+
+	descriptor := "update"
+	if updateFlags.Abort {
+		descriptor = "abort canary of"
+	}
+	if globalFlags.NoBlock {
+		maybeBlockWithFeedback(newCtx, blockWithFeedbackCtx{
+			Request:    req,
+			Descriptor: descriptor,
+			NoBlock:    true,
+			TaskID:     taskObject.ID,
+			Closer:     nil,
+		})
+		return
+	}
+
 	// The update creates new slices. Thus new slice IDs. We want to give the
 	// feedback about the new slice IDs at the end. So we need to fetch the new
 	// slice IDs once the task has finished. We don't want to mix this specific
 	// detail with the general implementation of maybeBlockWithFeedback. Thus we
 	// wait for the task to be finished here manually.
-	taskObject, err = newController.WaitForTask(newCtx, taskObject.ID, nil)
+	taskObject, err = waitForTask(newCtx, taskObject.ID, nil)
 	handleUpdateCmdError(err)
 
-	req, err = newController.ExtendWithExistingSliceIDs(req)
+	req, err = newController.ExtendWithExistingSliceIDs(newCtx, req)
 	handleUpdateCmdError(err)
 
 	maybeBlockWithFeedback(newCtx, blockWithFeedbackCtx{
 		Request:    req,
-		Descriptor: "update",
+		Descriptor: descriptor,
 		NoBlock:    false,
 		TaskID:     taskObject.ID,
 		Closer:     nil,
 	})
+
+	if opts.Canary > 0 && opts.CanaryGateSecs <= 0 && !updateFlags.Promote && !updateFlags.Abort {
+		logCanary(req)
+	}
+}
+
+// logCanary logs the canary of the given group in case the update paused
+// after updating the canary slices.
+func logCanary(req controller.Request) {
+	canary, err := newController.GetCanary(newCtx, req)
+	if controller.IsCanaryNotFound(err) {
+		return
+	}
+	handleUpdateCmdError(err)
+
+	newLogger.Info(newCtx, "Updated canary slices %v of group '%s'. Run update again using --promote to update the outdated slices %v, or --abort to roll the canary slices back.", canary.SliceIDs, req.Group, canary.OutdatedSliceIDs)
+}
+
+func updatePauseRun(cmd *cobra.Command, args []string) {
+	newLogger.Debug(newCtx, "cli: starting update pause")
+
+	if len(args) != 1 {
+		cmd.Help()
+		os.Exit(1)
+	}
+
+	newRequestConfig := controller.DefaultRequestConfig()
+	newRequestConfig.Group = args[0]
+	req := controller.NewRequest(newRequestConfig)
+
+	err := newController.PauseUpdate(newCtx, req)
+	handleUpdateCmdError(err)
+
+	if newPlanFleet != nil {
+		printPlan("pause updates of", req.Group, newPlanFleet.Flush())
+		return
+	}
+
+	newLogger.Info(newCtx, "Paused updates of group '%s'.", req.Group)
+}
+
+func updateResumeRun(cmd *cobra.Command, args []string) {
+	newLogger.Debug(newCtx, "cli: starting update resume")
+
+	if len(args) != 1 {
+		cmd.Help()
+		os.Exit(1)
+	}
+
+	newRequestConfig := controller.DefaultRequestConfig()
+	newRequestConfig.Group = args[0]
+	req := controller.NewRequest(newRequestConfig)
+
+	err := newController.ResumeUpdate(newCtx, req)
+	handleUpdateCmdError(err)
+
+	if newPlanFleet != nil {
+		printPlan("resume updates of", req.Group, newPlanFleet.Flush())
+		return
+	}
+
+	newLogger.Info(newCtx, "Resumed updates of group '%s'.", req.Group)
 }
 
 func handleUpdateCmdError(err error) {
-	if err != nil {
+	if controller.IsCanaryNotFound(err) {
+		newLogger.Error(newCtx, "Failed to find canary. Either no slice or all slices of the group are up to date.")
+		os.Exit(1)
+	} else if err != nil {
 		fmt.Printf("%#v\n", maskAny(err))
 		os.Exit(1)
 	}
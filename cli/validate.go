@@ -77,7 +77,13 @@ func validateRun(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	ok, err := controller.ValidateMultipleRequest(requests)
+	dependencies, err := readDependencies(fs)
+	if err != nil {
+		newLogger.Error(newCtx, "%#v", maskAny(err))
+		os.Exit(1)
+	}
+
+	ok, err := controller.ValidateMultipleRequestWithDependencies(requests, dependencies)
 	if ok {
 		fmt.Println("Groups are valid globally.")
 	} else {
@@ -50,3 +50,15 @@ func UnitBase(name string) string {
 	name = groupExp.ReplaceAllString(name, "")
 	return ExtExp.ReplaceAllString(name, "")
 }
+
+// UnitType returns the type of the unit, which is the extension of the unit
+// name, if any.
+//
+//   app@1.service  =>  .service
+//   app@1.timer    =>  .timer
+//   app.socket     =>  .socket
+//   app@1          =>
+//
+func UnitType(name string) string {
+	return ExtExp.FindString(name)
+}
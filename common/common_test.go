@@ -160,3 +160,43 @@ func ExampleUnitBase() {
 	// app
 	// app
 }
+
+func Test_UnitType(t *testing.T) {
+	var testCases = []struct {
+		Input    string
+		Expected string
+	}{
+		{
+			Input:    "app@1.service",
+			Expected: ".service",
+		},
+		{
+			Input:    "app@foo.timer",
+			Expected: ".timer",
+		},
+		{
+			Input:    "app@.socket",
+			Expected: ".socket",
+		},
+		{
+			Input:    "app.path",
+			Expected: ".path",
+		},
+		{
+			Input:    "app.mount",
+			Expected: ".mount",
+		},
+
+		{
+			Input:    "app@1",
+			Expected: "",
+		},
+	}
+
+	for i, testCase := range testCases {
+		output := UnitType(testCase.Input)
+		if output != testCase.Expected {
+			t.Fatal("case", i+1, "expected", testCase.Expected, "got", output)
+		}
+	}
+}
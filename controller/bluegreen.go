@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// updateBlueGreen updates all slices of the given group at once. A new set of
+// slices, as many as given by req, is submitted and started next to the
+// existing ones. Once all new slices are running and opts.ReadySecs have
+// passed, all existing slices are stopped and destroyed in one step. In case
+// any new slice fails to run, the new slices are removed again and the
+// existing slices are left untouched. The returned error can then be
+// identified using IsUpdateFailed, or IsRollbackFailed in case removing the
+// new slices failed as well.
+func (c controller) updateBlueGreen(ctx context.Context, req Request, opts UpdateOptions) error {
+	c.Config.Logger.Debug(ctx, "controller: running blue-green update for group '%v'", req.Group)
+
+	if !req.isSliceable() {
+		return maskAnyf(updateNotAllowedError, "cannot update unsliceable group")
+	}
+	for _, sliceID := range req.SliceIDs {
+		if sliceID == "" {
+			return maskAnyf(updateNotAllowedError, "group misses slice ID")
+		}
+	}
+
+	// Create new random IDs for the whole new set of slices.
+	newReq := req
+	newReq.DesiredSlices = len(req.SliceIDs)
+	newReq.SliceIDs = nil
+	newReq, err := c.ExtendWithRandomSliceIDs(ctx, newReq)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	addCtx := context.WithValue(ctx, "add slice", newReq.SliceIDs)
+	c.Config.Logger.Info(addCtx, "controller: adding slices %v", newReq.SliceIDs)
+
+	err = c.runBlueGreenAddWorker(addCtx, newReq, opts)
+	if err != nil {
+		c.Config.Logger.Error(addCtx, "controller: error encountered adding slices: %v", err)
+		if ctx.Err() != nil {
+			// The update got cancelled. Removing the new slices would issue
+			// further fleet operations, which is what cancelling wants to
+			// prevent.
+			return maskAny(err)
+		}
+
+		c.Config.Logger.Info(addCtx, "controller: removing slices %v, keeping slices %v", newReq.SliceIDs, req.SliceIDs)
+		if removeErr := c.removeBlueGreenSlices(addCtx, newReq); removeErr != nil {
+			return maskAnyf(rollbackFailedError, "%s (%s)", removeErr.Error(), err.Error())
+		}
+		return maskAnyf(updateFailedError, "removed new slices %v (%s)", newReq.SliceIDs, err.Error())
+	}
+
+	removeCtx := context.WithValue(ctx, "remove slice", req.SliceIDs)
+	c.Config.Logger.Info(removeCtx, "controller: removing slices %v", req.SliceIDs)
+
+	if err := c.runRemoveWorker(removeCtx, req); err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// runBlueGreenAddWorker submits and starts all slices of the given request,
+// checks their health and checks they are still running after opts.ReadySecs.
+func (c controller) runBlueGreenAddWorker(ctx context.Context, req Request, opts UpdateOptions) error {
+	if err := c.executeTaskAction(c.Submit, ctx, req); err != nil {
+		return maskAny(err)
+	}
+	if err := c.executeTaskAction(c.Start, ctx, req); err != nil {
+		return maskAny(err)
+	}
+	if err := c.checkHealth(ctx, req, opts); err != nil {
+		return maskAny(err)
+	}
+
+	if err := sleep(ctx, time.Duration(opts.ReadySecs)*time.Second); err != nil {
+		return maskAny(err)
+	}
+
+	n, err := c.getNumRunningSlices(ctx, req)
+	if err != nil {
+		return maskAny(err)
+	}
+	if n != len(req.SliceIDs) {
+		return maskAnyf(updateFailedError, "blue-green: slices not running: %d != %v", n, req.SliceIDs)
+	}
+
+	return nil
+}
+
+// removeBlueGreenSlices removes the slices of the given request that exist.
+// Slices may be missing in case submitting them failed.
+func (c controller) removeBlueGreenSlices(ctx context.Context, req Request) error {
+	existingSliceIDs, err := c.getExistingSliceIDs(ctx, req)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	var sliceIDs []string
+	for _, sliceID := range req.SliceIDs {
+		if contains(existingSliceIDs, sliceID) {
+			sliceIDs = append(sliceIDs, sliceID)
+		}
+	}
+	if len(sliceIDs) == 0 {
+		return nil
+	}
+
+	removeReq := req
+	removeReq.SliceIDs = sliceIDs
+	if err := c.runRemoveWorker(ctx, removeReq); err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
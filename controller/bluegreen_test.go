@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/fleet"
+)
+
+// sliceIDsOf returns the sorted slice IDs of all units of group "falcon".
+func sliceIDsOf(t *testing.T, testController controller, req Request) []string {
+	sliceIDs, err := testController.getExistingSliceIDs(context.Background(), req)
+	if err != nil {
+		t.Fatal("Error returned looking up slice IDs:", err)
+	}
+	sort.Strings(sliceIDs)
+	return sliceIDs
+}
+
+// watchDummyFleet calls f with the locked dummy fleet until the returned
+// function is called.
+func watchDummyFleet(dummyFleet *fleet.DummyFleet, f func()) func() {
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+
+			dummyFleet.Mutex.Lock()
+			f()
+			dummyFleet.Mutex.Unlock()
+		}
+	}()
+
+	return func() { close(stop) }
+}
+
+// TestUpdate_BlueGreen tests that a blue-green update starts all new slices
+// next to the old ones before removing the old ones.
+func TestUpdate_BlueGreen(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	testController.WaitSleep = 10 * time.Millisecond
+	req := givenCanaryGroup(dummyFleet, "1", "2", "3")
+
+	var maxUnits int
+	stop := watchDummyFleet(dummyFleet, func() {
+		if len(dummyFleet.Units) > maxUnits {
+			maxUnits = len(dummyFleet.Units)
+		}
+	})
+
+	// MaxGrowth and MinAlive would not allow a rolling update.
+	opts := UpdateOptions{
+		MaxGrowth: 0,
+		MinAlive:  3,
+		Strategy:  StrategyBlueGreen,
+	}
+	err := waitForUpdate(t, testController, req, opts)
+	stop()
+	if err != nil {
+		t.Fatal("Update failed:", err)
+	}
+
+	if maxUnits != 6 {
+		t.Fatal("Expected old and new slices to exist side by side, got max units:", maxUnits)
+	}
+	sliceIDs := sliceIDsOf(t, testController, req)
+	if len(sliceIDs) != 3 {
+		t.Fatal("Expected 3 slices, got:", sliceIDs)
+	}
+	for _, sliceID := range []string{"1", "2", "3"} {
+		if contains(sliceIDs, sliceID) {
+			t.Fatal("Expected old slice to be removed:", sliceID)
+		}
+	}
+	for _, content := range unitContents(dummyFleet) {
+		if content != canaryNewContent {
+			t.Fatal("Expected all slices to be updated, got:", content)
+		}
+	}
+}
+
+// TestUpdate_BlueGreen_Failed tests that a failing blue-green update removes
+// the new slices and leaves the old ones untouched.
+func TestUpdate_BlueGreen_Failed(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	testController.WaitSleep = 10 * time.Millisecond
+	testController.WaitTimeout = 500 * time.Millisecond
+	req := givenCanaryGroup(dummyFleet, "1", "2")
+
+	stop := watchDummyFleet(dummyFleet, func() {
+		for name, content := range dummyFleet.Contents {
+			us := dummyFleet.Units[name]
+			if content == canaryNewContent && us.Current == "launched" {
+				us.Machine = append([]fleet.MachineStatus{}, us.Machine...)
+				us.Machine[0].SystemdActive = "failed"
+				us.Machine[0].SystemdSub = "failed"
+				dummyFleet.Units[name] = us
+			}
+		}
+	})
+	defer stop()
+
+	opts := UpdateOptions{
+		Strategy: StrategyBlueGreen,
+	}
+	err := waitForUpdate(t, testController, req, opts)
+	if !IsUpdateFailed(err) {
+		t.Fatal("Expected update to fail, got:", err)
+	}
+
+	sliceIDs := sliceIDsOf(t, testController, req)
+	if len(sliceIDs) != 2 || sliceIDs[0] != "1" || sliceIDs[1] != "2" {
+		t.Fatal("Expected old slices to be kept, got:", sliceIDs)
+	}
+	for _, content := range unitContents(dummyFleet) {
+		if content != canaryOldContent {
+			t.Fatal("Expected old slices to be untouched, got:", content)
+		}
+	}
+	n, err := testController.getNumRunningSlices(context.Background(), req)
+	if err != nil {
+		t.Fatal("Error returned by getNumRunningSlices:", err)
+	}
+	if n != 2 {
+		t.Fatal("Expected old slices to be running, got:", n)
+	}
+}
+
+// TestUpdate_BlueGreen_Canary tests that the blue-green strategy cannot be
+// combined with a canary update.
+func TestUpdate_BlueGreen_Canary(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	req := givenCanaryGroup(dummyFleet, "1", "2")
+
+	opts := UpdateOptions{
+		MaxGrowth: 1,
+		MinAlive:  1,
+		Canary:    1,
+		Strategy:  StrategyBlueGreen,
+	}
+	_, err := testController.Update(context.Background(), req, opts)
+	if !IsUpdateNotAllowed(err) {
+		t.Fatal("Expected update not to be allowed, got:", err)
+	}
+}
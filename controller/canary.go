@@ -0,0 +1,271 @@
+package controller
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/fleet"
+	"github.com/giantswarm/inago/task"
+)
+
+// Canary represents the slices of a group during a canary update. Canaries
+// are not tracked anywhere but fleet. Slices running the unit contents given
+// by the request are considered canaries, while all other slices are
+// considered outdated. This way a canary update can be promoted or aborted
+// even after the process that started it exited.
+type Canary struct {
+	// SliceIDs contains the IDs of the slices already updated.
+	SliceIDs []string
+
+	// OutdatedSliceIDs contains the IDs of the slices not yet updated.
+	OutdatedSliceIDs []string
+}
+
+func (c controller) GetCanary(ctx context.Context, req Request) (Canary, error) {
+	c.Config.Logger.Debug(ctx, "controller: looking up canary of group '%v'", req.Group)
+
+	sliceIDs, err := c.getExistingSliceIDs(ctx, req)
+	if err != nil {
+		return Canary{}, maskAny(err)
+	}
+	req.SliceIDs = sliceIDs
+
+	dirtyReq, ok, err := c.GroupNeedsUpdate(ctx, req)
+	if err != nil {
+		return Canary{}, maskAny(err)
+	}
+	if !ok {
+		return Canary{}, maskAnyf(canaryNotFoundError, "all slices of group '%s' are up to date", req.Group)
+	}
+
+	canary := Canary{
+		OutdatedSliceIDs: dirtyReq.SliceIDs,
+	}
+	for _, sliceID := range sliceIDs {
+		if !contains(dirtyReq.SliceIDs, sliceID) {
+			canary.SliceIDs = append(canary.SliceIDs, sliceID)
+		}
+	}
+	if len(canary.SliceIDs) == 0 {
+		return Canary{}, maskAnyf(canaryNotFoundError, "no slice of group '%s' is up to date", req.Group)
+	}
+
+	return canary, nil
+}
+
+func (c controller) PromoteCanary(ctx context.Context, req Request, opts UpdateOptions) (*task.Task, error) {
+	c.Config.Logger.Debug(ctx, "controller: handling promoting canary of group '%v'", req.Group)
+
+	if _, err := c.GetCanary(ctx, req); err != nil {
+		return nil, maskAny(err)
+	}
+
+	opts.Canary = 0
+	taskObject, err := c.Update(ctx, req, opts)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	return taskObject, nil
+}
+
+func (c controller) AbortCanary(ctx context.Context, req Request, opts UpdateOptions) (*task.Task, error) {
+	c.Config.Logger.Debug(ctx, "controller: handling aborting canary of group '%v'", req.Group)
+
+	canary, err := c.GetCanary(ctx, req)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	action := func(ctx context.Context) error {
+		return maskAny(c.abortCanary(ctx, req, canary, opts))
+	}
+	taskObject, err := c.TaskService.Create(ctx, action)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	return taskObject, nil
+}
+
+// abortCanary replaces the canary slices of the given canary by slices running
+// the unit contents of the outdated slices. The previous unit contents are
+// recovered from the first outdated slice using previousUnits.
+func (c controller) abortCanary(ctx context.Context, req Request, canary Canary, opts UpdateOptions) error {
+	c.Config.Logger.Info(ctx, "controller: rolling back canary slices %v of group '%v'", canary.SliceIDs, req.Group)
+
+	previousReq, err := c.previousUnits(ctx, req, canary.OutdatedSliceIDs[0])
+	if err != nil {
+		return maskAny(err)
+	}
+	previousReq.SliceIDs = canary.SliceIDs
+
+	err = c.UpdateWithStrategy(ctx, previousReq, canaryUpdateOptions(opts, len(previousReq.SliceIDs)))
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// previousSliceIDPlaceholder replaces the slice ID within unit contents
+// recovered by previousUnits. Unit files never contain NUL bytes, so the
+// placeholder cannot clash with the recovered contents.
+const previousSliceIDPlaceholder = "\x00slice-id\x00"
+
+// previousUnits returns the given request carrying the unit contents deployed
+// to the given slice. The contents are taken from fleet, so they are rendered
+// already, e.g. for the given slice. To render them for other slices, each
+// occurrence of the given slice ID as a whole word is considered to be
+// rendered from the slice ID. Thus unrelated words equal to the slice ID, like
+// the "1" of "--workers=1" in case of slice "1", are rendered as slice ID as
+// well.
+func (c controller) previousUnits(ctx context.Context, req Request, sliceID string) (Request, error) {
+	sliceReq := req
+	sliceReq.SliceIDs = []string{sliceID}
+	sliceReq.Units = append([]Unit{}, req.Units...)
+	extended, err := sliceReq.ExtendSlices()
+	if err != nil {
+		return Request{}, maskAny(err)
+	}
+
+	sliceIDExp := regexp.MustCompile(`\b` + regexp.QuoteMeta(sliceID) + `\b`)
+
+	previousReq := req
+	previousReq.Units = append([]Unit{}, req.Units...)
+	previousReq.Renderer = renderPreviousUnit
+
+	// ExtendSlices keeps the order of the units for each slice. Thus the
+	// extended units map to the units of the request by their index.
+	for i, u := range extended.Units {
+		content, err := c.Fleet.GetUnitContent(ctx, u.Name)
+		if err != nil {
+			return Request{}, maskAny(err)
+		}
+		previousReq.Units[i].Content = sliceIDExp.ReplaceAllLiteralString(content, previousSliceIDPlaceholder)
+	}
+
+	return previousReq, nil
+}
+
+// renderPreviousUnit renders unit contents recovered by previousUnits for the
+// slice described by values.
+func renderPreviousUnit(u Unit, values SliceValues) (string, error) {
+	return strings.Replace(u.Content, previousSliceIDPlaceholder, values.SliceID, -1), nil
+}
+
+// checkCanary watches the given canary slices for the given duration. All
+// slices must be running on each check. A slice not running, e.g. because it
+// failed, fails the check with an error that you can identify using
+// IsCanaryUnhealthy. Slices are only checked every WaitSleep. Units restarted
+// in between are thus only noticed in case they moved to another machine, or
+// their unit hash changed. Restarts on the same machine go unnoticed.
+func (c controller) checkCanary(ctx context.Context, req Request, canary Canary, d time.Duration) error {
+	c.Config.Logger.Info(ctx, "controller: checking health of canary slices %v of group '%v' for %v", canary.SliceIDs, req.Group, d)
+
+	canaryReq := req
+	canaryReq.SliceIDs = canary.SliceIDs
+
+	// seen holds the machine state of each canary unit as found on the first
+	// check.
+	seen := map[string]fleet.MachineStatus{}
+
+	deadline := time.Now().Add(d)
+	for {
+		unitStatusList, err := c.groupStatusWithValidate(ctx, canaryReq)
+		if IsUnitNotFound(err) || IsUnitSliceNotFound(err) {
+			return maskAnyf(canaryUnhealthyError, "canary slices %v disappeared", canary.SliceIDs)
+		} else if err != nil {
+			return maskAny(err)
+		}
+
+		sliceStatuses, err := c.sliceStatuses(unitStatusList)
+		if err != nil {
+			return maskAny(err)
+		}
+		for _, sliceID := range canary.SliceIDs {
+			if status := sliceStatuses[sliceID]; status != StatusRunning {
+				return maskAnyf(canaryUnhealthyError, "canary slice '%s' is %s", sliceID, status)
+			}
+		}
+
+		for _, us := range unitStatusList {
+			var ms fleet.MachineStatus
+			if len(us.Machine) > 0 {
+				ms = us.Machine[0]
+			}
+			previous, ok := seen[us.Name]
+			if !ok {
+				seen[us.Name] = ms
+				continue
+			}
+			if ms.ID != previous.ID {
+				return maskAnyf(canaryUnhealthyError, "canary unit '%s' moved from machine '%s' to '%s'", us.Name, previous.ID, ms.ID)
+			}
+			if ms.UnitHash != previous.UnitHash {
+				return maskAnyf(canaryUnhealthyError, "canary unit '%s' changed from hash '%s' to '%s'", us.Name, previous.UnitHash, ms.UnitHash)
+			}
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil
+		}
+		if err := sleep(ctx, c.WaitSleep); err != nil {
+			return maskAny(err)
+		}
+	}
+}
+
+// updateCanary continues a canary update once the canary slices have been
+// updated. In case opts.CanaryGateSecs is positive, the canary slices are
+// checked for that long. A healthy canary gets promoted. An unhealthy canary
+// gets aborted, returning an error that you can identify using
+// IsCanaryRolledBack. Without gate the update ends, leaving the canary to be
+// promoted or aborted manually.
+func (c controller) updateCanary(ctx context.Context, req Request, opts UpdateOptions) error {
+	if opts.CanaryGateSecs <= 0 {
+		return nil
+	}
+
+	canary, err := c.GetCanary(ctx, req)
+	if IsCanaryNotFound(err) {
+		// All slices have been updated already. There is nothing left to
+		// promote.
+		return nil
+	} else if err != nil {
+		return maskAny(err)
+	}
+
+	err = c.checkCanary(ctx, req, canary, time.Duration(opts.CanaryGateSecs)*time.Second)
+	if IsCanaryUnhealthy(err) {
+		if abortErr := c.abortCanary(ctx, req, canary, opts); abortErr != nil {
+			return maskAnyf(rollbackFailedError, "%s (%s)", abortErr.Error(), err.Error())
+		}
+		return maskAnyf(canaryRolledBackError, "%s", err.Error())
+	} else if err != nil {
+		return maskAny(err)
+	}
+
+	c.Config.Logger.Info(ctx, "controller: promoting canary slices %v of group '%v'", canary.SliceIDs, req.Group)
+
+	outdatedReq := req
+	outdatedReq.SliceIDs = canary.OutdatedSliceIDs
+	if err := c.UpdateWithStrategy(ctx, outdatedReq, canaryUpdateOptions(opts, len(outdatedReq.SliceIDs))); err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// canaryUpdateOptions returns the given options adjusted to update the given
+// number of slices. Only as many slices can be kept alive as are updated.
+func canaryUpdateOptions(opts UpdateOptions, numSlices int) UpdateOptions {
+	if opts.MinAlive > numSlices {
+		opts.MinAlive = numSlices
+	}
+
+	return opts
+}
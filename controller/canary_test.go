@@ -0,0 +1,281 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/fleet"
+)
+
+const (
+	canaryOldContent = "[Service]\nExecStart=/bin/old\n"
+	canaryNewContent = "[Service]\nExecStart=/bin/new\n"
+)
+
+// givenCanaryGroup submits and starts the given slices of group "falcon" using
+// the old content, and returns a request carrying the new content.
+func givenCanaryGroup(dummyFleet *fleet.DummyFleet, sliceIDs ...string) Request {
+	for _, sliceID := range sliceIDs {
+		dummyFleet.Submit(context.Background(), "falcon-unit@"+sliceID+".service", canaryOldContent)
+		dummyFleet.Start(context.Background(), "falcon-unit@"+sliceID+".service")
+	}
+
+	return Request{
+		RequestConfig: RequestConfig{
+			Group:    "falcon",
+			SliceIDs: sliceIDs,
+		},
+		Units: []Unit{
+			{
+				Name:    "falcon-unit@.service",
+				Content: canaryNewContent,
+			},
+		},
+	}
+}
+
+// unitContents returns the contents of all units of group "falcon".
+func unitContents(dummyFleet *fleet.DummyFleet) []string {
+	dummyFleet.Mutex.Lock()
+	defer dummyFleet.Mutex.Unlock()
+
+	var contents []string
+	for name, content := range dummyFleet.Contents {
+		if strings.HasPrefix(name, "falcon-unit@") {
+			contents = append(contents, content)
+		}
+	}
+	return contents
+}
+
+func waitForUpdate(t *testing.T, testController controller, req Request, opts UpdateOptions) error {
+	taskObject, err := testController.Update(context.Background(), req, opts)
+	if err != nil {
+		t.Fatal("Error returned by update:", err)
+	}
+	taskObject, err = testController.WaitForTask(context.Background(), taskObject.ID, nil)
+	if err != nil {
+		t.Fatal("Error returned waiting for update:", err)
+	}
+	return taskObject.Error
+}
+
+// TestUpdate_Canary tests that a canary update updates only the canary slices,
+// which can then be promoted.
+func TestUpdate_Canary(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	testController.WaitSleep = 10 * time.Millisecond
+	req := givenCanaryGroup(dummyFleet, "1", "2", "3")
+
+	opts := UpdateOptions{
+		MaxGrowth: 1,
+		MinAlive:  1,
+		Canary:    1,
+	}
+	if err := waitForUpdate(t, testController, req, opts); err != nil {
+		t.Fatal("Update failed:", err)
+	}
+
+	canary, err := testController.GetCanary(context.Background(), req)
+	if err != nil {
+		t.Fatal("Error returned by GetCanary:", err)
+	}
+	if len(canary.SliceIDs) != 1 || len(canary.OutdatedSliceIDs) != 2 {
+		t.Fatalf("Expected 1 canary and 2 outdated slices, got %#v", canary)
+	}
+
+	taskObject, err := testController.PromoteCanary(context.Background(), req, opts)
+	if err != nil {
+		t.Fatal("Error returned by PromoteCanary:", err)
+	}
+	taskObject, err = testController.WaitForTask(context.Background(), taskObject.ID, nil)
+	if err != nil || taskObject.Error != nil {
+		t.Fatal("Promoting canary failed:", err, taskObject.Error)
+	}
+
+	_, err = testController.GetCanary(context.Background(), req)
+	if !IsCanaryNotFound(err) {
+		t.Fatal("Expected no canary after promoting, got:", err)
+	}
+	for _, content := range unitContents(dummyFleet) {
+		if content != canaryNewContent {
+			t.Fatal("Expected all slices to be updated, got:", content)
+		}
+	}
+}
+
+// TestUpdate_Canary_Abort tests that aborting a canary restores the previous
+// unit contents.
+func TestUpdate_Canary_Abort(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	testController.WaitSleep = 10 * time.Millisecond
+	req := givenCanaryGroup(dummyFleet, "1", "2")
+
+	opts := UpdateOptions{
+		MaxGrowth: 1,
+		MinAlive:  1,
+		Canary:    1,
+	}
+	if err := waitForUpdate(t, testController, req, opts); err != nil {
+		t.Fatal("Update failed:", err)
+	}
+
+	taskObject, err := testController.AbortCanary(context.Background(), req, opts)
+	if err != nil {
+		t.Fatal("Error returned by AbortCanary:", err)
+	}
+	taskObject, err = testController.WaitForTask(context.Background(), taskObject.ID, nil)
+	if err != nil || taskObject.Error != nil {
+		t.Fatal("Aborting canary failed:", err, taskObject.Error)
+	}
+
+	contents := unitContents(dummyFleet)
+	if len(contents) != 2 {
+		t.Fatal("Expected 2 slices, got:", len(contents))
+	}
+	for _, content := range contents {
+		if content != canaryOldContent {
+			t.Fatal("Expected all slices to be restored, got:", content)
+		}
+	}
+}
+
+// TestUpdate_Canary_Gate tests that a canary failing during the gate gets
+// rolled back automatically.
+func TestUpdate_Canary_Gate(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	testController.WaitSleep = 10 * time.Millisecond
+	req := givenCanaryGroup(dummyFleet, "1", "2")
+
+	// Fail the canary once the replaced slice is gone, i.e. once the canary
+	// slice is updated and the gate is checked.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+
+			dummyFleet.Mutex.Lock()
+			if len(dummyFleet.Units) != 2 {
+				dummyFleet.Mutex.Unlock()
+				continue
+			}
+			for name, content := range dummyFleet.Contents {
+				us := dummyFleet.Units[name]
+				if content == canaryNewContent && us.Current == "launched" {
+					us.Machine = append([]fleet.MachineStatus{}, us.Machine...)
+					us.Machine[0].SystemdActive = "failed"
+					us.Machine[0].SystemdSub = "failed"
+					dummyFleet.Units[name] = us
+				}
+			}
+			dummyFleet.Mutex.Unlock()
+		}
+	}()
+
+	opts := UpdateOptions{
+		MaxGrowth:      1,
+		MinAlive:       0,
+		Canary:         1,
+		CanaryGateSecs: 1,
+	}
+	err := waitForUpdate(t, testController, req, opts)
+	if !IsCanaryRolledBack(err) {
+		t.Fatal("Expected canary to be rolled back, got:", err)
+	}
+
+	for _, content := range unitContents(dummyFleet) {
+		if content != canaryOldContent {
+			t.Fatal("Expected all slices to be restored, got:", content)
+		}
+	}
+}
+
+// TestUpdate_Canary_Abort_Renderer tests that the previous unit contents are
+// rendered for each slice replacing a canary slice, instead of all slices
+// running the contents of the first outdated slice.
+func TestUpdate_Canary_Abort_Renderer(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	testController.WaitSleep = 10 * time.Millisecond
+
+	for _, sliceID := range []string{"a1", "b2", "c3"} {
+		dummyFleet.Submit(context.Background(), "falcon-unit@"+sliceID+".service", "[Service]\nExecStart=/bin/old --slice="+sliceID+" --workers=1\n")
+		dummyFleet.Start(context.Background(), "falcon-unit@"+sliceID+".service")
+	}
+	req := Request{
+		RequestConfig: RequestConfig{
+			Group:    "falcon",
+			SliceIDs: []string{"a1", "b2", "c3"},
+		},
+		Units: []Unit{
+			{
+				Name:    "falcon-unit@.service",
+				Content: "[Service]\nExecStart=/bin/new --slice=SLICE --workers=1\n",
+			},
+		},
+		Renderer: testRenderer,
+	}
+
+	opts := UpdateOptions{
+		MaxGrowth: 1,
+		MinAlive:  1,
+		Canary:    2,
+	}
+	if err := waitForUpdate(t, testController, req, opts); err != nil {
+		t.Fatal("Update failed:", err)
+	}
+
+	taskObject, err := testController.AbortCanary(context.Background(), req, opts)
+	if err != nil {
+		t.Fatal("Error returned by AbortCanary:", err)
+	}
+	taskObject, err = testController.WaitForTask(context.Background(), taskObject.ID, nil)
+	if err != nil || taskObject.Error != nil {
+		t.Fatal("Aborting canary failed:", err, taskObject.Error)
+	}
+
+	dummyFleet.Mutex.Lock()
+	defer dummyFleet.Mutex.Unlock()
+	if len(dummyFleet.Contents) != 3 {
+		t.Fatal("Expected 3 slices, got:", len(dummyFleet.Contents))
+	}
+	for name, content := range dummyFleet.Contents {
+		sliceID := strings.TrimSuffix(strings.TrimPrefix(name, "falcon-unit@"), ".service")
+		expected := "[Service]\nExecStart=/bin/old --slice=" + sliceID + " --workers=1\n"
+		if content != expected {
+			t.Fatalf("Expected slice '%s' to run %q, got %q", sliceID, expected, content)
+		}
+	}
+}
+
+// TestCheckCanary_MachineChanged tests that a canary unit being rescheduled
+// between two checks fails the check, even though it is running on each
+// check.
+func TestCheckCanary_MachineChanged(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	testController.WaitSleep = 10 * time.Millisecond
+	req := givenCanaryGroup(dummyFleet, "1")
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+
+		dummyFleet.Mutex.Lock()
+		defer dummyFleet.Mutex.Unlock()
+		us := dummyFleet.Units["falcon-unit@1.service"]
+		us.Machine = append([]fleet.MachineStatus{}, us.Machine...)
+		us.Machine[0].ID = "other-machine"
+		dummyFleet.Units["falcon-unit@1.service"] = us
+	}()
+
+	err := testController.checkCanary(context.Background(), req, Canary{SliceIDs: []string{"1"}}, time.Second)
+	if !IsCanaryUnhealthy(err) {
+		t.Fatal("Expected canary to be unhealthy, got:", err)
+	}
+}
@@ -4,10 +4,10 @@
 package controller
 
 import (
+	"net"
 	"strings"
 	"time"
 
-	"github.com/coreos/fleet/unit"
 	"github.com/juju/errgo"
 	"golang.org/x/net/context"
 
@@ -26,6 +26,16 @@ type Config struct {
 
 	TaskService task.Service
 
+	// PauseStorage is used to look up whether updates of a group are paused.
+	// See also PauseUpdate.
+	PauseStorage PauseStorage
+
+	// Dial is used to connect to units when health checking them. In case it
+	// is nil, units are connected to directly. When fleet is reached using an
+	// SSH tunnel, the machines are usually only reachable through the tunnel as
+	// well. See fleet.SSHTunnel.
+	Dial func(network, addr string) (net.Conn, error)
+
 	// Settings.
 
 	// WaitCount represents the amount of times a desired status is required to
@@ -59,12 +69,13 @@ func DefaultConfig() Config {
 	newTaskService := task.NewTaskService(newTaskServiceConfig)
 
 	newConfig := Config{
-		Fleet:       newFleet,
-		TaskService: newTaskService,
-		WaitCount:   3,
-		WaitSleep:   1 * time.Second,
-		WaitTimeout: 5 * time.Minute,
-		Logger:      logging.NewLogger(logging.DefaultConfig()),
+		Fleet:        newFleet,
+		TaskService:  newTaskService,
+		PauseStorage: NewMemoryPauseStorage(),
+		WaitCount:    3,
+		WaitSleep:    1 * time.Second,
+		WaitTimeout:  5 * time.Minute,
+		Logger:       logging.NewLogger(logging.DefaultConfig()),
 	}
 
 	return newConfig
@@ -73,16 +84,28 @@ func DefaultConfig() Config {
 // Controller defines the interface a controller needs to implement to provide
 // operations for groups of unit files against a fleet cluster.
 type Controller interface {
-	ExtendWithExistingSliceIDs(req Request) (Request, error)
+	// ExtendWithExistingSliceIDs sets the slice IDs of the given req to the IDs
+	// of all slices of the group found within the fleet cluster.
+	ExtendWithExistingSliceIDs(ctx context.Context, req Request) (Request, error)
 
 	// GroupNeedsUpdate checks if the given group should be updated or not. To
 	// make a decision the unit content of each unit of each slice is compared
 	// using its unit hash. As soon as one unit hash differs, or a unit cannot be
 	// found, Inago assumes the whole group slice to be "dirty" and returns true
 	// having the group slices removed from the given req that are up to date,
-	// otherwise false, leaving the req as it is.
+	// otherwise false, leaving the req as it is. Unit contents are rendered for
+	// each slice using req.Renderer before hashing.
 	GroupNeedsUpdate(ctx context.Context, req Request) (Request, bool, error)
 
+	// Diff compares the units of the given group as found in req with the
+	// units deployed within the fleet cluster. For each unit, slices having the
+	// same difference are grouped. Each of these variants is returned as
+	// UnitDiff, carrying a unified diff against the content given by req,
+	// rendered for the slices using req.Renderer. If req contains no slice IDs, all existing slices of the
+	// group are compared. If the group cannot be found, an error that you can
+	// identify using IsUnitNotFound is returned.
+	Diff(ctx context.Context, req Request) ([]UnitDiff, error)
+
 	// Submit schedules a group on the configured fleet cluster. This is done by
 	// setting the state of the units in the group to loaded.
 	// If req.DesiredSlices is positive, new random (non conflicting) SliceIDs will be generated.
@@ -105,6 +128,18 @@ type Controller interface {
 	// found, an error that you can identify using IsUnitNotFound is returned.
 	GetStatus(ctx context.Context, req Request) ([]fleet.UnitStatus, error)
 
+	// List returns a summary of each group found within the fleet cluster.
+	// Groups are not known to fleet. Thus they are inferred from the names of
+	// the units, which are prefixed with the name of their group.
+	List(ctx context.Context) ([]GroupSummary, error)
+
+	// WatchStatus polls the status of a group every WaitSleep and sends one
+	// StatusEvent per poll to the returned channel. Each event carries the
+	// slices whose aggregated status changed since the previous poll. All slices
+	// of the group are watched, regardless of req.SliceIDs. The channel is
+	// closed once ctx is done.
+	WatchStatus(ctx context.Context, req Request) <-chan StatusEvent
+
 	// WaitForStatus waits for a group to reach the given status.
 	WaitForStatus(ctx context.Context, req Request, closer <-chan struct{}, desiredStatuses ...Status) error
 
@@ -118,6 +153,46 @@ type Controller interface {
 	// define the strategy used to update the given group. See also
 	// UpdateOptions.
 	Update(ctx context.Context, req Request, opts UpdateOptions) (*task.Task, error)
+
+	// GetCanary returns the canary of the given group, as recovered from the
+	// unit contents deployed to fleet. In case the group has no canary, i.e. no
+	// slice or all slices are up to date, an error that you can identify using
+	// IsCanaryNotFound is returned.
+	GetCanary(ctx context.Context, req Request) (Canary, error)
+
+	// PromoteCanary updates the outdated slices of a group having a canary.
+	// See also GetCanary.
+	PromoteCanary(ctx context.Context, req Request, opts UpdateOptions) (*task.Task, error)
+
+	// AbortCanary replaces the canary slices of a group by slices running the
+	// unit contents of the outdated slices. See also GetCanary.
+	AbortCanary(ctx context.Context, req Request, opts UpdateOptions) (*task.Task, error)
+
+	// PauseUpdate pauses updates of the given group. Updates in progress finish
+	// adding and removing the slices they are currently working on, but do not
+	// start updating further slices until ResumeUpdate is called. The pause
+	// state is persisted using the configured PauseStorage.
+	PauseUpdate(ctx context.Context, req Request) error
+
+	// ResumeUpdate resumes updates of the given group paused by PauseUpdate.
+	ResumeUpdate(ctx context.Context, req Request) error
+
+	// IsUpdatePaused returns whether updates of the given group are paused.
+	IsUpdatePaused(ctx context.Context, req Request) (bool, error)
+
+	// Scale changes the number of slices of the given group to desiredSlices.
+	// Missing slices are submitted and started using new random slice IDs.
+	// Superfluous slices are stopped and destroyed, where failed slices are
+	// removed first, followed by stopped slices. The given req needs to contain
+	// the units of the group, which are used to submit new slices.
+	Scale(ctx context.Context, req Request, desiredSlices int) (*task.Task, error)
+
+	// Reconcile converges the given group to the state described by the given
+	// opts. Depending on the current state of the group within the fleet
+	// cluster, slices are submitted, started, stopped, updated or destroyed.
+	// The given req needs to contain the units of the group. See also
+	// ReconcileOptions.
+	Reconcile(ctx context.Context, req Request, opts ReconcileOptions) (*task.Task, error)
 }
 
 // NewController creates a new Controller that is configured with the given
@@ -168,17 +243,15 @@ func (c controller) GroupNeedsUpdate(ctx context.Context, req Request) (Request,
 	}
 	c.Config.Logger.Debug(ctx, "controller: checking slice IDs")
 	for _, u := range req.Units {
-		unitFile, err := unit.NewUnitFile(u.Content)
-		if err != nil {
-			return Request{}, false, maskAny(err)
-		}
-		hash := unitFile.Hash().String()
-
 		for _, uhi := range uhis {
-			if common.UnitBase(u.Name) != uhi.Base {
+			if unitKey(u.Name) != uhi.Base {
 				continue
 			}
-			if hash == uhi.Hash {
+			ok, err := req.matchesUnitHash(u, uhi.SliceID, uhi.Hash)
+			if err != nil {
+				return Request{}, false, maskAny(err)
+			}
+			if ok {
 				continue
 			}
 			if contains(newSliceIDs, uhi.SliceID) {
@@ -222,6 +295,10 @@ func (c controller) Submit(ctx context.Context, req Request) (*task.Task, error)
 
 		c.Config.Logger.Debug(ctx, "action: submitting units")
 		for _, unit := range req.Units {
+			if err := ctx.Err(); err != nil {
+				// The task got cancelled. Do not issue any further fleet operations.
+				return maskAny(err)
+			}
 			err := c.Fleet.Submit(ctx, unit.Name, unit.Content)
 			if err != nil {
 				return maskAny(err)
@@ -235,8 +312,6 @@ func (c controller) Submit(ctx context.Context, req Request) (*task.Task, error)
 			return maskAny(err)
 		}
 
-		// TODO retry operations
-
 		return nil
 	}
 	taskObject, err := c.TaskService.Create(ctx, action)
@@ -259,6 +334,9 @@ func (c controller) Start(ctx context.Context, req Request) (*task.Task, error)
 
 		c.Config.Logger.Debug(ctx, "action: starting units")
 		for _, unitStatus := range unitStatusList {
+			if err := ctx.Err(); err != nil {
+				return maskAny(err)
+			}
 			err := c.Fleet.Start(ctx, unitStatus.Name)
 			if err != nil {
 				return maskAny(err)
@@ -272,8 +350,6 @@ func (c controller) Start(ctx context.Context, req Request) (*task.Task, error)
 			return maskAny(err)
 		}
 
-		// TODO retry operations
-
 		return nil
 	}
 
@@ -295,6 +371,9 @@ func (c controller) Stop(ctx context.Context, req Request) (*task.Task, error) {
 		}
 
 		for _, unitStatus := range unitStatusList {
+			if err := ctx.Err(); err != nil {
+				return maskAny(err)
+			}
 			err := c.Fleet.Stop(ctx, unitStatus.Name)
 			if err != nil {
 				return maskAny(err)
@@ -307,8 +386,6 @@ func (c controller) Stop(ctx context.Context, req Request) (*task.Task, error) {
 			return maskAny(err)
 		}
 
-		// TODO retry operations
-
 		return nil
 	}
 
@@ -330,6 +407,9 @@ func (c controller) Destroy(ctx context.Context, req Request) (*task.Task, error
 		}
 
 		for _, unitStatus := range unitStatusList {
+			if err := ctx.Err(); err != nil {
+				return maskAny(err)
+			}
 			err := c.Fleet.Destroy(ctx, unitStatus.Name)
 			if err != nil {
 				return maskAny(err)
@@ -342,8 +422,6 @@ func (c controller) Destroy(ctx context.Context, req Request) (*task.Task, error
 			return maskAny(err)
 		}
 
-		// TODO retry operations
-
 		return nil
 	}
 
@@ -386,16 +464,40 @@ func (c controller) Update(ctx context.Context, req Request, opts UpdateOptions)
 		},
 		{
 			message: "cannot have minimum alive units greater than current number of units",
-			broken:  opts.MinAlive > numRunning,
+			broken:  opts.Strategy != StrategyBlueGreen && opts.MinAlive > numRunning,
 		},
 		{
 			message: "to keep all current units alive, max growth must be greater than 0",
-			broken:  opts.MinAlive == numRunning && opts.MaxGrowth < 1,
+			broken:  opts.Strategy != StrategyBlueGreen && opts.MinAlive == numRunning && opts.MaxGrowth < 1,
 		},
 		{
 			message: "number of units of unsliced groups must not be allowed to grow",
 			broken:  !req.isSliceable() && opts.MaxGrowth > 0,
 		},
+		{
+			message: "update strategy must be either rolling or blue-green",
+			broken:  opts.Strategy != "" && opts.Strategy != StrategyRolling && opts.Strategy != StrategyBlueGreen,
+		},
+		{
+			message: "health check type must be either http or tcp",
+			broken:  opts.HealthCheck.Type != HealthCheckNone && opts.HealthCheck.Type != HealthCheckHTTP && opts.HealthCheck.Type != HealthCheckTCP,
+		},
+		{
+			message: "health check timeout must be positive, or zero",
+			broken:  opts.HealthCheck.TimeoutSecs < 0,
+		},
+		{
+			message: "health check success threshold must be positive, or zero",
+			broken:  opts.HealthCheck.SuccessThreshold < 0,
+		},
+		{
+			message: "health check grace period must be positive, or zero",
+			broken:  opts.HealthCheck.GraceSecs < 0,
+		},
+		{
+			message: "blue-green update strategy cannot be combined with canary",
+			broken:  opts.Strategy == StrategyBlueGreen && opts.Canary > 0,
+		},
 	}
 	for _, rule := range updateAllowedRules {
 		if rule.broken {
@@ -415,13 +517,45 @@ func (c controller) Update(ctx context.Context, req Request, opts UpdateOptions)
 			return maskAny(unitsAlreadyUpToDate)
 		}
 
-		err = c.UpdateWithStrategy(ctx, req, opts)
+		updateReq := req
+		updateOpts := opts
+		if opts.Canary > 0 {
+			// Only the canary slices are updated first.
+			if opts.Canary < len(req.SliceIDs) {
+				updateReq.SliceIDs = req.SliceIDs[:opts.Canary]
+			}
+			updateOpts = canaryUpdateOptions(opts, len(updateReq.SliceIDs))
+		}
+
+		var snapshot updateSnapshot
+		if opts.Rollback {
+			snapshot, err = c.takeUpdateSnapshot(ctx, updateReq)
+			if err != nil {
+				return maskAny(err)
+			}
+		}
+
+		if opts.Strategy == StrategyBlueGreen {
+			err = c.updateBlueGreen(ctx, updateReq, updateOpts)
+		} else {
+			err = c.UpdateWithStrategy(ctx, updateReq, updateOpts)
+		}
 		if err != nil {
 			c.Config.Logger.Error(ctx, "controller: error encountered updating: %v", err)
+			if ctx.Err() != nil {
+				// The update got cancelled. Rolling back would issue further
+				// fleet operations, which is what cancelling wants to prevent.
+				return maskAny(err)
+			}
+			if opts.Rollback {
+				return maskAny(c.rollbackUpdate(ctx, updateReq, snapshot, err))
+			}
 			return maskAny(err)
 		}
 
-		// TODO retry operations
+		if opts.Canary > 0 {
+			return maskAny(c.updateCanary(ctx, req, opts))
+		}
 
 		return nil
 	}
@@ -451,8 +585,10 @@ func (c controller) WaitForStatus(ctx context.Context, req Request, closer <-cha
 		return maskAny(invalidArgumentError)
 	}
 
-	fail := make(chan error)
-	done := make(chan struct{})
+	// The channels are buffered to not block the goroutine below in case
+	// waiting already ended.
+	fail := make(chan error, 1)
+	done := make(chan struct{}, 1)
 
 	go func() {
 		// count describes the count of how often one of the desired aggregated statuses was
@@ -474,7 +610,7 @@ func (c controller) WaitForStatus(ctx context.Context, req Request, closer <-cha
 			}
 
 			c.Config.Logger.Debug(ctx, "controller: checking units have desired statuses: %v", desiredStatuses)
-			for _, us := range unitStatusList {
+			for _, us := range UnitStatusList(unitStatusList).ResolveTriggered() {
 				c.Config.Logger.Debug(ctx, "controller: unit status: %#v", us)
 
 				aggregator := Aggregator{
@@ -490,7 +626,10 @@ func (c controller) WaitForStatus(ctx context.Context, req Request, closer <-cha
 					// Whenever the aggregated status does not match the desired
 					// statuses, we reset the counter.
 					count = 0
-					time.Sleep(c.WaitSleep)
+					if err := sleep(ctx, c.WaitSleep); err != nil {
+						fail <- maskAny(err)
+						return
+					}
 					continue L1
 				}
 			}
@@ -504,7 +643,10 @@ func (c controller) WaitForStatus(ctx context.Context, req Request, closer <-cha
 				c.Config.Logger.Debug(ctx, "controller: group has reached count (%v) of desired statuses: %v", c.WaitCount, desiredStatuses)
 				break
 			}
-			time.Sleep(c.WaitSleep)
+			if err := sleep(ctx, c.WaitSleep); err != nil {
+				fail <- maskAny(err)
+				return
+			}
 		}
 
 		done <- struct{}{}
@@ -517,6 +659,8 @@ func (c controller) WaitForStatus(ctx context.Context, req Request, closer <-cha
 		return nil
 	case <-closer:
 		return nil
+	case <-ctx.Done():
+		return maskAny(ctx.Err())
 	case <-time.After(c.WaitTimeout):
 		return maskAny(waitTimeoutReachedError)
 	}
@@ -532,13 +676,25 @@ func (c controller) WaitForTask(ctx context.Context, taskID string, closer <-cha
 	return taskObject, maskAny(err)
 }
 
+// sleep blocks for the given duration. In case the given context is done
+// before, sleep returns the context's error immediately. This way loops stop
+// quickly once their task got cancelled.
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return maskAny(ctx.Err())
+	case <-time.After(d):
+		return nil
+	}
+}
+
 // groupStatus fetches the group status using information provided
 // by req. Note that this methods throws a unitNotFoundError in case no unit
 // can be found.
 func (c controller) groupStatus(ctx context.Context, req Request) ([]fleet.UnitStatus, error) {
 	c.Config.Logger.Debug(ctx, "controller: fetching group status from fleet")
 
-	unitStatusList, err := c.Fleet.GetStatusWithMatcher(matchesGroupSlices(req))
+	unitStatusList, err := c.getStatusWithMatcher(ctx, matchesGroupSlices(req))
 	if fleet.IsUnitNotFound(err) {
 		// This happens when no unit is found.
 		return nil, maskAny(unitNotFoundError)
@@ -547,7 +703,20 @@ func (c controller) groupStatus(ctx context.Context, req Request) ([]fleet.UnitS
 	}
 	c.Config.Logger.Debug(ctx, "controller: received unit status list: %#v", unitStatusList)
 
-	// TODO retry operations
+	return unitStatusList, nil
+}
+
+// getStatusWithMatcher fetches the status of all units the given matcher
+// returns true for. Marker units of the fleet pause storage are never matched,
+// as they do not belong to any group. See isPauseMarker. Thus all unit statuses
+// need to be fetched using this method instead of using fleet directly.
+func (c controller) getStatusWithMatcher(ctx context.Context, matcher func(string) bool) ([]fleet.UnitStatus, error) {
+	unitStatusList, err := c.Fleet.GetStatusWithMatcher(ctx, func(name string) bool {
+		return !isPauseMarker(name) && matcher(name)
+	})
+	if err != nil {
+		return nil, maskAny(err)
+	}
 
 	return unitStatusList, nil
 }
@@ -567,8 +736,6 @@ func (c controller) groupStatusWithValidate(ctx context.Context, req Request) ([
 		return nil, maskAny(err)
 	}
 
-	// TODO retry operations
-
 	return unitStatusList, nil
 }
 
@@ -618,9 +785,12 @@ func matchesGroupSlices(request Request) func(string) bool {
 			return false
 		}
 
+		unitSliceID, err := common.SliceID(unitName)
+		if err != nil {
+			return false
+		}
 		for _, sliceID := range request.SliceIDs {
-			// TODO fix extension
-			if strings.HasSuffix(unitName, "@"+sliceID+".service") {
+			if unitSliceID == sliceID {
 				return true
 			}
 		}
@@ -644,7 +814,7 @@ func matchesUnitBase(request Request) func(string) bool {
 		}
 
 		for _, u := range request.Units {
-			if common.UnitBase(u.Name) == common.UnitBase(unitName) {
+			if unitKey(u.Name) == unitKey(unitName) {
 				return true
 			}
 		}
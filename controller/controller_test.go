@@ -1,7 +1,9 @@
 package controller
 
 import (
+	"io"
 	"net"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -401,6 +403,27 @@ func Test_matchesGroupSlices(t *testing.T) {
 			Output: false,
 		},
 
+		{
+			InputUnitName: "demo-main@1.timer",
+			InputRequest: Request{
+				RequestConfig: RequestConfig{
+					Group:    "demo",
+					SliceIDs: []string{"1", "2"},
+				},
+			},
+			Output: true,
+		},
+		{
+			InputUnitName: "demo-main@11.socket",
+			InputRequest: Request{
+				RequestConfig: RequestConfig{
+					Group:    "demo",
+					SliceIDs: []string{"1"},
+				},
+			},
+			Output: false,
+		},
+
 		{
 			InputUnitName: "demo-main.service",
 			InputRequest: Request{
@@ -444,6 +467,27 @@ func Test_matchesGroupSlices(t *testing.T) {
 	}
 }
 
+const (
+	testUnitContent        = "[Service]\nExecStart=/bin/true\n"
+	testUnitUpdatedContent = "[Service]\nExecStart=/bin/false\n"
+)
+
+// givenRequest returns a request for the group "falcon" having one unit with
+// the given name and content.
+func givenRequest(name, content string) Request {
+	return Request{
+		RequestConfig: RequestConfig{
+			Group: "falcon",
+		},
+		Units: []Unit{
+			{
+				Name:    name,
+				Content: content,
+			},
+		},
+	}
+}
+
 // givenController returns a controller where the fleet backend is replaced
 // with a mock.
 func givenController() (Controller, *fleetMock) {
@@ -667,6 +711,33 @@ func TestController_Start(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, fleetMock.Mock)
 }
 
+// TestController_NoRetry tests that the controller does not retry operations
+// failing temporarily. Retrying is up to the fleet client. Retrying in both
+// layers would multiply the number of attempts.
+func TestController_NoRetry(t *testing.T) {
+	RegisterTestingT(t)
+
+	fleetMock := newFleetMock(defaultFleetMockConfig())
+	fleetMock.On("GetStatusWithMatcher", mock.AnythingOfType("func(string) bool")).Return(
+		[]fleet.UnitStatus{},
+		&url.Error{Op: "Get", URL: "http://domain-sock/", Err: io.EOF},
+	).Once()
+
+	newControllerConfig := DefaultConfig()
+	newControllerConfig.Fleet = fleetMock
+	controller := NewController(newControllerConfig)
+
+	req := Request{
+		RequestConfig: RequestConfig{
+			Group: "test",
+		},
+	}
+	_, err := controller.GetStatus(context.Background(), req)
+	Expect(err).To(HaveOccurred())
+
+	mock.AssertExpectationsForObjects(t, fleetMock.Mock)
+}
+
 func TestController_Destroy(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -942,6 +1013,60 @@ func TestController_WaitForStatus_Timeout(t *testing.T) {
 	Expect(IsWaitTimeoutReached(err)).To(BeTrue()) // Because WaitForStatus is 0 nothing should happen but directly return the error
 }
 
+// TestController_Start_Cancel tests that cancelling the task of
+// Controller.Start stops waiting for the units to become running, long before
+// the WaitTimeout expires.
+func TestController_Start_Cancel(t *testing.T) {
+	RegisterTestingT(t)
+
+	// Mocks
+	c, fleetMock := givenController()
+	c.(*controller).WaitTimeout = 10 * time.Second
+	fleetMock.On("GetStatusWithMatcher", mock.AnythingOfType("func(string) bool")).Return(
+		[]fleet.UnitStatus{
+			{
+				Current: "loaded",
+				Desired: "launched",
+				Machine: []fleet.MachineStatus{
+					{
+						ID:            "test-id",
+						IP:            net.ParseIP("10.0.0.101"),
+						SystemdActive: "activating",
+						SystemdSub:    "start-pre",
+						UnitHash:      "test-hash",
+					},
+				},
+				Name: "test-main@1.service",
+			},
+		},
+		nil,
+	)
+	fleetMock.On("Start", "test-main@1.service").Return(nil).Once()
+
+	// Execute test
+	req := Request{
+		RequestConfig: RequestConfig{
+			Group:    "test",
+			SliceIDs: []string{"1"},
+		},
+	}
+	start := time.Now()
+	taskObject, err := c.Start(context.Background(), req)
+	Expect(err).To(BeNil())
+
+	time.Sleep(50 * time.Millisecond)
+	err = c.(*controller).TaskService.Cancel(context.Background(), taskObject.ID)
+	Expect(err).To(BeNil())
+
+	taskObject, err = c.WaitForTask(context.Background(), taskObject.ID, nil)
+	Expect(err).To(BeNil())
+
+	// Assert
+	Expect(task.HasCancelledStatus(taskObject)).To(BeTrue())
+	Expect(time.Since(start) < time.Second).To(BeTrue())
+	mock.AssertExpectationsForObjects(t, fleetMock.Mock)
+}
+
 // TestController_UpdateValidation tests the validation of the Update method of the controller.
 func TestController_UpdateValidation(t *testing.T) {
 	RegisterTestingT(t)
@@ -1266,3 +1391,48 @@ func TestController_Update(t *testing.T) {
 		Expect(err).To(BeNil())
 	}
 }
+
+// TestController_GroupNeedsUpdate_UnitTypes tests that units sharing the same
+// base but having different unit types are compared separately.
+func TestController_GroupNeedsUpdate_UnitTypes(t *testing.T) {
+	testController, dummyFleet := getTestController()
+
+	serviceContent := "[Service]\nExecStart=/bin/true\n"
+	timerContent := "[Timer]\nOnCalendar=weekly\n"
+	dummyFleet.Submit(context.Background(), "falcon-unit@1.service", serviceContent)
+	dummyFleet.Submit(context.Background(), "falcon-unit@1.timer", timerContent)
+
+	req := Request{
+		RequestConfig: RequestConfig{
+			Group:    "falcon",
+			SliceIDs: []string{"1"},
+		},
+		Units: []Unit{
+			{
+				Name:    "falcon-unit@.service",
+				Content: serviceContent,
+			},
+			{
+				Name:    "falcon-unit@.timer",
+				Content: timerContent,
+			},
+		},
+	}
+
+	_, ok, err := testController.GroupNeedsUpdate(context.Background(), req)
+	if err != nil {
+		t.Fatal("Error returned by GroupNeedsUpdate:", err)
+	}
+	if ok {
+		t.Fatal("Expected group to be up to date")
+	}
+
+	req.Units[1].Content = "[Timer]\nOnCalendar=daily\n"
+	_, ok, err = testController.GroupNeedsUpdate(context.Background(), req)
+	if err != nil {
+		t.Fatal("Error returned by GroupNeedsUpdate:", err)
+	}
+	if !ok {
+		t.Fatal("Expected group to need an update")
+	}
+}
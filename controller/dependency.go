@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"sort"
+)
+
+// Dependencies maps group names to the names of the groups they depend on. A
+// group is only started once all of its dependencies are running, and
+// destroyed before any of its dependencies.
+type Dependencies map[string][]string
+
+// ValidateMultipleRequestWithDependencies takes a list of Requests and the
+// dependencies between their groups, and returns whether they are valid
+// together or not. Next to the checks of ValidateMultipleRequest, the
+// dependencies must not be cyclic.
+// If the requests are not valid, the error returned provides more details.
+func ValidateMultipleRequestWithDependencies(requests []Request, dependencies Dependencies) (bool, error) {
+	var validationError ValidationError
+
+	ok, err := ValidateMultipleRequest(requests)
+	if !ok {
+		validationError = err.(ValidationError)
+	}
+
+	if _, err := OrderByDependencies(requests, dependencies); IsGroupDependencyCycle(err) {
+		validationError.Add(err)
+	} else if err != nil {
+		return false, maskAny(err)
+	}
+
+	if len(validationError.CausingErrors) != 0 {
+		return false, validationError
+	}
+	return true, nil
+}
+
+// OrderByDependencies returns the given requests ordered such that each group
+// comes after the groups it depends on. Dependencies on groups not contained
+// in requests are ignored. Groups not depending on each other keep the order
+// of their names. In case the dependencies are cyclic, an error that you can
+// identify using IsGroupDependencyCycle is returned.
+func OrderByDependencies(requests []Request, dependencies Dependencies) ([]Request, error) {
+	byGroup := map[string]Request{}
+	var groups []string
+	for _, req := range requests {
+		byGroup[req.Group] = req
+		groups = append(groups, req.Group)
+	}
+	sort.Strings(groups)
+
+	var ordered []Request
+	visited := map[string]bool{}
+	visiting := map[string]bool{}
+
+	var visit func(group string) error
+	visit = func(group string) error {
+		if visited[group] {
+			return nil
+		}
+		if visiting[group] {
+			return maskAnyf(groupDependencyCycleError, "group '%s'", group)
+		}
+		visiting[group] = true
+
+		deps := append([]string{}, dependencies[group]...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if _, ok := byGroup[dep]; !ok {
+				continue
+			}
+			if err := visit(dep); err != nil {
+				return maskAny(err)
+			}
+		}
+
+		visiting[group] = false
+		visited[group] = true
+		ordered = append(ordered, byGroup[group])
+
+		return nil
+	}
+
+	for _, group := range groups {
+		if err := visit(group); err != nil {
+			return nil, maskAny(err)
+		}
+	}
+
+	return ordered, nil
+}
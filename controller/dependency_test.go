@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+)
+
+func requestsForGroups(groups ...string) []Request {
+	var requests []Request
+	for _, group := range groups {
+		requests = append(requests, Request{
+			RequestConfig: RequestConfig{
+				Group: group,
+			},
+		})
+	}
+	return requests
+}
+
+func groupsOfRequests(requests []Request) []string {
+	var groups []string
+	for _, req := range requests {
+		groups = append(groups, req.Group)
+	}
+	return groups
+}
+
+// TestOrderByDependencies tests the OrderByDependencies function.
+func TestOrderByDependencies(t *testing.T) {
+	var tests = []struct {
+		groups       []string
+		dependencies Dependencies
+		expected     []string
+		errAssertion func(error) bool
+	}{
+		// Test that groups without dependencies are ordered by name.
+		{
+			groups:       []string{"b", "c", "a"},
+			dependencies: nil,
+			expected:     []string{"a", "b", "c"},
+		},
+		// Test that groups come after their dependencies.
+		{
+			groups: []string{"k8s-node", "k8s-master", "k8s-network"},
+			dependencies: Dependencies{
+				"k8s-master": {"k8s-network"},
+				"k8s-node":   {"k8s-master"},
+			},
+			expected: []string{"k8s-network", "k8s-master", "k8s-node"},
+		},
+		// Test that dependencies on groups not requested are ignored.
+		{
+			groups: []string{"k8s-node", "k8s-master"},
+			dependencies: Dependencies{
+				"k8s-master": {"k8s-network"},
+				"k8s-node":   {"k8s-master"},
+			},
+			expected: []string{"k8s-master", "k8s-node"},
+		},
+		// Test that cyclic dependencies are rejected.
+		{
+			groups: []string{"a", "b", "c"},
+			dependencies: Dependencies{
+				"a": {"c"},
+				"b": {"a"},
+				"c": {"b"},
+			},
+			errAssertion: IsGroupDependencyCycle,
+		},
+		// Test that groups depending on themselves are rejected.
+		{
+			groups: []string{"a"},
+			dependencies: Dependencies{
+				"a": {"a"},
+			},
+			errAssertion: IsGroupDependencyCycle,
+		},
+	}
+
+	for index, test := range tests {
+		ordered, err := OrderByDependencies(requestsForGroups(test.groups...), test.dependencies)
+		if test.errAssertion != nil {
+			if !test.errAssertion(err) {
+				t.Errorf("%v: Expected error, got: '%v'", index, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: Unexpected error: '%v'", index, err)
+			continue
+		}
+		if groups := groupsOfRequests(ordered); !reflect.DeepEqual(groups, test.expected) {
+			t.Errorf("%v: Expected order '%v', got '%v'", index, test.expected, groups)
+		}
+	}
+}
+
+// TestValidateMultipleRequestWithDependencies tests the
+// ValidateMultipleRequestWithDependencies function.
+func TestValidateMultipleRequestWithDependencies(t *testing.T) {
+	valid, err := ValidateMultipleRequestWithDependencies(requestsForGroups("a", "b"), Dependencies{"b": {"a"}})
+	if !valid || err != nil {
+		t.Fatalf("Requests should be valid, but returned err: '%v'", err)
+	}
+
+	valid, err = ValidateMultipleRequestWithDependencies(requestsForGroups("bat", "batman"), Dependencies{
+		"bat":    {"batman"},
+		"batman": {"bat"},
+	})
+	if valid {
+		t.Fatal("Requests should be invalid")
+	}
+	validationErr := err.(ValidationError)
+	if !validationErr.Contains(IsGroupsArePrefix) {
+		t.Fatalf("Expected prefix error, got: '%v'", validationErr.CausingErrors)
+	}
+	if !validationErr.Contains(IsGroupDependencyCycle) {
+		t.Fatalf("Expected cycle error, got: '%v'", validationErr.CausingErrors)
+	}
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"github.com/coreos/fleet/unit"
+	"github.com/pmezard/go-difflib/difflib"
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/fleet"
+)
+
+// UnitDiff represents the difference between a unit of a group as found on
+// the local filesystem and the same unit as deployed to a set of slices within
+// the fleet cluster. All slices of a UnitDiff have the same difference, which
+// usually means they have the unit deployed using the same content.
+type UnitDiff struct {
+	// Name represents the unit name as found on the local filesystem, e.g.
+	// "app@.service".
+	Name string
+
+	// SliceIDs contains the IDs of the slices having the unit deployed using
+	// the same content. This is empty for unsliced groups.
+	SliceIDs []string
+
+	// Missing is true when the unit is not deployed to the slices at all.
+	Missing bool
+
+	// Drift is true when the difference of the slices differs from the
+	// difference of the majority of the slices of the group.
+	Drift bool
+
+	// Diff represents the unified diff between the deployed content and the
+	// local content. This is empty when both are equal.
+	Diff string
+}
+
+// unitVariant represents one distinct diff of a unit across slices.
+type unitVariant struct {
+	Diff     string
+	Missing  bool
+	SliceIDs []string
+}
+
+func (c controller) Diff(ctx context.Context, req Request) ([]UnitDiff, error) {
+	c.Config.Logger.Debug(ctx, "controller: diffing group '%v'", req.Group)
+
+	var sliceIDs []string
+	if req.isSliceable() {
+		sliceIDs = req.SliceIDs
+		if len(sliceIDs) == 0 {
+			var err error
+			sliceIDs, err = c.getExistingSliceIDs(ctx, req)
+			if err != nil {
+				return nil, maskAny(err)
+			}
+		}
+		if len(sliceIDs) == 0 {
+			return nil, maskAnyf(unitNotFoundError, "group '%s'", req.Group)
+		}
+	} else {
+		req.SliceIDs = nil
+		_, err := c.groupStatus(ctx, req)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		// Unsliced groups are treated like a group having one slice without ID.
+		sliceIDs = []string{""}
+	}
+
+	// variants holds the distinct diffs of each unit, indexed like req.Units.
+	// Slices are grouped by their diff rather than by their deployed content,
+	// because the local content can be rendered differently for each slice.
+	variants := make([][]unitVariant, len(req.Units))
+	for _, sliceID := range sliceIDs {
+		sliceReq := req
+		sliceReq.Units = append([]Unit{}, req.Units...)
+		sliceReq.SliceIDs = nil
+		if sliceID != "" {
+			sliceReq.SliceIDs = []string{sliceID}
+		}
+		extended, err := sliceReq.ExtendSlices()
+		if err != nil {
+			return nil, maskAny(err)
+		}
+
+		// ExtendSlices keeps the order of the units for each slice. Thus the
+		// extended units map to the units of the request by their index.
+		for i, u := range extended.Units {
+			content, err := c.Fleet.GetUnitContent(ctx, u.Name)
+			missing := fleet.IsUnitNotFound(err)
+			if err != nil && !missing {
+				return nil, maskAny(err)
+			}
+
+			local, err := req.renderExistingUnit(req.Units[i], sliceID)
+			if err != nil {
+				return nil, maskAny(err)
+			}
+
+			diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A:        difflib.SplitLines(content),
+				B:        difflib.SplitLines(local),
+				FromFile: "fleet/" + req.Units[i].Name,
+				ToFile:   "local/" + req.Units[i].Name,
+				Context:  3,
+			})
+			if err != nil {
+				return nil, maskAny(err)
+			}
+
+			variants[i] = addUnitVariant(variants[i], diff, missing, sliceID)
+		}
+	}
+
+	var diffs []UnitDiff
+	for i, u := range req.Units {
+		majority := 0
+		for j, v := range variants[i] {
+			if len(v.SliceIDs) > len(variants[i][majority].SliceIDs) {
+				majority = j
+			}
+		}
+
+		for j, v := range variants[i] {
+			diffs = append(diffs, UnitDiff{
+				Name:     u.Name,
+				SliceIDs: v.SliceIDs,
+				Missing:  v.Missing,
+				Drift:    j != majority,
+				Diff:     v.Diff,
+			})
+		}
+	}
+
+	return diffs, nil
+}
+
+// addUnitVariant adds the given slice ID to the variant having the given
+// diff, or adds a new variant in case there is none yet.
+func addUnitVariant(variants []unitVariant, diff string, missing bool, sliceID string) []unitVariant {
+	for i, v := range variants {
+		if v.Diff == diff && v.Missing == missing {
+			if sliceID != "" {
+				variants[i].SliceIDs = append(variants[i].SliceIDs, sliceID)
+			}
+			return variants
+		}
+	}
+
+	v := unitVariant{
+		Diff:    diff,
+		Missing: missing,
+	}
+	if sliceID != "" {
+		v.SliceIDs = []string{sliceID}
+	}
+
+	return append(variants, v)
+}
+
+// normalizeUnitContent formats the given unit file content the same way fleet
+// reports the content of deployed units. This prevents differences in e.g.
+// comments or whitespace from showing up in diffs.
+func normalizeUnitContent(content string) (string, error) {
+	unitFile, err := unit.NewUnitFile(content)
+	if err != nil {
+		return "", maskAny(err)
+	}
+
+	return unitFile.String(), nil
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+// TestDiff_NotFound tests that diffing a group not existing results in an
+// error.
+func TestDiff_NotFound(t *testing.T) {
+	testController, _ := getTestController()
+
+	_, err := testController.Diff(context.Background(), givenRequest("falcon-unit@.service", testUnitContent))
+	if !IsUnitNotFound(err) {
+		t.Fatal("Expected unit not found error, got:", err)
+	}
+
+	_, err = testController.Diff(context.Background(), givenRequest("falcon-unit.service", testUnitContent))
+	if !IsUnitNotFound(err) {
+		t.Fatal("Expected unit not found error, got:", err)
+	}
+}
+
+// TestDiff_Drift tests that slices are grouped by the content they have
+// deployed, and that slices differing from the majority are flagged.
+func TestDiff_Drift(t *testing.T) {
+	testController, dummyFleet := getTestController()
+
+	dummyFleet.Submit(context.Background(), "falcon-unit@1.service", testUnitContent)
+	dummyFleet.Submit(context.Background(), "falcon-unit@2.service", testUnitContent)
+	dummyFleet.Submit(context.Background(), "falcon-unit@3.service", testUnitUpdatedContent)
+
+	req := givenRequest("falcon-unit@.service", testUnitUpdatedContent)
+	req.SliceIDs = []string{"1", "2", "3"}
+	diffs, err := testController.Diff(context.Background(), req)
+	if err != nil {
+		t.Fatal("Error returned by diff:", err)
+	}
+
+	if len(diffs) != 2 {
+		t.Fatal("Expected 2 diffs, got:", diffs)
+	}
+
+	if strings.Join(diffs[0].SliceIDs, ",") != "1,2" {
+		t.Fatal("Incorrect slices of first diff:", diffs[0].SliceIDs)
+	}
+	if diffs[0].Drift || diffs[0].Missing {
+		t.Fatal("Expected first diff to be the majority:", diffs[0])
+	}
+	if !strings.Contains(diffs[0].Diff, "-ExecStart=/bin/true") || !strings.Contains(diffs[0].Diff, "+ExecStart=/bin/false") {
+		t.Fatal("Incorrect diff:", diffs[0].Diff)
+	}
+
+	if strings.Join(diffs[1].SliceIDs, ",") != "3" {
+		t.Fatal("Incorrect slices of second diff:", diffs[1].SliceIDs)
+	}
+	if !diffs[1].Drift {
+		t.Fatal("Expected second diff to be flagged as drift")
+	}
+	if diffs[1].Diff != "" {
+		t.Fatal("Expected no diff for up to date slice, got:", diffs[1].Diff)
+	}
+}
+
+// TestDiff_Missing tests that units not deployed to a slice are reported as
+// missing.
+func TestDiff_Missing(t *testing.T) {
+	testController, dummyFleet := getTestController()
+
+	dummyFleet.Submit(context.Background(), "falcon-unit@1.service", testUnitContent)
+	dummyFleet.Submit(context.Background(), "falcon-other@1.service", testUnitContent)
+
+	req := givenRequest("falcon-unit@.service", testUnitContent)
+	req.Units = append(req.Units, Unit{Name: "falcon-other@.service", Content: testUnitContent})
+	req.Units = append(req.Units, Unit{Name: "falcon-new@.service", Content: testUnitContent})
+	diffs, err := testController.Diff(context.Background(), req)
+	if err != nil {
+		t.Fatal("Error returned by diff:", err)
+	}
+
+	if len(diffs) != 3 {
+		t.Fatal("Expected 3 diffs, got:", diffs)
+	}
+	if diffs[0].Missing || diffs[0].Diff != "" || diffs[1].Missing || diffs[1].Diff != "" {
+		t.Fatal("Expected existing units to be up to date:", diffs)
+	}
+	if !diffs[2].Missing || !strings.Contains(diffs[2].Diff, "+ExecStart=/bin/true") {
+		t.Fatal("Expected new unit to be missing:", diffs[2])
+	}
+}
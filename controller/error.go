@@ -114,6 +114,55 @@ func IsUpdateNotAllowed(err error) bool {
 	return errgo.Cause(err) == updateNotAllowedError
 }
 
+var updateRolledBackError = errgo.Newf("update rolled back")
+
+// IsUpdateRolledBack asserts updateRolledBackError.
+func IsUpdateRolledBack(err error) bool {
+	return errgo.Cause(err) == updateRolledBackError
+}
+
+var rollbackFailedError = errgo.Newf("rollback failed")
+
+// IsRollbackFailed asserts rollbackFailedError.
+func IsRollbackFailed(err error) bool {
+	return errgo.Cause(err) == rollbackFailedError
+}
+
+var canaryNotFoundError = errgo.Newf("canary not found")
+
+// IsCanaryNotFound asserts canaryNotFoundError.
+func IsCanaryNotFound(err error) bool {
+	return errgo.Cause(err) == canaryNotFoundError
+}
+
+var canaryUnhealthyError = errgo.Newf("canary unhealthy")
+
+// IsCanaryUnhealthy asserts canaryUnhealthyError.
+func IsCanaryUnhealthy(err error) bool {
+	return errgo.Cause(err) == canaryUnhealthyError
+}
+
+var canaryRolledBackError = errgo.Newf("canary rolled back")
+
+// IsCanaryRolledBack asserts canaryRolledBackError.
+func IsCanaryRolledBack(err error) bool {
+	return errgo.Cause(err) == canaryRolledBackError
+}
+
+var healthCheckFailedError = errgo.Newf("health check failed")
+
+// IsHealthCheckFailed asserts healthCheckFailedError.
+func IsHealthCheckFailed(err error) bool {
+	return errgo.Cause(err) == healthCheckFailedError
+}
+
+var scaleNotAllowedError = errgo.Newf("scale not allowed")
+
+// IsScaleNotAllowed asserts scaleNotAllowedError.
+func IsScaleNotAllowed(err error) bool {
+	return errgo.Cause(err) == scaleNotAllowedError
+}
+
 var unitsAlreadyUpToDate = errgo.Newf("units already up to date")
 
 // IsUnitsAlreadyUpToDate asserts unitsAlreadyUpToDate.
@@ -190,3 +239,17 @@ var invalidSubmitRequestNoSliceIDsGivenError = errgo.New("invalid submit request
 func IsInvalidSubmitRequestNoSliceIDsGiven(err error) bool {
 	return errgo.Cause(err) == invalidSubmitRequestNoSliceIDsGivenError
 }
+
+var groupDependencyCycleError = errgo.New("group dependencies are cyclic")
+
+// IsGroupDependencyCycle returns true if the given error cause is groupDependencyCycleError.
+func IsGroupDependencyCycle(err error) bool {
+	return errgo.Cause(err) == groupDependencyCycleError
+}
+
+var renderUnitError = errgo.New("rendering unit failed")
+
+// IsRenderUnit returns true if the given error cause is renderUnitError.
+func IsRenderUnit(err error) bool {
+	return errgo.Cause(err) == renderUnitError
+}
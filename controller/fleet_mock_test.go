@@ -63,11 +63,15 @@ func (fm *fleetMock) GetStatus(ctx context.Context, name string) (fleet.UnitStat
 	args := fm.Called(name)
 	return args.Get(0).(fleet.UnitStatus), args.Error(1)
 }
+func (fm *fleetMock) GetUnitContent(ctx context.Context, name string) (string, error) {
+	args := fm.Called(name)
+	return args.String(0), args.Error(1)
+}
 func (fm *fleetMock) GetStatusWithExpression(exp *regexp.Regexp) ([]fleet.UnitStatus, error) {
 	args := fm.Called(exp)
 	return args.Get(0).([]fleet.UnitStatus), args.Error(1)
 }
-func (fm *fleetMock) GetStatusWithMatcher(f func(string) bool) ([]fleet.UnitStatus, error) {
+func (fm *fleetMock) GetStatusWithMatcher(ctx context.Context, f func(string) bool) ([]fleet.UnitStatus, error) {
 	if fm.UseTestifyMock {
 		args := fm.Called(f)
 		return args.Get(0).([]fleet.UnitStatus), args.Error(1)
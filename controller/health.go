@@ -0,0 +1,288 @@
+package controller
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/coreos/fleet/unit"
+	"golang.org/x/net/context"
+)
+
+const (
+	// healthCheckSection is the unit file section holding the options of Inago.
+	// Systemd ignores sections prefixed with "X-".
+	healthCheckSection = "X-Inago"
+
+	// healthCheckPortKey is the key within healthCheckSection defining the port
+	// a unit is health checked on, e.g.
+	//
+	//     [X-Inago]
+	//     HealthCheckPort=8080
+	//
+	healthCheckPortKey = "HealthCheckPort"
+
+	// defaultHealthCheckTimeout is used in case HealthCheck.TimeoutSecs is not
+	// set.
+	defaultHealthCheckTimeout = 5 * time.Second
+
+	// defaultHealthCheckGrace is used in case HealthCheck.GraceSecs is not set.
+	defaultHealthCheckGrace = 60 * time.Second
+)
+
+// HealthCheckType represents the way a unit is health checked.
+type HealthCheckType string
+
+const (
+	// HealthCheckNone disables health checks.
+	HealthCheckNone HealthCheckType = ""
+
+	// HealthCheckHTTP checks a unit by sending a HTTP GET request. Responses
+	// having a status code lower than 400 are considered healthy.
+	HealthCheckHTTP HealthCheckType = "http"
+
+	// HealthCheckTCP checks a unit by opening a TCP connection.
+	HealthCheckTCP HealthCheckType = "tcp"
+)
+
+// HealthCheck represents the health check new slices need to pass during an
+// update. Each unit declaring a port using the HealthCheckPort key within its
+// X-Inago section is checked against the IP of each machine it is scheduled
+// to. Units not declaring a port are not checked.
+type HealthCheck struct {
+	// Type represents the way units are checked. HealthCheckNone disables
+	// health checks.
+	Type HealthCheckType
+
+	// Path represents the path requested by HTTP health checks. Defaults to
+	// "/".
+	Path string
+
+	// TimeoutSecs represents the number of seconds a single check may take.
+	// Defaults to 5 seconds.
+	TimeoutSecs int
+
+	// SuccessThreshold represents the number of checks in a row all units of a
+	// slice need to pass to consider the slice healthy. Defaults to 1.
+	SuccessThreshold int
+
+	// GraceSecs represents the number of seconds a new slice has to become
+	// healthy after it got started. Checks are repeated until then. Defaults to
+	// 60 seconds.
+	GraceSecs int
+}
+
+// healthCheckTarget represents a unit to check at a certain address.
+type healthCheckTarget struct {
+	UnitName string
+	Addr     string
+}
+
+// checkHealth checks the slices of the given request until all units
+// declaring a port passed the health check opts.HealthCheck.SuccessThreshold
+// times in a row. In case the slices did not become healthy within
+// opts.HealthCheck.GraceSecs, an error is returned that you can identify using
+// IsHealthCheckFailed.
+func (c controller) checkHealth(ctx context.Context, req Request, opts UpdateOptions) error {
+	hc := opts.HealthCheck
+	if hc.Type == HealthCheckNone {
+		return nil
+	}
+
+	ports, err := healthCheckPorts(req)
+	if err != nil {
+		return maskAny(err)
+	}
+	if len(ports) == 0 {
+		c.Config.Logger.Warning(ctx, "controller: no unit of group '%v' declares %s, skipping health check", req.Group, healthCheckPortKey)
+		return nil
+	}
+
+	threshold := hc.SuccessThreshold
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	c.Config.Logger.Info(ctx, "controller: checking health of slices %v", req.SliceIDs)
+
+	grace := time.Duration(hc.GraceSecs) * time.Second
+	if grace <= 0 {
+		grace = defaultHealthCheckGrace
+	}
+
+	deadline := time.Now().Add(grace)
+	successes := 0
+	for {
+		err := c.probeSlices(ctx, req, ports, hc)
+		if err == nil {
+			successes++
+			if successes >= threshold {
+				return nil
+			}
+		} else {
+			c.Config.Logger.Debug(ctx, "controller: health check failed: %v", err)
+			successes = 0
+		}
+
+		if !time.Now().Before(deadline) {
+			if err == nil {
+				err = fmt.Errorf("passed %d of %d checks", successes, threshold)
+			}
+			return maskAnyf(healthCheckFailedError, "slices %v: %s", req.SliceIDs, err.Error())
+		}
+		if err := sleep(ctx, c.WaitSleep); err != nil {
+			return maskAny(err)
+		}
+	}
+}
+
+// probeSlices checks all units of the given request declaring a port once.
+// The first failing check is returned.
+func (c controller) probeSlices(ctx context.Context, req Request, ports map[string]string, hc HealthCheck) error {
+	unitStatusList, err := c.groupStatusWithValidate(ctx, req)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	var targets []healthCheckTarget
+	for _, us := range unitStatusList {
+		port, ok := ports[us.Name]
+		if !ok {
+			continue
+		}
+		if len(us.Machine) == 0 {
+			return fmt.Errorf("unit '%s' is not scheduled", us.Name)
+		}
+		for _, ms := range us.Machine {
+			if ms.IP == nil {
+				return fmt.Errorf("unit '%s' has no IP", us.Name)
+			}
+			targets = append(targets, healthCheckTarget{
+				UnitName: us.Name,
+				Addr:     net.JoinHostPort(ms.IP.String(), port),
+			})
+		}
+	}
+
+	timeout := time.Duration(hc.TimeoutSecs) * time.Second
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+
+	for _, target := range targets {
+		if err := ctx.Err(); err != nil {
+			return maskAny(err)
+		}
+		if err := probe(target.Addr, hc, timeout, c.Dial); err != nil {
+			return fmt.Errorf("unit '%s' at %s: %s", target.UnitName, target.Addr, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// probe checks the given address once using the given health check. The
+// given dial function is used to connect to the address. In case it is nil,
+// the address is connected to directly.
+func probe(addr string, hc HealthCheck, timeout time.Duration, dial func(network, addr string) (net.Conn, error)) error {
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: timeout}).Dial
+	}
+	dial = dialWithTimeout(dial, timeout)
+
+	switch hc.Type {
+	case HealthCheckHTTP:
+		path := hc.Path
+		if path == "" {
+			path = "/"
+		}
+		client := http.Client{
+			// Health checks go straight to the machines. Thus no proxy is used.
+			// Connections are not kept alive, since dialed connections may be
+			// borrowed from the SSH tunnel, which only gets them back once they
+			// are closed.
+			Transport: &http.Transport{
+				Dial:              dial,
+				DisableKeepAlives: true,
+			},
+			Timeout: timeout,
+		}
+		resp, err := client.Get("http://" + addr + path)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		return nil
+	case HealthCheckTCP:
+		conn, err := dial("tcp", addr)
+		if err != nil {
+			return err
+		}
+		conn.Close()
+		return nil
+	}
+
+	return maskAnyf(invalidArgumentError, "unknown health check type '%s'", hc.Type)
+}
+
+// dialWithTimeout returns a dial function failing in case the given dial
+// function does not return within the given timeout. Connections established
+// afterwards are closed right away.
+func dialWithTimeout(dial func(network, addr string) (net.Conn, error), timeout time.Duration) func(network, addr string) (net.Conn, error) {
+	return func(network, addr string) (net.Conn, error) {
+		type result struct {
+			Conn net.Conn
+			Err  error
+		}
+		results := make(chan result, 1)
+		go func() {
+			conn, err := dial(network, addr)
+			results <- result{Conn: conn, Err: err}
+		}()
+
+		select {
+		case r := <-results:
+			return r.Conn, r.Err
+		case <-time.After(timeout):
+			go func() {
+				if r := <-results; r.Conn != nil {
+					r.Conn.Close()
+				}
+			}()
+			return nil, fmt.Errorf("dial %s %s: timeout after %v", network, addr, timeout)
+		}
+	}
+}
+
+// healthCheckPorts returns the ports declared by the units of the given
+// request, indexed by the unit names of all slices.
+func healthCheckPorts(req Request) (map[string]string, error) {
+	extended, err := req.ExtendSlices()
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	ports := map[string]string{}
+	for _, u := range extended.Units {
+		unitFile, err := unit.NewUnitFile(u.Content)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		values := unitFile.Contents[healthCheckSection][healthCheckPortKey]
+		if len(values) == 0 {
+			continue
+		}
+		port := values[len(values)-1]
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return nil, maskAnyf(invalidArgumentError, "invalid %s '%s' of unit '%s'", healthCheckPortKey, port, u.Name)
+		}
+		ports[u.Name] = port
+	}
+
+	return ports, nil
+}
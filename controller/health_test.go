@@ -0,0 +1,268 @@
+package controller
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/inago/fleet"
+)
+
+func Test_healthCheckPorts(t *testing.T) {
+	testCases := []struct {
+		Content       string
+		ExpectedPorts map[string]string
+		ExpectedError bool
+	}{
+		{
+			Content:       "[Service]\nExecStart=/bin/app\n",
+			ExpectedPorts: map[string]string{},
+		},
+		{
+			Content: "[Service]\nExecStart=/bin/app\n\n[X-Inago]\nHealthCheckPort=8080\n",
+			ExpectedPorts: map[string]string{
+				"falcon-unit@1.service": "8080",
+				"falcon-unit@2.service": "8080",
+			},
+		},
+		{
+			Content:       "[Service]\nExecStart=/bin/app\n\n[X-Inago]\nHealthCheckPort=http\n",
+			ExpectedError: true,
+		},
+	}
+
+	for i, testCase := range testCases {
+		req := Request{
+			RequestConfig: RequestConfig{
+				Group:    "falcon",
+				SliceIDs: []string{"1", "2"},
+			},
+			Units: []Unit{
+				{
+					Name:    "falcon-unit@.service",
+					Content: testCase.Content,
+				},
+			},
+		}
+
+		ports, err := healthCheckPorts(req)
+		if testCase.ExpectedError {
+			if err == nil {
+				t.Fatalf("case %d: expected error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if len(ports) != len(testCase.ExpectedPorts) {
+			t.Fatalf("case %d: expected %v, got %v", i, testCase.ExpectedPorts, ports)
+		}
+		for name, port := range testCase.ExpectedPorts {
+			if ports[name] != port {
+				t.Fatalf("case %d: expected %v, got %v", i, testCase.ExpectedPorts, ports)
+			}
+		}
+	}
+}
+
+func Test_probe_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Error returned by listen:", err)
+	}
+	addr := listener.Addr().String()
+
+	hc := HealthCheck{Type: HealthCheckTCP}
+	if err := probe(addr, hc, time.Second, nil); err != nil {
+		t.Fatal("Expected probe to succeed, got:", err)
+	}
+
+	listener.Close()
+	if err := probe(addr, hc, time.Second, nil); err == nil {
+		t.Fatal("Expected probe to fail, got none")
+	}
+}
+
+// Test_probe_Dial tests that the given dial function is used to connect to
+// units, e.g. to reach them through an SSH tunnel.
+func Test_probe_Dial(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Error returned by listen:", err)
+	}
+	defer listener.Close()
+
+	var dialed []string
+	dial := func(network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		return net.Dial(network, listener.Addr().String())
+	}
+
+	hc := HealthCheck{Type: HealthCheckTCP}
+	if err := probe("10.0.0.1:8080", hc, time.Second, dial); err != nil {
+		t.Fatal("Expected probe to succeed, got:", err)
+	}
+	if len(dialed) != 1 || dialed[0] != "10.0.0.1:8080" {
+		t.Fatal("Expected probe to dial 10.0.0.1:8080, got:", dialed)
+	}
+
+	// Dial functions not returning in time fail the probe.
+	block := make(chan struct{})
+	defer close(block)
+	dial = func(network, addr string) (net.Conn, error) {
+		<-block
+		return nil, fmt.Errorf("test failure")
+	}
+	if err := probe("10.0.0.1:8080", hc, 10*time.Millisecond, dial); err == nil {
+		t.Fatal("Expected probe to fail, got none")
+	}
+}
+
+// closeCountingConn counts the number of times connections are closed.
+type closeCountingConn struct {
+	net.Conn
+	Closed *int32
+}
+
+func (c closeCountingConn) Close() error {
+	atomic.AddInt32(c.Closed, 1)
+	return c.Conn.Close()
+}
+
+// Test_probe_Dial_Close tests that HTTP health checks close the connections
+// they dialed, which are e.g. borrowed from the SSH tunnel.
+func Test_probe_Dial_Close(t *testing.T) {
+	for _, statusCode := range []int{http.StatusOK, http.StatusNoContent} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(statusCode)
+		}))
+
+		var dialed, closed int32
+		dial := func(network, addr string) (net.Conn, error) {
+			conn, err := net.Dial(network, addr)
+			if err != nil {
+				return nil, err
+			}
+			atomic.AddInt32(&dialed, 1)
+			return closeCountingConn{Conn: conn, Closed: &closed}, nil
+		}
+
+		hc := HealthCheck{Type: HealthCheckHTTP}
+		for i := 0; i < 3; i++ {
+			if err := probe(server.Listener.Addr().String(), hc, time.Second, dial); err != nil {
+				t.Fatalf("status code %d: expected probe to succeed, got: %v", statusCode, err)
+			}
+		}
+		server.Close()
+
+		// The transport closes connections in the background.
+		for deadline := time.Now().Add(time.Second); atomic.LoadInt32(&closed) < 3 && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if d, c := atomic.LoadInt32(&dialed), atomic.LoadInt32(&closed); d != 3 || c != d {
+			t.Fatalf("status code %d: expected 3 connections to be dialed and closed, got %d dialed and %d closed", statusCode, d, c)
+		}
+	}
+}
+
+// givenHealthCheckedGroup returns a request like givenCanaryGroup, whose units
+// declare the port of the given server as health check port. IPs are assigned
+// to launched units of the dummy fleet until the returned function is called.
+func givenHealthCheckedGroup(t *testing.T, dummyFleet *fleet.DummyFleet, server *httptest.Server) (Request, func()) {
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal("Error returned splitting server address:", err)
+	}
+
+	req := givenCanaryGroup(dummyFleet, "1", "2")
+	req.Units[0].Content += "\n[X-Inago]\nHealthCheckPort=" + port + "\n"
+
+	stop := watchDummyFleet(dummyFleet, func() {
+		for name, us := range dummyFleet.Units {
+			if len(us.Machine) > 0 && us.Machine[0].IP == nil {
+				// The machine statuses are shared with status lists returned
+				// earlier. Thus they are copied before being modified.
+				us.Machine = append([]fleet.MachineStatus{}, us.Machine...)
+				us.Machine[0].IP = net.ParseIP("127.0.0.1")
+				dummyFleet.Units[name] = us
+			}
+		}
+	})
+
+	return req, stop
+}
+
+// TestUpdate_HealthCheck tests that new slices passing the health check are
+// added.
+func TestUpdate_HealthCheck(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	testController.WaitSleep = 10 * time.Millisecond
+
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	req, stop := givenHealthCheckedGroup(t, dummyFleet, server)
+	defer stop()
+
+	opts := UpdateOptions{
+		MaxGrowth: 1,
+		MinAlive:  1,
+		HealthCheck: HealthCheck{
+			Type:             HealthCheckHTTP,
+			Path:             "/health",
+			SuccessThreshold: 2,
+		},
+	}
+	if err := waitForUpdate(t, testController, req, opts); err != nil {
+		t.Fatal("Update failed:", err)
+	}
+
+	// Each of the 2 new slices needs to pass 2 checks.
+	if n := atomic.LoadInt64(&requests); n < 4 {
+		t.Fatal("Expected at least 4 health check requests, got:", n)
+	}
+	for _, content := range unitContents(dummyFleet) {
+		if content == canaryOldContent {
+			t.Fatal("Expected all slices to be updated, got:", content)
+		}
+	}
+}
+
+// TestUpdate_HealthCheck_Failed tests that a new slice failing the health
+// check fails the update.
+func TestUpdate_HealthCheck_Failed(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	testController.WaitSleep = 10 * time.Millisecond
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	req, stop := givenHealthCheckedGroup(t, dummyFleet, server)
+	defer stop()
+
+	opts := UpdateOptions{
+		MaxGrowth: 1,
+		MinAlive:  1,
+		HealthCheck: HealthCheck{
+			Type:      HealthCheckHTTP,
+			GraceSecs: 1,
+		},
+	}
+	err := waitForUpdate(t, testController, req, opts)
+	if !IsHealthCheckFailed(err) {
+		t.Fatal("Expected health check to fail, got:", err)
+	}
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/common"
+	"github.com/giantswarm/inago/fleet"
+)
+
+// GroupSummary represents the summarized status of a group found within the
+// fleet cluster.
+type GroupSummary struct {
+	// Group is the group name inferred from the names of the group's units.
+	Group string
+
+	// SliceIDs contains the IDs of the slices of the group. This is empty for
+	// unsliced groups.
+	SliceIDs []string
+
+	// Statuses contains the distinct aggregated statuses of the units of the
+	// group, in alphabetical order. Units in states not known to the
+	// StatusIndex have StatusUnknown.
+	Statuses []Status
+
+	// HashesEqual is true when each unit has the same content hash across all
+	// slices of the group.
+	HashesEqual bool
+}
+
+func (c controller) List(ctx context.Context) ([]GroupSummary, error) {
+	c.Config.Logger.Debug(ctx, "controller: listing groups")
+
+	unitStatusList, err := c.getStatusWithMatcher(ctx, func(string) bool { return true })
+	if fleet.IsUnitNotFound(err) {
+		// There are no units at all.
+		return nil, nil
+	} else if err != nil {
+		return nil, maskAny(err)
+	}
+
+	groups := map[string]UnitStatusList{}
+	for _, us := range unitStatusList {
+		group := groupOfUnit(us.Name)
+		groups[group] = append(groups[group], us)
+	}
+	groups = mergePrefixGroups(groups)
+
+	var names []string
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	aggregator := Aggregator{
+		Logger: c.Config.Logger,
+	}
+
+	var summaries []GroupSummary
+	for _, name := range names {
+		usl := groups[name]
+
+		hashesEqual, err := allHashesEqual(usl)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		summary := GroupSummary{
+			Group:       name,
+			HashesEqual: hashesEqual,
+		}
+
+		seenSliceIDs := map[string]struct{}{}
+		seenStatuses := map[Status]struct{}{}
+		for _, us := range usl.ResolveTriggered() {
+			sliceID, err := common.SliceID(us.Name)
+			if err != nil {
+				return nil, maskAny(err)
+			}
+			if _, ok := seenSliceIDs[sliceID]; !ok && sliceID != "" {
+				seenSliceIDs[sliceID] = struct{}{}
+				summary.SliceIDs = append(summary.SliceIDs, sliceID)
+			}
+
+			// Listing all units of the cluster includes units in states not
+			// known to the StatusIndex. These must not prevent listing the other
+			// groups.
+			status, err := aggregator.AggregateUnitStatus(us)
+			if IsInvalidUnitStatus(err) {
+				status = StatusUnknown
+			} else if err != nil {
+				return nil, maskAny(err)
+			}
+			if _, ok := seenStatuses[status]; !ok {
+				seenStatuses[status] = struct{}{}
+				summary.Statuses = append(summary.Statuses, status)
+			}
+		}
+		sort.Strings(summary.SliceIDs)
+		sort.Sort(statusesByName(summary.Statuses))
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+// groupOfUnit infers the group name from the given unit name. Unit names are
+// prefixed with their group name, followed by a dash and the name of the unit
+// within the group, e.g. "app-server@1.service" belongs to group "app". As
+// group names can contain dashes themselves, everything up to the last dash is
+// considered the group name. Candidates of the same group are merged using
+// mergePrefixGroups.
+//
+// The heuristic misgroups units in some cases, as unit names do not record
+// their group name:
+//
+//   - Units of a group named e.g. "app" whose unit names all contain further
+//     dashes, like "app-some-unit@1.service" and "app-other-unit@1.service",
+//     are listed as groups "app-some" and "app-other".
+//   - Units not managed by Inago are grouped by the same rules, e.g.
+//     "docker-cleanup.service" is listed as group "docker". In case their name
+//     is prefixed by the name of a group and a dash, like "app-backup.service",
+//     they are merged into that group.
+//
+func groupOfUnit(name string) string {
+	base := common.UnitBase(name)
+	if i := strings.LastIndex(base, "-"); i > 0 {
+		return base[:i]
+	}
+
+	return base
+}
+
+// mergePrefixGroups merges the units of groups whose name is prefixed by the
+// name of another group and a dash into that group. E.g. units of
+// "app-some-unit@1.service" are inferred to belong to group "app-some", but
+// belong to group "app" in case there is a unit "app-server@1.service" as
+// well. The validation of groups does not allow group names prefixing other
+// group names. Thus the shortest prefix is the actual group name.
+func mergePrefixGroups(groups map[string]UnitStatusList) map[string]UnitStatusList {
+	var names []string
+	for name := range groups {
+		names = append(names, name)
+	}
+	// Prefixes are sorted before the names they prefix. This way units are
+	// always merged into the shortest prefix.
+	sort.Strings(names)
+
+	merged := map[string]UnitStatusList{}
+	for _, name := range names {
+		target := name
+		for other := range merged {
+			if strings.HasPrefix(name, other+"-") {
+				target = other
+				break
+			}
+		}
+		merged[target] = append(merged[target], groups[name]...)
+	}
+
+	return merged
+}
+
+type statusesByName []Status
+
+func (s statusesByName) Len() int           { return len(s) }
+func (s statusesByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s statusesByName) Less(i, j int) bool { return s[i] < s[j] }
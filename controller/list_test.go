@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+// TestList_Empty tests that listing an empty cluster results in no groups.
+func TestList_Empty(t *testing.T) {
+	testController, _ := getTestController()
+
+	summaries, err := testController.List(context.Background())
+	if err != nil {
+		t.Fatal("Error returned by list:", err)
+	}
+	if len(summaries) != 0 {
+		t.Fatal("Expected no groups, got:", summaries)
+	}
+}
+
+// TestList tests that groups are inferred from unit names and summarized.
+func TestList(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	ctx := context.Background()
+
+	// Group "app" has 2 slices, where slice 2 runs a different version of the
+	// unit "app-some-unit".
+	dummyFleet.Submit(ctx, "app-server@1.service", "[Service]\nExecStart=/bin/server\n")
+	dummyFleet.Submit(ctx, "app-some-unit@1.service", "[Service]\nExecStart=/bin/some-unit\n")
+	dummyFleet.Submit(ctx, "app-server@2.service", "[Service]\nExecStart=/bin/server\n")
+	dummyFleet.Submit(ctx, "app-some-unit@2.service", "[Service]\nExecStart=/bin/some-unit-v2\n")
+	dummyFleet.Start(ctx, "app-server@1.service")
+	dummyFleet.Start(ctx, "app-some-unit@1.service")
+
+	// Group "my-db" is unsliced and contains dashes.
+	dummyFleet.Submit(ctx, "my-db-server.service", "[Service]\nExecStart=/bin/db\n")
+	dummyFleet.Start(ctx, "my-db-server.service")
+
+	summaries, err := testController.List(ctx)
+	if err != nil {
+		t.Fatal("Error returned by list:", err)
+	}
+
+	expected := []GroupSummary{
+		{
+			Group:       "app",
+			SliceIDs:    []string{"1", "2"},
+			Statuses:    []Status{StatusRunning, StatusStopped},
+			HashesEqual: false,
+		},
+		{
+			Group:       "my-db",
+			Statuses:    []Status{StatusRunning},
+			HashesEqual: true,
+		},
+	}
+	if !reflect.DeepEqual(summaries, expected) {
+		t.Fatalf("Expected %#v, got %#v", expected, summaries)
+	}
+}
+
+// TestList_UnknownStatus tests that units in states not known to the
+// StatusIndex, e.g. units not managed by Inago, do not prevent listing groups.
+func TestList_UnknownStatus(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	ctx := context.Background()
+
+	dummyFleet.Submit(ctx, "app-server@1.service", "[Service]\nExecStart=/bin/server\n")
+	dummyFleet.Start(ctx, "app-server@1.service")
+
+	dummyFleet.Submit(ctx, "docker-cleanup.service", "[Service]\nExecStart=/bin/cleanup\n")
+	dummyFleet.Start(ctx, "docker-cleanup.service")
+	us := dummyFleet.Units["docker-cleanup.service"]
+	us.Machine[0].SystemdActive = "maintenance"
+	dummyFleet.Units["docker-cleanup.service"] = us
+
+	summaries, err := testController.List(ctx)
+	if err != nil {
+		t.Fatal("Error returned by list:", err)
+	}
+
+	expected := []GroupSummary{
+		{
+			Group:       "app",
+			SliceIDs:    []string{"1"},
+			Statuses:    []Status{StatusRunning},
+			HashesEqual: true,
+		},
+		{
+			Group:       "docker",
+			Statuses:    []Status{StatusUnknown},
+			HashesEqual: true,
+		},
+	}
+	if !reflect.DeepEqual(summaries, expected) {
+		t.Fatalf("Expected %#v, got %#v", expected, summaries)
+	}
+}
+
+// TestList_Templated tests that units rendered for their slice, e.g. using
+// {{.SliceID}}, have equal hashes in case they are rendered from the same
+// unit, and that non-templated units mentioning a slice ID are equal as well.
+func TestList_Templated(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	ctx := context.Background()
+
+	dummyFleet.Submit(ctx, "app-server@1.service", "[Service]\nExecStart=/bin/server --name=app-1\n")
+	dummyFleet.Submit(ctx, "app-server@2.service", "[Service]\nExecStart=/bin/server --name=app-2\n")
+	dummyFleet.Submit(ctx, "app-worker@1.service", "[Service]\nExecStart=/bin/worker --workers=1\n")
+	dummyFleet.Submit(ctx, "app-worker@2.service", "[Service]\nExecStart=/bin/worker --workers=1\n")
+	dummyFleet.Submit(ctx, "db-server@1.service", "[Service]\nExecStart=/bin/db --name=db-1\n")
+	dummyFleet.Submit(ctx, "db-server@2.service", "[Service]\nExecStart=/bin/db --name=db-2 --verbose\n")
+
+	summaries, err := testController.List(ctx)
+	if err != nil {
+		t.Fatal("Error returned by list:", err)
+	}
+
+	expected := []GroupSummary{
+		{
+			Group:       "app",
+			SliceIDs:    []string{"1", "2"},
+			Statuses:    []Status{StatusStopped},
+			HashesEqual: true,
+		},
+		{
+			Group:       "db",
+			SliceIDs:    []string{"1", "2"},
+			Statuses:    []Status{StatusStopped},
+			HashesEqual: false,
+		},
+	}
+	if !reflect.DeepEqual(summaries, expected) {
+		t.Fatalf("Expected %#v, got %#v", expected, summaries)
+	}
+}
+
+func Test_groupOfUnit(t *testing.T) {
+	testCases := []struct {
+		Input    string
+		Expected string
+	}{
+		{Input: "app-server@1.service", Expected: "app"},
+		{Input: "app-server.service", Expected: "app"},
+		{Input: "002-update-group-foo@a1b.service", Expected: "002-update-group"},
+		{Input: "app@1.service", Expected: "app"},
+		{Input: "app.timer", Expected: "app"},
+	}
+
+	for i, testCase := range testCases {
+		output := groupOfUnit(testCase.Input)
+		if output != testCase.Expected {
+			t.Fatalf("test case %d: expected %s, got %s", i+1, testCase.Expected, output)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/fleet"
+)
+
+// PauseStorage represents some storage solution to persist which groups have
+// their updates paused. Updates check the storage before updating each slice.
+// Thus a storage shared across processes allows pausing the updates executed
+// by other processes.
+type PauseStorage interface {
+	// IsPaused returns whether updates of the given group are paused.
+	IsPaused(ctx context.Context, group string) (bool, error)
+
+	// SetPaused pauses or resumes updates of the given group.
+	SetPaused(ctx context.Context, group string, paused bool) error
+}
+
+// NewMemoryPauseStorage creates a PauseStorage only visible to the current
+// process.
+func NewMemoryPauseStorage() PauseStorage {
+	newStorage := &memoryPauseStorage{
+		paused: map[string]struct{}{},
+	}
+
+	return newStorage
+}
+
+type memoryPauseStorage struct {
+	mutex  sync.Mutex
+	paused map[string]struct{}
+}
+
+func (ms *memoryPauseStorage) IsPaused(ctx context.Context, group string) (bool, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	_, ok := ms.paused[group]
+	return ok, nil
+}
+
+func (ms *memoryPauseStorage) SetPaused(ctx context.Context, group string, paused bool) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	if paused {
+		ms.paused[group] = struct{}{}
+	} else {
+		delete(ms.paused, group)
+	}
+
+	return nil
+}
+
+// pauseMarkerPrefix prefixes the names of the marker units the fleet pause
+// storage submits for paused groups. Unit names of groups must not contain
+// more than one @. Thus marker units named using the prefix cannot belong to
+// any group. See isPauseMarker.
+const pauseMarkerPrefix = "inago@paused@"
+
+// pauseMarkerContent is the content of marker units. Marker units require a
+// machine ID no fleet machine has. Thus fleet never schedules them to any
+// machine, but only keeps them within its registry.
+const pauseMarkerContent = "[Unit]\nDescription=Marks updates of the group named by this unit as paused. Managed by inagoctl update pause and resume.\n\n[Service]\nExecStart=/bin/true\n\n[X-Fleet]\nMachineID=inago-update-paused\n"
+
+// FleetPauseStorageConfig represents the configuration used to create a new
+// fleet pause storage.
+type FleetPauseStorageConfig struct {
+	// Fleet is the fleet cluster marker units are submitted to.
+	Fleet fleet.Fleet
+}
+
+// DefaultFleetPauseStorageConfig returns a best effort default configuration
+// for the fleet pause storage.
+func DefaultFleetPauseStorageConfig() FleetPauseStorageConfig {
+	newFleetConfig := fleet.DefaultConfig()
+	newFleet, err := fleet.NewFleet(newFleetConfig)
+	if err != nil {
+		panic(err)
+	}
+
+	newConfig := FleetPauseStorageConfig{
+		Fleet: newFleet,
+	}
+
+	return newConfig
+}
+
+// NewFleetPauseStorage creates a PauseStorage marking paused groups within the
+// fleet cluster. For each paused group a marker unit is submitted, named
+// pauseMarkerPrefix followed by the group name. This way updates executed by
+// any process on any machine using the same fleet cluster can be paused.
+func NewFleetPauseStorage(config FleetPauseStorageConfig) (PauseStorage, error) {
+	if config.Fleet == nil {
+		return nil, maskAnyf(invalidArgumentError, "fleet must not be empty")
+	}
+
+	newStorage := &fleetPauseStorage{
+		FleetPauseStorageConfig: config,
+	}
+
+	return newStorage, nil
+}
+
+type fleetPauseStorage struct {
+	FleetPauseStorageConfig
+}
+
+func (fs *fleetPauseStorage) IsPaused(ctx context.Context, group string) (bool, error) {
+	name, err := pauseMarker(group)
+	if err != nil {
+		return false, maskAny(err)
+	}
+
+	_, err = fs.Fleet.GetStatus(ctx, name)
+	if fleet.IsUnitNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, maskAny(err)
+	}
+
+	return true, nil
+}
+
+func (fs *fleetPauseStorage) SetPaused(ctx context.Context, group string, paused bool) error {
+	name, err := pauseMarker(group)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	if !paused {
+		err := fs.Fleet.Destroy(ctx, name)
+		if err != nil && !fleet.IsUnitNotFound(err) {
+			return maskAny(err)
+		}
+		return nil
+	}
+
+	if err := fs.Fleet.Submit(ctx, name, pauseMarkerContent); err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// pauseMarker returns the name of the marker unit of the given group.
+func pauseMarker(group string) (string, error) {
+	if group == "" || strings.ContainsAny(group, `/\@`) {
+		return "", maskAnyf(invalidArgumentError, "invalid group name '%s'", group)
+	}
+
+	return pauseMarkerPrefix + group + ".service", nil
+}
+
+// isPauseMarker checks whether the given unit name is the name of a marker
+// unit of the fleet pause storage. Group names given by requests might prefix
+// the names of marker units, e.g. group "inago". Thus marker units are
+// excluded by getStatusWithMatcher.
+func isPauseMarker(name string) bool {
+	return strings.HasPrefix(name, pauseMarkerPrefix)
+}
+
+func (c controller) PauseUpdate(ctx context.Context, req Request) error {
+	c.Config.Logger.Debug(ctx, "controller: pausing updates of group '%v'", req.Group)
+
+	if err := c.PauseStorage.SetPaused(ctx, req.Group, true); err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (c controller) ResumeUpdate(ctx context.Context, req Request) error {
+	c.Config.Logger.Debug(ctx, "controller: resuming updates of group '%v'", req.Group)
+
+	if err := c.PauseStorage.SetPaused(ctx, req.Group, false); err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (c controller) IsUpdatePaused(ctx context.Context, req Request) (bool, error) {
+	paused, err := c.PauseStorage.IsPaused(ctx, req.Group)
+	if err != nil {
+		return false, maskAny(err)
+	}
+
+	return paused, nil
+}
+
+// waitWhileUpdatePaused blocks as long as updates of the given group are
+// paused. Errors received from fail end waiting, as slices being updated
+// meanwhile might fail.
+func (c controller) waitWhileUpdatePaused(ctx context.Context, req Request, fail <-chan error) error {
+	logged := false
+	for {
+		paused, err := c.IsUpdatePaused(ctx, req)
+		if err != nil {
+			return maskAny(err)
+		}
+		if !paused {
+			if logged {
+				c.Config.Logger.Info(ctx, "controller: resuming update of group '%v'", req.Group)
+			}
+			return nil
+		}
+		if !logged {
+			c.Config.Logger.Info(ctx, "controller: update of group '%v' paused, waiting for resume", req.Group)
+			logged = true
+		}
+
+		select {
+		case err := <-fail:
+			return maskAny(err)
+		case <-ctx.Done():
+			return maskAny(ctx.Err())
+		case <-time.After(c.WaitSleep):
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coreos/fleet/unit"
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/fleet"
+)
+
+func TestFleetPauseStorage(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	ctx := context.Background()
+
+	newConfig := DefaultFleetPauseStorageConfig()
+	newConfig.Fleet = dummyFleet
+	storage, err := NewFleetPauseStorage(newConfig)
+	if err != nil {
+		t.Fatal("Error returned by NewFleetPauseStorage:", err)
+	}
+	// A second storage using the same fleet cluster represents another process
+	// or machine.
+	other, err := NewFleetPauseStorage(newConfig)
+	if err != nil {
+		t.Fatal("Error returned by NewFleetPauseStorage:", err)
+	}
+
+	if err := storage.SetPaused(ctx, "falcon", true); err != nil {
+		t.Fatal("Error returned by SetPaused:", err)
+	}
+	if paused, err := other.IsPaused(ctx, "falcon"); err != nil || !paused {
+		t.Fatal("Expected group to be paused, got:", paused, err)
+	}
+	if paused, err := other.IsPaused(ctx, "eagle"); err != nil || paused {
+		t.Fatal("Expected other group not to be paused, got:", paused, err)
+	}
+
+	// Marker units do not belong to any group.
+	givenCanaryGroup(dummyFleet, "1")
+	req := Request{
+		RequestConfig: RequestConfig{
+			Group: "inago",
+		},
+	}
+	usl, err := testController.GetStatus(ctx, req)
+	if err != nil && !IsUnitNotFound(err) {
+		t.Fatal("Error returned by GetStatus:", err)
+	}
+	if len(usl) != 0 {
+		t.Fatal("Expected marker unit not to belong to group 'inago', got:", usl)
+	}
+	summaries, err := testController.List(ctx)
+	if err != nil {
+		t.Fatal("Error returned by List:", err)
+	}
+	if len(summaries) != 1 || summaries[0].Group != "falcon" {
+		t.Fatalf("Expected only group 'falcon' to be listed, got %#v", summaries)
+	}
+
+	if err := other.SetPaused(ctx, "falcon", false); err != nil {
+		t.Fatal("Error returned by SetPaused:", err)
+	}
+	if paused, err := storage.IsPaused(ctx, "falcon"); err != nil || paused {
+		t.Fatal("Expected group to be resumed, got:", paused, err)
+	}
+	// Resuming a group not being paused does nothing.
+	if err := storage.SetPaused(ctx, "falcon", false); err != nil {
+		t.Fatal("Error returned by SetPaused:", err)
+	}
+
+	for _, group := range []string{"", "falcon/1", "falcon@1"} {
+		if err := storage.SetPaused(ctx, group, true); !IsInvalidArgument(err) {
+			t.Fatalf("Expected invalid argument for group '%s', got: %v", group, err)
+		}
+	}
+}
+
+// TestFleetPauseStorage_Marker tests that marker units are never scheduled,
+// and that pausing is recorded like any other operation during dry runs.
+func TestFleetPauseStorage_Marker(t *testing.T) {
+	_, dummyFleet := getTestController()
+	ctx := context.Background()
+
+	newPlanConfig := fleet.DefaultPlanConfig()
+	newPlanConfig.Fleet = dummyFleet
+	planFleet := fleet.NewPlanFleet(newPlanConfig)
+
+	newConfig := DefaultFleetPauseStorageConfig()
+	newConfig.Fleet = planFleet
+	storage, err := NewFleetPauseStorage(newConfig)
+	if err != nil {
+		t.Fatal("Error returned by NewFleetPauseStorage:", err)
+	}
+
+	if err := storage.SetPaused(ctx, "falcon", true); err != nil {
+		t.Fatal("Error returned by SetPaused:", err)
+	}
+	operations := planFleet.Flush()
+	if len(operations) != 1 || operations[0].Name != "inago@paused@falcon.service" {
+		t.Fatalf("Expected marker unit to be submitted, got %#v", operations)
+	}
+	if len(dummyFleet.Units) != 0 {
+		t.Fatal("Expected marker unit not to be submitted during dry run, got:", dummyFleet.Units)
+	}
+
+	unitFile, err := unit.NewUnitFile(pauseMarkerContent)
+	if err != nil {
+		t.Fatal("Error returned parsing marker unit:", err)
+	}
+	if values := unitFile.Contents["X-Fleet"]["MachineID"]; len(values) != 1 {
+		t.Fatal("Expected marker unit to require a machine ID, got:", values)
+	}
+	if !isPauseMarker("inago@paused@falcon.service") || isPauseMarker("inago-paused-falcon.service") {
+		t.Fatal("Expected only marker units to be recognized")
+	}
+}
+
+// TestUpdate_Pause tests that a paused update does not update any slice until
+// it is resumed.
+func TestUpdate_Pause(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	testController.WaitSleep = 10 * time.Millisecond
+	req := givenCanaryGroup(dummyFleet, "1", "2")
+
+	if err := testController.PauseUpdate(context.Background(), req); err != nil {
+		t.Fatal("Error returned by PauseUpdate:", err)
+	}
+
+	opts := UpdateOptions{
+		MaxGrowth: 1,
+		MinAlive:  1,
+	}
+	taskObject, err := testController.Update(context.Background(), req, opts)
+	if err != nil {
+		t.Fatal("Error returned by update:", err)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	for _, content := range unitContents(dummyFleet) {
+		if content != canaryOldContent {
+			t.Fatal("Expected no slice to be updated while paused, got:", content)
+		}
+	}
+	paused, err := testController.IsUpdatePaused(context.Background(), req)
+	if err != nil || !paused {
+		t.Fatal("Expected update to be paused, got:", paused, err)
+	}
+
+	if err := testController.ResumeUpdate(context.Background(), req); err != nil {
+		t.Fatal("Error returned by ResumeUpdate:", err)
+	}
+	taskObject, err = testController.WaitForTask(context.Background(), taskObject.ID, nil)
+	if err != nil || taskObject.Error != nil {
+		t.Fatal("Update failed:", err, taskObject.Error)
+	}
+
+	for _, content := range unitContents(dummyFleet) {
+		if content != canaryNewContent {
+			t.Fatal("Expected all slices to be updated, got:", content)
+		}
+	}
+}
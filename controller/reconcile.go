@@ -0,0 +1,283 @@
+package controller
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/task"
+)
+
+// ReconcileOptions represents the desired state a group is supposed to
+// converge to using Reconcile.
+type ReconcileOptions struct {
+	// DesiredSlices represents the number of slices the group is supposed to
+	// have. A negative value keeps the current number of slices, or creates one
+	// slice in case the group does not exist yet. Zero destroys the group.
+	// Unsliced groups can only have zero or one slice.
+	DesiredSlices int
+
+	// DesiredStatus represents the status all slices of the group are supposed
+	// to have. This can either be StatusRunning or StatusStopped.
+	DesiredStatus Status
+
+	// UpdateOptions defines the strategy used to update running slices whose
+	// units changed. Slices that are not running are simply replaced. See also
+	// Update.
+	UpdateOptions UpdateOptions
+}
+
+func (c controller) Reconcile(ctx context.Context, req Request, opts ReconcileOptions) (*task.Task, error) {
+	c.Config.Logger.Debug(ctx, "controller: handling reconcile for group '%v'", req.Group)
+
+	if opts.DesiredStatus != StatusRunning && opts.DesiredStatus != StatusStopped {
+		return nil, maskAnyf(invalidArgumentError, "desired status must be '%s' or '%s'", StatusRunning, StatusStopped)
+	}
+	if !req.isSliceable() && opts.DesiredSlices > 1 {
+		return nil, maskAnyf(invalidArgumentError, "number of slices of unsliced groups must be 0 or 1")
+	}
+
+	action := func(ctx context.Context) error {
+		var err error
+		if req.isSliceable() {
+			err = c.reconcileSliced(ctx, req, opts)
+		} else {
+			err = c.reconcileUnsliced(ctx, req, opts)
+		}
+		if err != nil {
+			return maskAny(err)
+		}
+
+		return nil
+	}
+
+	taskObject, err := c.TaskService.Create(ctx, action)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	return taskObject, nil
+}
+
+// reconcileSliced converges a group of sliceable units. Superfluous slices are
+// removed first, so they do not need to be updated. Outdated slices are
+// updated next. Missing slices are added last, so they are already created
+// using the new units.
+func (c controller) reconcileSliced(ctx context.Context, req Request, opts ReconcileOptions) error {
+	currentSliceIDs, err := c.getExistingSliceIDs(ctx, req)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	desiredSlices := opts.DesiredSlices
+	if desiredSlices < 0 {
+		desiredSlices = len(currentSliceIDs)
+		if desiredSlices == 0 {
+			desiredSlices = 1
+		}
+	}
+
+	c.Config.Logger.Debug(ctx, "controller: reconciling slices %v of group '%v' to %v slices", currentSliceIDs, req.Group, desiredSlices)
+
+	if desiredSlices < len(currentSliceIDs) {
+		err := c.scaleDown(ctx, req, currentSliceIDs, len(currentSliceIDs)-desiredSlices)
+		if err != nil {
+			return maskAny(err)
+		}
+		currentSliceIDs, err = c.getExistingSliceIDs(ctx, req)
+		if err != nil {
+			return maskAny(err)
+		}
+	}
+
+	if len(currentSliceIDs) > 0 {
+		sliceReq := req
+		sliceReq.SliceIDs = currentSliceIDs
+		err := c.reconcileUnits(ctx, sliceReq, opts)
+		if err != nil {
+			return maskAny(err)
+		}
+		currentSliceIDs, err = c.getExistingSliceIDs(ctx, req)
+		if err != nil {
+			return maskAny(err)
+		}
+	}
+
+	if desiredSlices > len(currentSliceIDs) {
+		addReq := req
+		addReq.SliceIDs = nil
+		addReq.DesiredSlices = desiredSlices - len(currentSliceIDs)
+		addReq, err := c.ExtendWithRandomSliceIDs(ctx, addReq)
+		if err != nil {
+			return maskAny(err)
+		}
+
+		c.Config.Logger.Info(ctx, "controller: adding slices %v", addReq.SliceIDs)
+
+		if err := c.executeTaskAction(c.Submit, ctx, addReq); err != nil {
+			return maskAny(err)
+		}
+		currentSliceIDs = append(currentSliceIDs, addReq.SliceIDs...)
+	}
+
+	if len(currentSliceIDs) == 0 {
+		return nil
+	}
+
+	statusReq := req
+	statusReq.SliceIDs = currentSliceIDs
+	err = c.reconcileStatus(ctx, statusReq, opts.DesiredStatus)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// reconcileUnsliced converges a group of units that cannot be sliced. Such a
+// group exists at most once. Outdated units are replaced, since unsliced
+// groups cannot be updated.
+func (c controller) reconcileUnsliced(ctx context.Context, req Request, opts ReconcileOptions) error {
+	req.SliceIDs = nil
+
+	_, err := c.groupStatus(ctx, req)
+	exists := !IsUnitNotFound(err)
+	if err != nil && exists {
+		return maskAny(err)
+	}
+
+	if opts.DesiredSlices == 0 {
+		if !exists {
+			return nil
+		}
+
+		c.Config.Logger.Info(ctx, "controller: removing group '%v'", req.Group)
+
+		if err := c.runRemoveWorker(ctx, req); err != nil {
+			return maskAny(err)
+		}
+
+		return nil
+	}
+
+	if exists {
+		err := c.reconcileUnits(ctx, req, opts)
+		if err != nil {
+			return maskAny(err)
+		}
+	} else {
+		c.Config.Logger.Info(ctx, "controller: adding group '%v'", req.Group)
+
+		submitReq := req
+		submitReq.DesiredSlices = 1
+		if err := c.executeTaskAction(c.Submit, ctx, submitReq); err != nil {
+			return maskAny(err)
+		}
+	}
+
+	err = c.reconcileStatus(ctx, req, opts.DesiredStatus)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// reconcileUnits brings the units of the slices given by req up to date.
+// Running slices of sliceable groups are updated using opts.UpdateOptions.
+// All other outdated slices are replaced in place, keeping their slice IDs.
+func (c controller) reconcileUnits(ctx context.Context, req Request, opts ReconcileOptions) error {
+	dirtyReq, ok, err := c.GroupNeedsUpdate(ctx, req)
+	if err != nil {
+		return maskAny(err)
+	}
+	if !ok {
+		c.Config.Logger.Debug(ctx, "controller: units of group '%v' already up to date", req.Group)
+		return nil
+	}
+	if !req.isSliceable() {
+		dirtyReq.SliceIDs = nil
+	}
+
+	numRunning, err := c.getNumRunningSlices(ctx, dirtyReq)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	if req.isSliceable() && numRunning > 0 {
+		c.Config.Logger.Info(ctx, "controller: updating slices %v of group '%v'", dirtyReq.SliceIDs, req.Group)
+
+		update := func(ctx context.Context, req Request) (*task.Task, error) {
+			return c.Update(ctx, req, opts.UpdateOptions)
+		}
+		if err := c.executeTaskAction(update, ctx, dirtyReq); err != nil {
+			return maskAny(err)
+		}
+
+		return nil
+	}
+
+	c.Config.Logger.Info(ctx, "controller: replacing slices %v of group '%v'", dirtyReq.SliceIDs, req.Group)
+
+	if err := c.runRemoveWorker(ctx, dirtyReq); err != nil {
+		return maskAny(err)
+	}
+	submitReq := dirtyReq
+	if !req.isSliceable() {
+		submitReq.DesiredSlices = 1
+	}
+	if err := c.executeTaskAction(c.Submit, ctx, submitReq); err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// reconcileStatus starts, or stops, all slices given by req that do not have
+// the given desired status.
+func (c controller) reconcileStatus(ctx context.Context, req Request, desiredStatus Status) error {
+	unitStatusList, err := c.groupStatus(ctx, req)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	aggregator := Aggregator{
+		Logger: c.Config.Logger,
+	}
+
+	var pending bool
+	var sliceIDs []string
+	for _, us := range UnitStatusList(unitStatusList).ResolveTriggered() {
+		ok, err := aggregator.UnitHasStatus(us, desiredStatus)
+		if err != nil {
+			return maskAny(err)
+		}
+		if ok {
+			continue
+		}
+
+		pending = true
+		if us.SliceID != "" && !contains(sliceIDs, us.SliceID) {
+			sliceIDs = append(sliceIDs, us.SliceID)
+		}
+	}
+	if !pending {
+		c.Config.Logger.Debug(ctx, "controller: group '%v' already has status '%v'", req.Group, desiredStatus)
+		return nil
+	}
+
+	statusReq := req
+	statusReq.SliceIDs = sliceIDs
+
+	switch desiredStatus {
+	case StatusRunning:
+		c.Config.Logger.Info(ctx, "controller: starting slices %v of group '%v'", sliceIDs, req.Group)
+		err = c.executeTaskAction(c.Start, ctx, statusReq)
+	case StatusStopped:
+		c.Config.Logger.Info(ctx, "controller: stopping slices %v of group '%v'", sliceIDs, req.Group)
+		err = c.executeTaskAction(c.Stop, ctx, statusReq)
+	}
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
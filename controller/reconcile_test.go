@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/fleet"
+)
+
+func runReconcile(t *testing.T, c controller, req Request, opts ReconcileOptions) {
+	taskObject, err := c.Reconcile(context.Background(), req, opts)
+	if err != nil {
+		t.Fatal("Error returned by reconcile:", err)
+	}
+	taskObject, err = c.WaitForTask(context.Background(), taskObject.ID, nil)
+	if err != nil {
+		t.Fatal("Error returned waiting for reconcile:", err)
+	}
+	if taskObject.Error != nil {
+		t.Fatal("Reconcile failed:", taskObject.Error)
+	}
+}
+
+// TestReconcile_Validation tests that invalid reconcile options are rejected.
+func TestReconcile_Validation(t *testing.T) {
+	testController, _ := getTestController()
+
+	req := givenRequest("falcon-unit@.service", testUnitContent)
+	_, err := testController.Reconcile(context.Background(), req, ReconcileOptions{DesiredStatus: StatusFailed})
+	if !IsInvalidArgument(err) {
+		t.Fatal("Expected invalid argument error, got:", err)
+	}
+
+	req = givenRequest("falcon-unit.service", testUnitContent)
+	_, err = testController.Reconcile(context.Background(), req, ReconcileOptions{DesiredSlices: 2, DesiredStatus: StatusRunning})
+	if !IsInvalidArgument(err) {
+		t.Fatal("Expected invalid argument error, got:", err)
+	}
+}
+
+// TestReconcile_NewGroup tests that a group not existing yet is submitted and
+// started.
+func TestReconcile_NewGroup(t *testing.T) {
+	testController, dummyFleet := getTestController()
+
+	req := givenRequest("falcon-unit@.service", testUnitContent)
+	runReconcile(t, testController, req, ReconcileOptions{
+		DesiredSlices: 2,
+		DesiredStatus: StatusRunning,
+	})
+
+	sliceIDs := getSliceIDs(t, dummyFleet)
+	if len(sliceIDs) != 2 {
+		t.Fatal("Incorrect number of slices:", sliceIDs)
+	}
+	for _, sliceID := range sliceIDs {
+		us, err := dummyFleet.GetStatus(context.Background(), "falcon-unit@"+sliceID+".service")
+		if err != nil {
+			t.Fatal("Error returned getting status:", err)
+		}
+		if us.Current != "launched" {
+			t.Fatal("Slice not started:", sliceID)
+		}
+	}
+
+	// Reconciling again does not change anything.
+	runReconcile(t, testController, req, ReconcileOptions{
+		DesiredSlices: -1,
+		DesiredStatus: StatusRunning,
+	})
+	if strings.Join(getSliceIDs(t, dummyFleet), ",") != strings.Join(sliceIDs, ",") {
+		t.Fatal("Slices changed:", getSliceIDs(t, dummyFleet))
+	}
+}
+
+// TestReconcile_ReplaceStopped tests that outdated slices which are not
+// running are replaced in place and kept stopped.
+func TestReconcile_ReplaceStopped(t *testing.T) {
+	testController, dummyFleet := getTestController()
+
+	dummyFleet.Submit(context.Background(), "falcon-unit@1.service", testUnitContent)
+	dummyFleet.Submit(context.Background(), "falcon-unit@2.service", testUnitContent)
+
+	req := givenRequest("falcon-unit@.service", testUnitUpdatedContent)
+	runReconcile(t, testController, req, ReconcileOptions{
+		DesiredSlices: -1,
+		DesiredStatus: StatusStopped,
+	})
+
+	sliceIDs := getSliceIDs(t, dummyFleet)
+	if strings.Join(sliceIDs, ",") != "1,2" {
+		t.Fatal("Incorrect slices:", sliceIDs)
+	}
+	for _, sliceID := range sliceIDs {
+		name := "falcon-unit@" + sliceID + ".service"
+		content, err := dummyFleet.GetUnitContent(context.Background(), name)
+		if err != nil {
+			t.Fatal("Error returned getting content:", err)
+		}
+		if content != testUnitUpdatedContent {
+			t.Fatal("Slice not replaced:", sliceID)
+		}
+		us, err := dummyFleet.GetStatus(context.Background(), name)
+		if err != nil {
+			t.Fatal("Error returned getting status:", err)
+		}
+		if us.Current != "loaded" {
+			t.Fatal("Slice not stopped:", sliceID)
+		}
+	}
+}
+
+// TestReconcile_Destroy tests that reconciling to zero slices removes the
+// group.
+func TestReconcile_Destroy(t *testing.T) {
+	testController, dummyFleet := getTestController()
+
+	dummyFleet.Submit(context.Background(), "falcon-unit@1.service", testUnitContent)
+	dummyFleet.Start(context.Background(), "falcon-unit@1.service")
+	dummyFleet.Submit(context.Background(), "falcon-unit@2.service", testUnitContent)
+
+	req := givenRequest("falcon-unit@.service", testUnitContent)
+	runReconcile(t, testController, req, ReconcileOptions{
+		DesiredSlices: 0,
+		DesiredStatus: StatusRunning,
+	})
+
+	_, err := dummyFleet.GetStatusWithMatcher(context.Background(), func(s string) bool { return true })
+	if !fleet.IsUnitNotFound(err) {
+		t.Fatal("Slices not removed:", err)
+	}
+}
+
+// TestReconcile_Unsliced tests that unsliced groups are submitted and
+// started.
+func TestReconcile_Unsliced(t *testing.T) {
+	testController, dummyFleet := getTestController()
+
+	req := givenRequest("falcon-unit.service", testUnitContent)
+	runReconcile(t, testController, req, ReconcileOptions{
+		DesiredSlices: -1,
+		DesiredStatus: StatusRunning,
+	})
+
+	us, err := dummyFleet.GetStatus(context.Background(), "falcon-unit.service")
+	if err != nil {
+		t.Fatal("Error returned getting status:", err)
+	}
+	if us.Current != "launched" {
+		t.Fatal("Group not started:", us.Current)
+	}
+}
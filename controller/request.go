@@ -42,6 +42,10 @@ type Request struct {
 	// DesiredSlices defines the number of random sliceIDs that should be generated
 	// when submitting new groups.
 	DesiredSlices int
+
+	// Renderer renders the contents of Units for each slice. Unit contents are
+	// used as they are in case this is nil.
+	Renderer UnitRenderer
 }
 
 // NewRequest returns a Request, given a RequestConfig.
@@ -56,7 +60,9 @@ func NewRequest(config RequestConfig) Request {
 	return req
 }
 
-var unitExp = regexp.MustCompile("@.")
+// unitExp matches the empty slice ID of unit names like "app@.service" or
+// "app@.timer", regardless of the unit type.
+var unitExp = regexp.MustCompile(`@\.`)
 
 // isSliceable checks whether all units of the request are sliceable (contain an @)
 func (r Request) isSliceable() bool {
@@ -77,27 +83,51 @@ func (r Request) isSliceable() bool {
 // 	 foo@2.service
 // 	 bar@2.service
 //
+// The unit contents are rendered for each slice using the Renderer, if any.
+// The returned request has no Renderer anymore.
 func (r Request) ExtendSlices() (Request, error) {
 	if len(r.SliceIDs) == 0 {
+		if r.Renderer == nil {
+			return r, nil
+		}
+
+		var newUnits []Unit
+		for _, unit := range r.Units {
+			content, err := r.renderUnit(unit, SliceValues{})
+			if err != nil {
+				return Request{}, maskAny(err)
+			}
+			unit.Content = content
+			newUnits = append(newUnits, unit)
+		}
+		r.Units = newUnits
+		r.Renderer = nil
+
 		return r, nil
 	}
 
 	var newUnits []Unit
 	for _, sliceID := range r.SliceIDs {
 		for _, unit := range r.Units {
+			content, err := r.renderUnit(unit, SliceValues{SliceID: sliceID})
+			if err != nil {
+				return Request{}, maskAny(err)
+			}
+
 			newUnit := unit
-			// TODO fix extension
 			newUnit.Name = unitExp.ReplaceAllString(newUnit.Name, fmt.Sprintf("@%s.", sliceID))
+			newUnit.Content = content
 			newUnits = append(newUnits, newUnit)
 		}
 	}
 	r.Units = newUnits
+	r.Renderer = nil
 
 	return r, nil
 }
 
-func (c controller) getExistingSliceIDs(req Request) ([]string, error) {
-	usl, err := c.Fleet.GetStatusWithMatcher(matchesUnitBase(req))
+func (c controller) getExistingSliceIDs(ctx context.Context, req Request) ([]string, error) {
+	usl, err := c.getStatusWithMatcher(ctx, matchesUnitBase(req))
 	if fleet.IsUnitNotFound(err) {
 		// This happenes when there is no unit, e.g. on submit. Thus we don't need
 		// to check against anything. Se we do nothing and go ahead by simply
@@ -122,8 +152,8 @@ func (c controller) getExistingSliceIDs(req Request) ([]string, error) {
 	return newSliceIDs, nil
 }
 
-func (c controller) ExtendWithExistingSliceIDs(req Request) (Request, error) {
-	newSliceIDs, err := c.getExistingSliceIDs(req)
+func (c controller) ExtendWithExistingSliceIDs(ctx context.Context, req Request) (Request, error) {
+	newSliceIDs, err := c.getExistingSliceIDs(ctx, req)
 	if err != nil {
 		return Request{}, maskAny(err)
 	}
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"golang.org/x/net/context"
+)
+
+// updateSnapshot represents the state of a group right before it gets
+// updated. It provides all information necessary to roll back a failed update.
+type updateSnapshot struct {
+	// SliceIDs contains the IDs of all slices the group had before the update.
+	SliceIDs []string
+
+	// Slices contains one request for each slice going to be updated. The units
+	// of each request carry the content fleet reported for the slice before the
+	// update.
+	Slices []Request
+}
+
+// takeUpdateSnapshot remembers the slice IDs of the group given by req and
+// the unit contents of all slices going to be updated.
+func (c controller) takeUpdateSnapshot(ctx context.Context, req Request) (updateSnapshot, error) {
+	c.Config.Logger.Debug(ctx, "controller: taking update snapshot for group '%v'", req.Group)
+
+	sliceIDs, err := c.getExistingSliceIDs(ctx, req)
+	if err != nil {
+		return updateSnapshot{}, maskAny(err)
+	}
+
+	snapshot := updateSnapshot{
+		SliceIDs: sliceIDs,
+	}
+
+	for _, sliceID := range req.SliceIDs {
+		sliceReq := req
+		sliceReq.SliceIDs = []string{sliceID}
+		sliceReq.Units = append([]Unit{}, req.Units...)
+		// The snapshot holds the contents as deployed to fleet, which must not
+		// be rendered again.
+		sliceReq.Renderer = nil
+
+		extended, err := sliceReq.ExtendSlices()
+		if err != nil {
+			return updateSnapshot{}, maskAny(err)
+		}
+
+		// ExtendSlices keeps the order of the units for each slice. Thus the
+		// extended units map to the units of the slice request by their index.
+		for i, u := range extended.Units {
+			content, err := c.Fleet.GetUnitContent(ctx, u.Name)
+			if err != nil {
+				return updateSnapshot{}, maskAny(err)
+			}
+			sliceReq.Units[i].Content = content
+		}
+
+		snapshot.Slices = append(snapshot.Slices, sliceReq)
+	}
+
+	c.Config.Logger.Debug(ctx, "controller: took update snapshot of slices: %v", snapshot.SliceIDs)
+
+	return snapshot, nil
+}
+
+// rollbackUpdate puts the group given by req back into the state described by
+// snapshot. Slices created by the failed update are removed. Slices removed by
+// the failed update are submitted and started again using their original slice
+// IDs and unit contents. Slices that still exist but are not running anymore
+// are started again. Slices missing some of their units are removed and
+// submitted again as a whole. The returned error wraps the given cause. It can be
+// identified using IsUpdateRolledBack, or IsRollbackFailed in case the
+// rollback itself failed.
+func (c controller) rollbackUpdate(ctx context.Context, req Request, snapshot updateSnapshot, cause error) error {
+	c.Config.Logger.Info(ctx, "controller: rolling back update of group '%v'", req.Group)
+
+	currentSliceIDs, err := c.getExistingSliceIDs(ctx, req)
+	if err != nil {
+		return maskAnyf(rollbackFailedError, "%s (%s)", err.Error(), cause.Error())
+	}
+
+	var removedSliceIDs []string
+	for _, sliceID := range currentSliceIDs {
+		if contains(snapshot.SliceIDs, sliceID) {
+			continue
+		}
+		removedSliceIDs = append(removedSliceIDs, sliceID)
+	}
+
+	if len(removedSliceIDs) > 0 {
+		c.Config.Logger.Debug(ctx, "controller: removing slices created by update: %v", removedSliceIDs)
+
+		removeReq := req
+		removeReq.SliceIDs = removedSliceIDs
+		err := c.runRemoveWorker(ctx, removeReq)
+		if err != nil {
+			return maskAnyf(rollbackFailedError, "%s (%s)", err.Error(), cause.Error())
+		}
+	}
+
+	var restoredSliceIDs []string
+	for _, sliceReq := range snapshot.Slices {
+		submit := !contains(currentSliceIDs, sliceReq.SliceIDs[0])
+
+		if !submit {
+			complete, err := c.isSliceComplete(ctx, sliceReq)
+			if err != nil {
+				return maskAnyf(rollbackFailedError, "%s (%s)", err.Error(), cause.Error())
+			}
+
+			if complete {
+				n, err := c.getNumRunningSlices(ctx, sliceReq)
+				if err != nil {
+					return maskAnyf(rollbackFailedError, "%s (%s)", err.Error(), cause.Error())
+				}
+				if n == 1 {
+					// The update did not touch this slice. Nothing to do here.
+					continue
+				}
+			} else {
+				// The update destroyed some of the units of this slice. The
+				// remaining units are removed as well, so the whole slice can be
+				// submitted again.
+				c.Config.Logger.Debug(ctx, "controller: removing slice partially destroyed by update: %v", sliceReq.SliceIDs)
+
+				if err := c.runRemoveWorker(ctx, sliceReq); err != nil {
+					return maskAnyf(rollbackFailedError, "%s (%s)", err.Error(), cause.Error())
+				}
+				submit = true
+			}
+		}
+
+		if submit {
+			c.Config.Logger.Debug(ctx, "controller: submitting slice removed by update: %v", sliceReq.SliceIDs)
+
+			if err := c.executeTaskAction(c.Submit, ctx, sliceReq); err != nil {
+				return maskAnyf(rollbackFailedError, "%s (%s)", err.Error(), cause.Error())
+			}
+		}
+
+		c.Config.Logger.Debug(ctx, "controller: starting slice stopped by update: %v", sliceReq.SliceIDs)
+
+		if err := c.executeTaskAction(c.Start, ctx, sliceReq); err != nil {
+			return maskAnyf(rollbackFailedError, "%s (%s)", err.Error(), cause.Error())
+		}
+
+		restoredSliceIDs = append(restoredSliceIDs, sliceReq.SliceIDs[0])
+	}
+
+	c.Config.Logger.Info(ctx, "controller: rolled back update of group '%v'", req.Group)
+
+	return maskAnyf(updateRolledBackError, "restored slices %v, removed slices %v (%s)", restoredSliceIDs, removedSliceIDs, cause.Error())
+}
+
+// isSliceComplete returns whether all units of the single slice given by
+// sliceReq exist within the fleet cluster.
+func (c controller) isSliceComplete(ctx context.Context, sliceReq Request) (bool, error) {
+	extended, err := sliceReq.ExtendSlices()
+	if err != nil {
+		return false, maskAny(err)
+	}
+
+	unitStatusList, err := c.groupStatus(ctx, sliceReq)
+	if IsUnitNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, maskAny(err)
+	}
+
+	for _, u := range extended.Units {
+		found := false
+		for _, us := range unitStatusList {
+			if us.Name == u.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
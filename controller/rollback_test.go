@@ -0,0 +1,256 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/fleet"
+)
+
+// failingStartFleet is a DummyFleet where starting any unit that is not
+// listed in Healthy results in a failed unit.
+type failingStartFleet struct {
+	*fleet.DummyFleet
+
+	Healthy []string
+}
+
+func (f *failingStartFleet) Start(ctx context.Context, name string) error {
+	err := f.DummyFleet.Start(ctx, name)
+	if err != nil {
+		return err
+	}
+	if contains(f.Healthy, name) {
+		return nil
+	}
+
+	f.DummyFleet.Mutex.Lock()
+	defer f.DummyFleet.Mutex.Unlock()
+
+	unitStatus := f.DummyFleet.Units[name]
+	unitStatus.Machine = []fleet.MachineStatus{
+		{
+			SystemdActive: "failed",
+			SystemdSub:    "failed",
+		},
+	}
+	f.DummyFleet.Units[name] = unitStatus
+
+	return nil
+}
+
+// TestUpdate_Rollback tests that a failed update restores the slices it
+// removed and removes the slices it created.
+func TestUpdate_Rollback(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	testFleet := &failingStartFleet{
+		DummyFleet: dummyFleet,
+		Healthy:    []string{"falcon-unit@1.service"},
+	}
+	testController.Fleet = testFleet
+	testController.WaitSleep = 10 * time.Millisecond
+	testController.WaitTimeout = 1 * time.Second
+
+	testFleet.Submit(context.Background(), "falcon-unit@1.service", "[Service]\nExecStart=/bin/true\n")
+	testFleet.Start(context.Background(), "falcon-unit@1.service")
+
+	req := Request{
+		RequestConfig: RequestConfig{
+			Group:    "falcon",
+			SliceIDs: []string{"1"},
+		},
+		Units: []Unit{
+			{
+				Name:    "falcon-unit@.service",
+				Content: "[Service]\nExecStart=/bin/false\n",
+			},
+		},
+	}
+	opts := UpdateOptions{
+		MaxGrowth: 0,
+		MinAlive:  0,
+		Rollback:  true,
+	}
+
+	taskObject, err := testController.Update(context.Background(), req, opts)
+	if err != nil {
+		t.Fatal("Error returned by update:", err)
+	}
+	taskObject, err = testController.WaitForTask(context.Background(), taskObject.ID, nil)
+	if err != nil {
+		t.Fatal("Error returned waiting for update:", err)
+	}
+	if !IsUpdateRolledBack(taskObject.Error) {
+		t.Fatal("Update was not rolled back:", taskObject.Error)
+	}
+	if !strings.Contains(taskObject.Error.Error(), "restored slices [1]") {
+		t.Fatal("Restored slices not reported:", taskObject.Error)
+	}
+
+	unitStatusList, err := dummyFleet.GetStatusWithMatcher(
+		context.Background(),
+		func(s string) bool {
+			return strings.HasPrefix(s, "falcon-unit@")
+		},
+	)
+	if err != nil {
+		t.Fatal("Error returned getting statuses:", err)
+	}
+	if len(unitStatusList) != 1 {
+		t.Fatal("Incorrect number of units:", len(unitStatusList))
+	}
+	if unitStatusList[0].Name != "falcon-unit@1.service" {
+		t.Fatal("Original unit not restored:", unitStatusList[0].Name)
+	}
+	if unitStatusList[0].Current != "launched" {
+		t.Fatal("Restored unit has incorrect current status:", unitStatusList[0].Current)
+	}
+
+	content, err := dummyFleet.GetUnitContent(context.Background(), "falcon-unit@1.service")
+	if err != nil {
+		t.Fatal("Error returned getting unit content:", err)
+	}
+	if content != "[Service]\nExecStart=/bin/true\n" {
+		t.Fatal("Restored unit has incorrect content:", content)
+	}
+}
+
+// TestUpdate_Rollback_MaxGrowth tests that a failed update adding and removing
+// multiple slices at the same time restores exactly the slices the group had
+// before the update.
+func TestUpdate_Rollback_MaxGrowth(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	testFleet := &failingStartFleet{
+		DummyFleet: dummyFleet,
+		Healthy: []string{
+			"falcon-unit@1.service",
+			"falcon-unit@2.service",
+			"falcon-unit@3.service",
+		},
+	}
+	testController.Fleet = testFleet
+	testController.WaitSleep = 10 * time.Millisecond
+	testController.WaitTimeout = 1 * time.Second
+
+	for _, name := range testFleet.Healthy {
+		testFleet.Submit(context.Background(), name, "[Service]\nExecStart=/bin/true\n")
+		testFleet.Start(context.Background(), name)
+	}
+
+	req := Request{
+		RequestConfig: RequestConfig{
+			Group:    "falcon",
+			SliceIDs: []string{"1", "2", "3"},
+		},
+		Units: []Unit{
+			{
+				Name:    "falcon-unit@.service",
+				Content: "[Service]\nExecStart=/bin/false\n",
+			},
+		},
+	}
+	opts := UpdateOptions{
+		MaxGrowth: 2,
+		MinAlive:  2,
+		Rollback:  true,
+	}
+
+	taskObject, err := testController.Update(context.Background(), req, opts)
+	if err != nil {
+		t.Fatal("Error returned by update:", err)
+	}
+	taskObject, err = testController.WaitForTask(context.Background(), taskObject.ID, nil)
+	if err != nil {
+		t.Fatal("Error returned waiting for update:", err)
+	}
+	if !IsUpdateRolledBack(taskObject.Error) {
+		t.Fatal("Update was not rolled back:", taskObject.Error)
+	}
+
+	unitStatusList, err := dummyFleet.GetStatusWithMatcher(
+		context.Background(),
+		func(s string) bool {
+			return strings.HasPrefix(s, "falcon-unit@")
+		},
+	)
+	if err != nil {
+		t.Fatal("Error returned getting statuses:", err)
+	}
+	if len(unitStatusList) != 3 {
+		t.Fatal("Incorrect number of units:", unitStatusList)
+	}
+	for _, us := range unitStatusList {
+		if !contains(testFleet.Healthy, us.Name) {
+			t.Fatal("Unit created by update not removed:", us.Name)
+		}
+		if us.Current != "launched" {
+			t.Fatal("Restored unit has incorrect current status:", us.Name, us.Current)
+		}
+
+		content, err := dummyFleet.GetUnitContent(context.Background(), us.Name)
+		if err != nil {
+			t.Fatal("Error returned getting unit content:", err)
+		}
+		if content != "[Service]\nExecStart=/bin/true\n" {
+			t.Fatal("Restored unit has incorrect content:", us.Name, content)
+		}
+	}
+}
+
+// TestRollbackUpdate_PartiallyDestroyedSlice tests that rolling back an update
+// resubmits the units missing from a slice the update partially destroyed.
+func TestRollbackUpdate_PartiallyDestroyedSlice(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	testController.WaitSleep = 10 * time.Millisecond
+
+	content := "[Service]\nExecStart=/bin/true\n"
+	for _, name := range []string{"falcon-unit@1.service", "falcon-sidekick@1.service"} {
+		dummyFleet.Submit(context.Background(), name, content)
+		dummyFleet.Start(context.Background(), name)
+	}
+
+	req := Request{
+		RequestConfig: RequestConfig{
+			Group:    "falcon",
+			SliceIDs: []string{"1"},
+		},
+		Units: []Unit{
+			{
+				Name:    "falcon-unit@.service",
+				Content: content,
+			},
+			{
+				Name:    "falcon-sidekick@.service",
+				Content: content,
+			},
+		},
+	}
+
+	snapshot, err := testController.takeUpdateSnapshot(context.Background(), req)
+	if err != nil {
+		t.Fatal("Error returned taking snapshot:", err)
+	}
+
+	// The update got interrupted while destroying the slice.
+	dummyFleet.Stop(context.Background(), "falcon-unit@1.service")
+	dummyFleet.Stop(context.Background(), "falcon-sidekick@1.service")
+	dummyFleet.Destroy(context.Background(), "falcon-sidekick@1.service")
+
+	err = testController.rollbackUpdate(context.Background(), req, snapshot, updateFailedError)
+	if !IsUpdateRolledBack(err) {
+		t.Fatal("Update was not rolled back:", err)
+	}
+
+	for _, name := range []string{"falcon-unit@1.service", "falcon-sidekick@1.service"} {
+		us, err := dummyFleet.GetStatus(context.Background(), name)
+		if err != nil {
+			t.Fatal("Unit not restored:", name, err)
+		}
+		if us.Current != "launched" {
+			t.Fatal("Restored unit has incorrect current status:", name, us.Current)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/task"
+)
+
+func (c controller) Scale(ctx context.Context, req Request, desiredSlices int) (*task.Task, error) {
+	c.Config.Logger.Debug(ctx, "controller: handling scale for group '%v' to %v slices", req.Group, desiredSlices)
+
+	if desiredSlices < 0 {
+		return nil, maskAnyf(invalidArgumentError, "number of slices must be positive, or zero")
+	}
+	if !req.isSliceable() {
+		return nil, maskAnyf(scaleNotAllowedError, "cannot scale unsliceable group")
+	}
+
+	action := func(ctx context.Context) error {
+		currentSliceIDs, err := c.getExistingSliceIDs(ctx, req)
+		if err != nil {
+			return maskAny(err)
+		}
+
+		c.Config.Logger.Debug(ctx, "controller: group '%v' has slices %v", req.Group, currentSliceIDs)
+
+		switch {
+		case desiredSlices > len(currentSliceIDs):
+			err = c.scaleUp(ctx, req, desiredSlices-len(currentSliceIDs))
+		case desiredSlices < len(currentSliceIDs):
+			err = c.scaleDown(ctx, req, currentSliceIDs, len(currentSliceIDs)-desiredSlices)
+		default:
+			c.Config.Logger.Debug(ctx, "controller: group '%v' already has %v slices", req.Group, desiredSlices)
+		}
+		if err != nil {
+			return maskAny(err)
+		}
+
+		return nil
+	}
+
+	taskObject, err := c.TaskService.Create(ctx, action)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	return taskObject, nil
+}
+
+// scaleUp submits and starts n new slices of the group given by req.
+func (c controller) scaleUp(ctx context.Context, req Request, n int) error {
+	addReq := req
+	addReq.SliceIDs = nil
+	addReq.DesiredSlices = n
+	addReq, err := c.ExtendWithRandomSliceIDs(ctx, addReq)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	c.Config.Logger.Info(ctx, "controller: adding slices %v", addReq.SliceIDs)
+
+	if err := c.executeTaskAction(c.Submit, ctx, addReq); err != nil {
+		return maskAny(err)
+	}
+	if err := c.executeTaskAction(c.Start, ctx, addReq); err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// scaleDown stops and destroys n of the given slices of the group given by
+// req. See sliceIDsByRemovalPriority for which slices are picked.
+func (c controller) scaleDown(ctx context.Context, req Request, sliceIDs []string, n int) error {
+	removeReq := req
+	removeReq.SliceIDs = sliceIDs
+	sorted, err := c.sliceIDsByRemovalPriority(ctx, removeReq)
+	if err != nil {
+		return maskAny(err)
+	}
+	removeReq.SliceIDs = sorted[:n]
+
+	c.Config.Logger.Info(ctx, "controller: removing slices %v", removeReq.SliceIDs)
+
+	if err := c.runRemoveWorker(ctx, removeReq); err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// sliceIDsByRemovalPriority returns the slice IDs of req ordered by how
+// favourable it is to remove them. Slices having failed units come first,
+// followed by slices having stopped units, followed by all other slices. The
+// order of the slice IDs of req is kept within each of these classes.
+func (c controller) sliceIDsByRemovalPriority(ctx context.Context, req Request) ([]string, error) {
+	unitStatusList, err := c.groupStatus(ctx, req)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	aggregator := Aggregator{
+		Logger: c.Config.Logger,
+	}
+
+	priorities := map[string]int{}
+	for _, sliceID := range req.SliceIDs {
+		priority := 2
+		for _, us := range UnitStatusList(unitStatusList).ResolveTriggered().unitStatusesBySliceID(sliceID) {
+			failed, err := aggregator.UnitHasStatus(us, StatusFailed)
+			if err != nil {
+				return nil, maskAny(err)
+			}
+			if failed {
+				priority = 0
+				break
+			}
+
+			stopped, err := aggregator.UnitHasStatus(us, StatusStopped)
+			if err != nil {
+				return nil, maskAny(err)
+			}
+			if stopped || len(us.Machine) == 0 {
+				priority = 1
+			}
+		}
+		priorities[sliceID] = priority
+	}
+
+	var sorted []string
+	for priority := 0; priority <= 2; priority++ {
+		for _, sliceID := range req.SliceIDs {
+			if priorities[sliceID] == priority {
+				sorted = append(sorted, sliceID)
+			}
+		}
+	}
+
+	return sorted, nil
+}
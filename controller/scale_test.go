@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/fleet"
+)
+
+func getSliceIDs(t *testing.T, f *fleet.DummyFleet) []string {
+	unitStatusList, err := f.GetStatusWithMatcher(
+		context.Background(),
+		func(s string) bool {
+			return strings.HasPrefix(s, "falcon-unit@")
+		},
+	)
+	if err != nil {
+		t.Fatal("Error returned getting statuses:", err)
+	}
+
+	var sliceIDs []string
+	for _, us := range unitStatusList {
+		sliceIDs = append(sliceIDs, us.SliceID)
+	}
+	sort.Strings(sliceIDs)
+
+	return sliceIDs
+}
+
+// TestScale_Validation tests that invalid scale requests are rejected.
+func TestScale_Validation(t *testing.T) {
+	testController, _ := getTestController()
+
+	_, err := testController.Scale(context.Background(), givenRequest("falcon-unit@.service", testUnitContent), -1)
+	if !IsInvalidArgument(err) {
+		t.Fatal("Expected invalid argument error, got:", err)
+	}
+
+	req := givenRequest("falcon-unit@.service", testUnitContent)
+	req.Units[0].Name = "falcon-unit.service"
+	_, err = testController.Scale(context.Background(), req, 2)
+	if !IsScaleNotAllowed(err) {
+		t.Fatal("Expected scale not allowed error, got:", err)
+	}
+}
+
+// TestScale_Up tests that scaling up adds new slices and keeps the existing
+// ones.
+func TestScale_Up(t *testing.T) {
+	testController, dummyFleet := getTestController()
+
+	dummyFleet.Submit(context.Background(), "falcon-unit@1.service", "some content")
+	dummyFleet.Start(context.Background(), "falcon-unit@1.service")
+
+	taskObject, err := testController.Scale(context.Background(), givenRequest("falcon-unit@.service", testUnitContent), 3)
+	if err != nil {
+		t.Fatal("Error returned by scale:", err)
+	}
+	taskObject, err = testController.WaitForTask(context.Background(), taskObject.ID, nil)
+	if err != nil {
+		t.Fatal("Error returned waiting for scale:", err)
+	}
+	if taskObject.Error != nil {
+		t.Fatal("Scale failed:", taskObject.Error)
+	}
+
+	sliceIDs := getSliceIDs(t, dummyFleet)
+	if len(sliceIDs) != 3 {
+		t.Fatal("Incorrect number of slices:", sliceIDs)
+	}
+	if !contains(sliceIDs, "1") {
+		t.Fatal("Existing slice removed:", sliceIDs)
+	}
+	for _, sliceID := range sliceIDs {
+		us, err := dummyFleet.GetStatus(context.Background(), "falcon-unit@"+sliceID+".service")
+		if err != nil {
+			t.Fatal("Error returned getting status:", err)
+		}
+		if us.Current != "launched" {
+			t.Fatal("Slice not started:", sliceID)
+		}
+	}
+}
+
+// TestScale_Down tests that scaling down removes failed slices first,
+// followed by stopped slices.
+func TestScale_Down(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	testFleet := &failingStartFleet{
+		DummyFleet: dummyFleet,
+		Healthy:    []string{"falcon-unit@1.service", "falcon-unit@2.service"},
+	}
+	testController.Fleet = testFleet
+
+	for _, sliceID := range []string{"1", "2", "3", "4"} {
+		testFleet.Submit(context.Background(), "falcon-unit@"+sliceID+".service", "some content")
+	}
+	// Slice 1 and 2 are running, slice 3 is failed and slice 4 is stopped.
+	testFleet.Start(context.Background(), "falcon-unit@1.service")
+	testFleet.Start(context.Background(), "falcon-unit@2.service")
+	testFleet.Start(context.Background(), "falcon-unit@3.service")
+
+	taskObject, err := testController.Scale(context.Background(), givenRequest("falcon-unit@.service", testUnitContent), 2)
+	if err != nil {
+		t.Fatal("Error returned by scale:", err)
+	}
+	taskObject, err = testController.WaitForTask(context.Background(), taskObject.ID, nil)
+	if err != nil {
+		t.Fatal("Error returned waiting for scale:", err)
+	}
+	if taskObject.Error != nil {
+		t.Fatal("Scale failed:", taskObject.Error)
+	}
+
+	sliceIDs := getSliceIDs(t, dummyFleet)
+	if strings.Join(sliceIDs, ",") != "1,2" {
+		t.Fatal("Incorrect slices left:", sliceIDs)
+	}
+}
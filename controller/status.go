@@ -50,6 +50,71 @@ func (usl UnitStatusList) Group() (UnitStatusList, error) {
 	return newList, nil
 }
 
+// triggerTypes contains the types of units activating other units of the same
+// name. E.g. "app@1.timer" activates "app@1.service".
+var triggerTypes = []string{".timer", ".socket", ".path"}
+
+// ResolveTriggered returns a copy of usl where inactive services that are
+// activated by a timer, socket or path unit within usl have the fleet and
+// systemd states of the activating unit. Such services are expected to be
+// inactive most of the time, e.g. while a timer is waiting. Thus their status
+// is defined by the activating unit when aggregating statuses.
+func (usl UnitStatusList) ResolveTriggered() UnitStatusList {
+	var newList UnitStatusList
+
+	for _, us := range usl {
+		if common.UnitType(us.Name) == ".service" && isInactive(us) {
+			if trigger, ok := usl.triggerOf(us); ok {
+				var machines []fleet.MachineStatus
+				for _, ms := range us.Machine {
+					if len(trigger.Machine) > 0 {
+						ms.SystemdActive = trigger.Machine[0].SystemdActive
+						ms.SystemdSub = trigger.Machine[0].SystemdSub
+					}
+					machines = append(machines, ms)
+				}
+				us.Current = trigger.Current
+				us.Desired = trigger.Desired
+				us.Machine = machines
+			}
+		}
+
+		newList = append(newList, us)
+	}
+
+	return newList
+}
+
+// triggerOf returns the unit status of the unit activating the given service.
+func (usl UnitStatusList) triggerOf(service fleet.UnitStatus) (fleet.UnitStatus, bool) {
+	name := strings.TrimSuffix(service.Name, ".service")
+
+	for _, triggerType := range triggerTypes {
+		for _, us := range usl {
+			if us.Name == name+triggerType {
+				return us, true
+			}
+		}
+	}
+
+	return fleet.UnitStatus{}, false
+}
+
+// isInactive checks whether the given unit is inactive on all machines it is
+// scheduled on.
+func isInactive(us fleet.UnitStatus) bool {
+	if len(us.Machine) == 0 {
+		return false
+	}
+	for _, ms := range us.Machine {
+		if ms.SystemdActive != "inactive" {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (usl UnitStatusList) unitStatusesBySliceID(sliceID string) UnitStatusList {
 	var newList []fleet.UnitStatus
 
@@ -84,39 +149,84 @@ func groupUnitStatus(usl []fleet.UnitStatus, groupMember fleet.UnitStatus) ([]fl
 }
 
 // allHashesEqual is supposed to receive a list of unit statuses that is not
-// grouped. This is necessary to compare unit hashes across groups.
+// grouped. This is necessary to compare unit hashes across groups. Units
+// rendered for their slice, e.g. using {{.SliceID}}, have different unit
+// hashes in each slice. Thus a unit is considered equal across slices in case
+// either its unit hashes or its slice hashes are equal. Units without slice
+// hash are compared using their unit hashes only.
 func allHashesEqual(usl []fleet.UnitStatus) (bool, error) {
 	uhis, err := groupUnitHashInfos(usl)
 	if err != nil {
 		return false, maskAny(err)
 	}
+	unitHashesDiffer := differingUnitHashInfos(uhis)
 
+	var withoutSliceHash []fleet.UnitStatus
+	var shis []unitHashInfo
+	for _, us := range usl {
+		if us.SliceHash == "" {
+			withoutSliceHash = append(withoutSliceHash, us)
+			continue
+		}
+		shis = append(shis, unitHashInfo{
+			Base:    unitKey(us.Name),
+			SliceID: us.SliceID,
+			Hash:    us.SliceHash,
+		})
+	}
+	uhis, err = groupUnitHashInfos(withoutSliceHash)
+	if err != nil {
+		return false, maskAny(err)
+	}
+	sliceHashesDiffer := differingUnitHashInfos(append(shis, uhis...))
+
+	for base := range unitHashesDiffer {
+		if sliceHashesDiffer[base] {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// differingUnitHashInfos returns the bases of the units having different
+// hashes.
+func differingUnitHashInfos(uhis []unitHashInfo) map[string]bool {
+	differing := map[string]bool{}
 	for _, uhi1 := range uhis {
 		for _, uhi2 := range uhis {
 			if uhi1.Base != uhi2.Base {
 				continue
 			}
 			if uhi1.Hash != uhi2.Hash {
-				return false, nil
+				differing[uhi1.Base] = true
 			}
 		}
 	}
 
-	return true, nil
+	return differing
 }
 
 type unitHashInfo struct {
+	// Base identifies the unit across the slices of a group. See unitKey.
 	Base    string
 	SliceID string
 	Hash    string
 }
 
+// unitKey identifies a unit across the slices of a group, ignoring its slice
+// ID. Units having the same base but different types, like "app@1.service" and
+// "app@1.timer", are different units.
+func unitKey(name string) string {
+	return common.UnitBase(name) + common.UnitType(name)
+}
+
 func groupUnitHashInfos(usl []fleet.UnitStatus) ([]unitHashInfo, error) {
 	var uhis []unitHashInfo
 
 	for _, us1 := range usl {
 		for _, us2 := range usl {
-			if common.UnitBase(us1.Name) != common.UnitBase(us2.Name) {
+			if unitKey(us1.Name) != unitKey(us2.Name) {
 				continue
 			}
 			for _, m1 := range us1.Machine {
@@ -125,7 +235,7 @@ func groupUnitHashInfos(usl []fleet.UnitStatus) ([]unitHashInfo, error) {
 					return nil, maskAny(err)
 				}
 				uhi := unitHashInfo{
-					Base:    common.UnitBase(us1.Name),
+					Base:    unitKey(us1.Name),
 					SliceID: sliceID,
 					Hash:    m1.UnitHash,
 				}
@@ -183,6 +293,10 @@ var (
 
 	// StatusStopping represents a unit stopping.
 	StatusStopping Status = "stopping"
+
+	// StatusUnknown represents a unit having a combination of fleet and systemd
+	// states not known to StatusIndex.
+	StatusUnknown Status = "unknown"
 )
 
 // StatusContext represents a units status from fleet and systemd.
@@ -257,7 +371,7 @@ var (
 			FleetCurrent:  "loaded|launched",
 			FleetDesired:  "*",
 			SystemdActive: "active|reloading",
-			SystemdSub:    "exited|running",
+			SystemdSub:    "exited|running|waiting|listening|mounted|elapsed",
 			Aggregated:    StatusRunning,
 		},
 	}
@@ -309,6 +423,21 @@ func (a Aggregator) AggregateStatus(fc, fd, sa, ss string) (Status, error) {
 	return aggregatedStatuses[0], nil
 }
 
+// AggregateUnitStatus aggregates the fleet and systemd states of the given unit
+// to a Status known to Inago. Units are scheduled to at most one machine. Units
+// not scheduled to any machine are not running anywhere, which is why their
+// status is aggregated as if systemd reported them to be inactive.
+func (a Aggregator) AggregateUnitStatus(us fleet.UnitStatus) (Status, error) {
+	if len(us.Machine) == 0 {
+		status, err := a.AggregateStatus(us.Current, us.Desired, "inactive", "dead")
+		return status, maskAny(err)
+	}
+
+	ms := us.Machine[0]
+	status, err := a.AggregateStatus(us.Current, us.Desired, ms.SystemdActive, ms.SystemdSub)
+	return status, maskAny(err)
+}
+
 // UnitHasStatus determines if a given unit's status is effectivly equal to a
 // set of given statuses. This method provides status mapping of
 // AggregateStatus and compares the result with the given set of statuses.
@@ -69,6 +69,33 @@ func TestUnitStatusList_Group_UnitHashDiffs(t *testing.T) {
 	Expect(len(output)).To(Equal(4))
 }
 
+// TestUnitStatusList_Group_Templated tests that units rendered for their
+// slice are grouped in case their slice hashes are equal.
+func TestUnitStatusList_Group_Templated(t *testing.T) {
+	RegisterTestingT(t)
+
+	input1 := givenSingleUnitStatus("main", "1")
+	input2 := givenSingleUnitStatus("main", "2")
+	input2.Machine[0].UnitHash = "something-else"
+	input1.SliceHash = "5678"
+	input2.SliceHash = "5678"
+
+	output, err := UnitStatusList([]fleet.UnitStatus{input1, input2}).Group()
+
+	Expect(err).To(Not(HaveOccurred()))
+	Expect(len(output)).To(Equal(2))
+	Expect(output[0].Name).To(Equal("*"))
+	Expect(output[1].Name).To(Equal("*"))
+
+	input2.SliceHash = "something-else"
+
+	output, err = UnitStatusList([]fleet.UnitStatus{input1, input2}).Group()
+
+	Expect(err).To(Not(HaveOccurred()))
+	Expect(output).To(ContainElement(input1))
+	Expect(output).To(ContainElement(input2))
+}
+
 func inputUnitStatusList(configs ...map[string][]string) UnitStatusList {
 	unitStatusList := UnitStatusList{}
 
@@ -288,6 +315,30 @@ func Test_Status_AggregateStatus(t *testing.T) {
 			ErrorMatcher: nil,
 			Expected:     StatusRunning,
 		},
+		{
+			FC:           "launched",
+			FD:           "",
+			SA:           "active",
+			SS:           "waiting",
+			ErrorMatcher: nil,
+			Expected:     StatusRunning,
+		},
+		{
+			FC:           "launched",
+			FD:           "",
+			SA:           "active",
+			SS:           "listening",
+			ErrorMatcher: nil,
+			Expected:     StatusRunning,
+		},
+		{
+			FC:           "launched",
+			FD:           "",
+			SA:           "active",
+			SS:           "mounted",
+			ErrorMatcher: nil,
+			Expected:     StatusRunning,
+		},
 		{
 			FC:           "foo",
 			FD:           "",
@@ -325,3 +376,47 @@ func Test_Status_AggregateStatus(t *testing.T) {
 		}
 	}
 }
+
+func Test_UnitStatusList_ResolveTriggered(t *testing.T) {
+	RegisterTestingT(t)
+
+	givenUnitStatus := func(name, sa, ss string) fleet.UnitStatus {
+		return fleet.UnitStatus{
+			Name:    name,
+			Current: "launched",
+			Desired: "launched",
+			Machine: []fleet.MachineStatus{
+				{
+					ID:            "machine1",
+					SystemdActive: sa,
+					SystemdSub:    ss,
+					UnitHash:      name,
+				},
+			},
+		}
+	}
+
+	usl := UnitStatusList{
+		// A service activated by a waiting timer.
+		givenUnitStatus("app@1.service", "inactive", "dead"),
+		givenUnitStatus("app@1.timer", "active", "waiting"),
+		// A failed service activated by a timer.
+		givenUnitStatus("app@2.service", "failed", "failed"),
+		givenUnitStatus("app@2.timer", "active", "waiting"),
+		// A service not activated by any other unit.
+		givenUnitStatus("app@3.service", "inactive", "dead"),
+	}
+
+	output := usl.ResolveTriggered()
+	Expect(output).To(HaveLen(5))
+
+	Expect(output[0].Name).To(Equal("app@1.service"))
+	Expect(output[0].Machine[0].SystemdActive).To(Equal("active"))
+	Expect(output[0].Machine[0].SystemdSub).To(Equal("waiting"))
+	Expect(output[0].Machine[0].UnitHash).To(Equal("app@1.service"))
+	Expect(output[2]).To(Equal(usl[2]))
+	Expect(output[4]).To(Equal(usl[4]))
+
+	// The given list is not modified.
+	Expect(usl[0].Machine[0].SystemdActive).To(Equal("inactive"))
+}
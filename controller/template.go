@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"github.com/coreos/fleet/unit"
+)
+
+// SliceValues represents the values of a single slice available when rendering
+// unit contents. There is no slice index, since the position of a slice among
+// the slices of a group changes whenever slices are added or removed. Units
+// would then need to be updated in each slice whenever another slice changes.
+type SliceValues struct {
+	// SliceID is the ID of the slice the unit is rendered for. This is empty
+	// for unsliced groups.
+	SliceID string
+}
+
+// UnitRenderer renders the content of the given unit, as found in
+// Request.Units, for the slice described by values.
+type UnitRenderer func(u Unit, values SliceValues) (string, error)
+
+// renderUnit returns the content of the given unit rendered for the slice
+// described by values. Without a Renderer the content is returned as it is.
+func (r Request) renderUnit(u Unit, values SliceValues) (string, error) {
+	if r.Renderer == nil {
+		return u.Content, nil
+	}
+
+	content, err := r.Renderer(u, values)
+	if err != nil {
+		return "", maskAnyf(renderUnitError, "unit '%s': %s", u.Name, err.Error())
+	}
+
+	return content, nil
+}
+
+// matchesUnitHash checks whether the given hash is the hash of the content of
+// the given unit rendered for the given slice.
+func (r Request) matchesUnitHash(u Unit, sliceID string, hash string) (bool, error) {
+	content, err := r.renderUnit(u, SliceValues{SliceID: sliceID})
+	if err != nil {
+		return false, maskAny(err)
+	}
+	unitFile, err := unit.NewUnitFile(content)
+	if err != nil {
+		return false, maskAny(err)
+	}
+
+	return unitFile.Hash().String() == hash, nil
+}
+
+// renderExistingUnit returns the normalized content of the given unit rendered
+// for the given existing slice.
+func (r Request) renderExistingUnit(u Unit, sliceID string) (string, error) {
+	content, err := r.renderUnit(u, SliceValues{SliceID: sliceID})
+	if err != nil {
+		return "", maskAny(err)
+	}
+	content, err = normalizeUnitContent(content)
+	if err != nil {
+		return "", maskAny(err)
+	}
+
+	return content, nil
+}
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+// testRenderer replaces "SLICE" within unit contents by the ID of the slice.
+func testRenderer(u Unit, values SliceValues) (string, error) {
+	if strings.Contains(u.Content, "FAIL") {
+		return "", fmt.Errorf("test failure")
+	}
+
+	return strings.Replace(u.Content, "SLICE", values.SliceID, -1), nil
+}
+
+// Test_Request_ExtendSlices_Renderer tests that unit contents are rendered for
+// each slice.
+func Test_Request_ExtendSlices_Renderer(t *testing.T) {
+	req := Request{
+		RequestConfig: RequestConfig{
+			SliceIDs: []string{"a", "b"},
+		},
+		Units: []Unit{
+			{
+				Name:    "unit@.service",
+				Content: "slice SLICE",
+			},
+		},
+		Renderer: testRenderer,
+	}
+
+	output, err := req.ExtendSlices()
+	if err != nil {
+		t.Fatal("Request.ExtendSlices returned error:", err)
+	}
+	if output.Renderer != nil {
+		t.Fatal("Expected extended request to have no renderer")
+	}
+
+	expected := []Unit{
+		{Name: "unit@a.service", Content: "slice a"},
+		{Name: "unit@b.service", Content: "slice b"},
+	}
+	for i, u := range expected {
+		if output.Units[i] != u {
+			t.Fatalf("Expected %#v, got %#v", u, output.Units[i])
+		}
+	}
+
+	// Units of unsliced groups are rendered as well.
+	req.SliceIDs = nil
+	req.Units = []Unit{{Name: "unit.service", Content: "slice SLICE"}}
+	output, err = req.ExtendSlices()
+	if err != nil {
+		t.Fatal("Request.ExtendSlices returned error:", err)
+	}
+	if output.Units[0].Content != "slice " {
+		t.Fatal("Expected unsliced unit to be rendered, got:", output.Units[0].Content)
+	}
+
+	req.Units = []Unit{{Name: "unit.service", Content: "FAIL"}}
+	_, err = req.ExtendSlices()
+	if !IsRenderUnit(err) {
+		t.Fatal("Expected render unit error, got:", err)
+	}
+}
+
+// TestController_GroupNeedsUpdate_Renderer tests that the unit contents
+// rendered for each slice are compared.
+func TestController_GroupNeedsUpdate_Renderer(t *testing.T) {
+	testController, dummyFleet := getTestController()
+
+	dummyFleet.Submit(context.Background(), "falcon-unit@1.service", "[Service]\nExecStart=/bin/falcon --slice=1\n")
+	dummyFleet.Submit(context.Background(), "falcon-unit@2.service", "[Service]\nExecStart=/bin/falcon --slice=2\n")
+
+	req := Request{
+		RequestConfig: RequestConfig{
+			Group:    "falcon",
+			SliceIDs: []string{"1", "2"},
+		},
+		Units: []Unit{
+			{
+				Name:    "falcon-unit@.service",
+				Content: "[Service]\nExecStart=/bin/falcon --slice=SLICE\n",
+			},
+		},
+		Renderer: testRenderer,
+	}
+
+	_, ok, err := testController.GroupNeedsUpdate(context.Background(), req)
+	if err != nil {
+		t.Fatal("Error returned by GroupNeedsUpdate:", err)
+	}
+	if ok {
+		t.Fatal("Expected group to be up to date")
+	}
+
+	req.Units[0].Content = "[Service]\nExecStart=/bin/falcon --slice=SLICE --verbose\n"
+	dirtyReq, ok, err := testController.GroupNeedsUpdate(context.Background(), req)
+	if err != nil {
+		t.Fatal("Error returned by GroupNeedsUpdate:", err)
+	}
+	if !ok {
+		t.Fatal("Expected group to need an update")
+	}
+	if len(dirtyReq.SliceIDs) != 2 {
+		t.Fatal("Expected both slices to need an update, got:", dirtyReq.SliceIDs)
+	}
+
+	// Slice 2 runs the content rendered for slice 1.
+	dummyFleet.Submit(context.Background(), "falcon-unit@2.service", "[Service]\nExecStart=/bin/falcon --slice=1\n")
+	req.Units[0].Content = "[Service]\nExecStart=/bin/falcon --slice=SLICE\n"
+	dirtyReq, ok, err = testController.GroupNeedsUpdate(context.Background(), req)
+	if err != nil {
+		t.Fatal("Error returned by GroupNeedsUpdate:", err)
+	}
+	if !ok {
+		t.Fatal("Expected group to need an update")
+	}
+	if !reflect.DeepEqual(dirtyReq.SliceIDs, []string{"2"}) {
+		t.Fatal("Expected slice 2 to need an update, got:", dirtyReq.SliceIDs)
+	}
+}
+
+// TestController_Diff_Renderer tests that slices are compared against the
+// unit contents rendered for each of them.
+func TestController_Diff_Renderer(t *testing.T) {
+	testController, dummyFleet := getTestController()
+
+	dummyFleet.Submit(context.Background(), "falcon-unit@1.service", "[Service]\nExecStart=/bin/falcon --slice=1\n")
+	dummyFleet.Submit(context.Background(), "falcon-unit@2.service", "[Service]\nExecStart=/bin/falcon --slice=2\n")
+
+	req := Request{
+		RequestConfig: RequestConfig{
+			Group:    "falcon",
+			SliceIDs: []string{"1", "2"},
+		},
+		Units: []Unit{
+			{
+				Name:    "falcon-unit@.service",
+				Content: "[Service]\nExecStart=/bin/falcon --slice=SLICE\n",
+			},
+		},
+		Renderer: testRenderer,
+	}
+
+	diffs, err := testController.Diff(context.Background(), req)
+	if err != nil {
+		t.Fatal("Error returned by Diff:", err)
+	}
+	if len(diffs) != 1 || diffs[0].Diff != "" || len(diffs[0].SliceIDs) != 2 {
+		t.Fatalf("Expected one variant without diff, got %#v", diffs)
+	}
+}
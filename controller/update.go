@@ -22,7 +22,7 @@ func (c controller) executeTaskAction(f func(ctx context.Context, req Request) (
 	if err != nil {
 		return maskAny(err)
 	}
-	if task.HasFailedStatus(taskObject) {
+	if task.HasFailedStatus(taskObject) || task.HasCancelledStatus(taskObject) {
 		return maskAny(taskObject.Error)
 	}
 	return nil
@@ -37,7 +37,7 @@ func (c controller) getNumRunningSlices(ctx context.Context, req Request) (int,
 	} else if err != nil {
 		return 0, maskAny(err)
 	}
-	grouped, err := UnitStatusList(groupStatus).Group()
+	grouped, err := UnitStatusList(groupStatus).ResolveTriggered().Group()
 	if err != nil {
 		return 0, maskAny(err)
 	}
@@ -77,11 +77,11 @@ func (c controller) isGroupRemovalAllowed(ctx context.Context, req Request, minA
 
 	c.Config.Logger.Debug(
 		ctx, "controller: removeInProgress: %v, minAlive: %v",
-		*removeInProgress, minAlive,
+		atomic.LoadInt64(removeInProgress), minAlive,
 	)
 	// if minAlive = 0 we don't need to calculate if we can remove slices,
 	// as the user provided us with the information, that killing slices is fine
-	if (minAlive-int(*removeInProgress)) > 0 || minAlive == 0 {
+	if (minAlive-int(atomic.LoadInt64(removeInProgress))) > 0 || minAlive == 0 {
 		c.Config.Logger.Debug(ctx, "controller: group removal allowed ((minAlive - int(removeInProgress)) > 0) || minAlive == 0")
 		return true, nil
 	}
@@ -95,10 +95,10 @@ func (c controller) isGroupAdditionAllowed(ctx context.Context, req Request, max
 
 	c.Config.Logger.Debug(
 		ctx, "controller: additionInProgress: %v, maxGrowth: %v",
-		*additionInProgress, maxGrowth,
+		atomic.LoadInt64(additionInProgress), maxGrowth,
 	)
 
-	if (maxGrowth - int(*additionInProgress)) > 0 {
+	if (maxGrowth - int(atomic.LoadInt64(additionInProgress))) > 0 {
 		c.Config.Logger.Debug(ctx, "controller: group addition allowed ((maxGrowth  - additionInProgress) > 0)")
 		return true, nil
 	}
@@ -155,7 +155,14 @@ func (c controller) runAddWorker(ctx context.Context, req Request, opts UpdateOp
 		return Request{}, maskAny(err)
 	}
 
-	time.Sleep(time.Duration(opts.ReadySecs) * time.Second)
+	// Check health.
+	if err := c.checkHealth(ctx, newReq, opts); err != nil {
+		return Request{}, maskAny(err)
+	}
+
+	if err := sleep(ctx, time.Duration(opts.ReadySecs)*time.Second); err != nil {
+		return Request{}, maskAny(err)
+	}
 
 	return newReq, nil
 }
@@ -234,8 +241,52 @@ type UpdateOptions struct {
 	// group. This is basically a cool down where the update process sleeps
 	// before updating the next group.
 	ReadySecs int
+
+	// Rollback defines whether a failed update should be rolled back. When set,
+	// the slice IDs and unit contents of the group are remembered before the
+	// update starts. In case the update fails, all slices created by the update
+	// are removed again and all slices removed by the update are put back using
+	// their original slice IDs and unit contents.
+	Rollback bool
+
+	// Canary represents the number of slices updated first. Zero disables the
+	// canary update. Once the canary slices are updated, the update either ends
+	// or continues with respect to CanaryGateSecs. See also Canary.
+	Canary int
+
+	// CanaryGateSecs represents the number of seconds all canary slices need to
+	// be running without interruption. In case they do, the remaining slices
+	// are updated. Otherwise the canary slices are rolled back. Zero means the
+	// update ends once the canary slices are updated, leaving the decision to
+	// PromoteCanary or AbortCanary.
+	CanaryGateSecs int
+
+	// HealthCheck defines the health check new slices need to pass before
+	// they are considered added. A slice failing the health check fails the
+	// update. See HealthCheck.
+	HealthCheck HealthCheck
+
+	// Strategy defines how slices are replaced. The zero value means
+	// StrategyRolling. See UpdateStrategy.
+	Strategy UpdateStrategy
 }
 
+// UpdateStrategy represents the way an update replaces the slices of a group.
+type UpdateStrategy string
+
+const (
+	// StrategyRolling replaces slices one at a time with respect to MaxGrowth
+	// and MinAlive.
+	StrategyRolling UpdateStrategy = "rolling"
+
+	// StrategyBlueGreen submits and starts a full new set of slices next to
+	// the existing ones. Once all new slices are running and ReadySecs have
+	// passed, all existing slices are removed in one step. In case any new
+	// slice fails, the new slices are removed and the existing slices are left
+	// untouched. MaxGrowth and MinAlive are not taken into account.
+	StrategyBlueGreen UpdateStrategy = "blue-green"
+)
+
 // updateCurrentSliceIDs updates the list of current slice IDs,
 // removing the slice that was modified, and adding any new slice IDs.
 func (c controller) updateCurrentSliceIDs(ctx context.Context, currentSliceIDs []string, modifiedSliceIDs []string, newSliceIDs []string) []string {
@@ -266,8 +317,10 @@ func (c controller) updateCurrentSliceIDs(ctx context.Context, currentSliceIDs [
 func (c controller) UpdateWithStrategy(ctx context.Context, req Request, opts UpdateOptions) error {
 	c.Config.Logger.Debug(ctx, "controller: running update for group '%v'", req.Group)
 
-	fail := make(chan error, 1)
 	numTotal := len(req.SliceIDs)
+	// The channel is buffered to not block any worker in case multiple
+	// workers fail.
+	fail := make(chan error, numTotal)
 
 	done := make(chan struct{}, numTotal)
 
@@ -289,6 +342,16 @@ func (c controller) UpdateWithStrategy(ctx context.Context, req Request, opts Up
 		return maskAnyf(updateNotAllowedError, "invalid min alive option")
 	}
 
+	// Workers still adding or removing slices are cancelled and waited for
+	// before returning. Otherwise they would keep changing the group after the
+	// update failed, e.g. while it is rolled back.
+	ctx, cancel := context.WithCancel(ctx)
+	var workers sync.WaitGroup
+	defer func() {
+		cancel()
+		workers.Wait()
+	}()
+
 	// We need to track which slice IDs are currently in use.
 	// This list is updated as slices are added and removed.
 	currentSliceIDsMutex := sync.Mutex{}
@@ -296,8 +359,21 @@ func (c controller) UpdateWithStrategy(ctx context.Context, req Request, opts Up
 	for _, id := range req.SliceIDs {
 		currentSliceIDs = append(currentSliceIDs, id)
 	}
+	// copyCurrentSliceIDs returns a copy of the slice IDs currently in use,
+	// since workers update them concurrently.
+	copyCurrentSliceIDs := func() []string {
+		currentSliceIDsMutex.Lock()
+		defer currentSliceIDsMutex.Unlock()
+		return append([]string{}, currentSliceIDs...)
+	}
 
 	for _, sliceID := range req.SliceIDs {
+		// Slices currently being added or removed are not affected by pausing.
+		// Only further slices are not updated until the update is resumed.
+		if err := c.waitWhileUpdatePaused(ctx, req, fail); err != nil {
+			return maskAny(err)
+		}
+
 		newReq := req
 		newReq.SliceIDs = []string{sliceID}
 
@@ -312,22 +388,24 @@ func (c controller) UpdateWithStrategy(ctx context.Context, req Request, opts Up
 			// See also isGroupAdditionAllowed.
 			c.Config.Logger.Debug(
 				ctx, "controller: opts.MaxGrowth: %v, numTotal: %v, opts.MinAlive: %v, addInProgress: %v",
-				opts.MaxGrowth, numTotal, opts.MinAlive, int(addInProgress),
+				opts.MaxGrowth, numTotal, opts.MinAlive, atomic.LoadInt64(&addInProgress),
 			)
 
-			c.Config.Logger.Debug(ctx, "controller: currentSliceIDs: %v", currentSliceIDs)
-			currentSliceReq.SliceIDs = currentSliceIDs
+			currentSliceReq.SliceIDs = copyCurrentSliceIDs()
+			c.Config.Logger.Debug(ctx, "controller: currentSliceIDs: %v", currentSliceReq.SliceIDs)
 			ok, err := c.isGroupAdditionAllowed(ctx, currentSliceReq, opts.MaxGrowth, &addInProgress)
 			if err != nil {
 				return maskAny(err)
 			}
 			if ok {
-				ctx = context.WithValue(ctx, "slice ID", sliceID)
+				sliceCtx := context.WithValue(ctx, "slice ID", sliceID)
 				// we increase the addInProgress counter before starting the goroutine
 				// to avoid a race condition in the allowed calculation
 				atomic.AddInt64(&addInProgress, 1)
-				go func(req Request) {
-					ctx := context.WithValue(ctx, "add slice", req.SliceIDs)
+				workers.Add(1)
+				go func(ctx context.Context, req Request) {
+					defer workers.Done()
+					ctx = context.WithValue(ctx, "add slice", req.SliceIDs)
 					c.Config.Logger.Debug(ctx, "controller: starting to add slice: %v", req.SliceIDs)
 
 					newSliceIDs, err := c.addFirst(ctx, req, opts)
@@ -342,7 +420,7 @@ func (c controller) UpdateWithStrategy(ctx context.Context, req Request, opts Up
 
 					atomic.AddInt64(&addInProgress, -1)
 					done <- struct{}{}
-				}(newReq)
+				}(sliceCtx, newReq)
 
 				break
 			}
@@ -354,21 +432,24 @@ func (c controller) UpdateWithStrategy(ctx context.Context, req Request, opts Up
 			//=> (minAlive - int(removeInProgress)) > 0)
 			c.Config.Logger.Debug(
 				ctx, "controller: opts.MinAlive: %v, removeInProgress: %v",
-				opts.MinAlive, int(removeInProgress),
+				opts.MinAlive, atomic.LoadInt64(&removeInProgress),
 			)
 
-			c.Config.Logger.Debug(ctx, "controller: currentSliceIDs: %v", currentSliceIDs)
-			currentSliceReq.SliceIDs = currentSliceIDs
+			currentSliceReq.SliceIDs = copyCurrentSliceIDs()
+			c.Config.Logger.Debug(ctx, "controller: currentSliceIDs: %v", currentSliceReq.SliceIDs)
 			ok, err = c.isGroupRemovalAllowed(ctx, currentSliceReq, opts.MinAlive, &removeInProgress)
 			if err != nil {
 				return maskAny(err)
 			}
 			if ok {
+				sliceCtx := context.WithValue(ctx, "slice ID", sliceID)
 				// we increase the removeInProgress counter before starting the goroutine
 				// to avoid a race condition in the allowed calculation
 				atomic.AddInt64(&removeInProgress, 1)
-				go func(req Request) {
-					ctx := context.WithValue(ctx, "remove slice", req.SliceIDs)
+				workers.Add(1)
+				go func(ctx context.Context, req Request) {
+					defer workers.Done()
+					ctx = context.WithValue(ctx, "remove slice", req.SliceIDs)
 					c.Config.Logger.Debug(ctx, "controller: starting to remove slice: %v", req.SliceIDs)
 
 					newSliceIDs, err := c.removeFirst(ctx, req, opts)
@@ -383,12 +464,14 @@ func (c controller) UpdateWithStrategy(ctx context.Context, req Request, opts Up
 
 					atomic.AddInt64(&removeInProgress, -1)
 					done <- struct{}{}
-				}(newReq)
+				}(sliceCtx, newReq)
 
 				break
 			}
 
-			time.Sleep(c.WaitSleep)
+			if err := sleep(ctx, c.WaitSleep); err != nil {
+				return maskAny(err)
+			}
 		}
 	}
 
@@ -403,6 +486,8 @@ func (c controller) UpdateWithStrategy(ctx context.Context, req Request, opts Up
 			if tc == numTotal {
 				return nil
 			}
+		case <-ctx.Done():
+			return maskAny(ctx.Err())
 		case <-time.After(c.WaitTimeout):
 			return maskAny(waitTimeoutReachedError)
 		}
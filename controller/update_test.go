@@ -396,6 +396,7 @@ func TestUpdateWithStrategy(t *testing.T) {
 				}
 
 				unitStatusList, err := f.GetStatusWithMatcher(
+					context.Background(),
 					func(s string) bool {
 						return strings.HasPrefix(s, "bluebird-unit@") && strings.HasSuffix(s, ".service")
 					},
@@ -452,6 +453,7 @@ func TestUpdateWithStrategy(t *testing.T) {
 				}
 
 				unitStatusList, err := f.GetStatusWithMatcher(
+					context.Background(),
 					func(s string) bool {
 						return strings.HasPrefix(s, "canary-unit@") && strings.HasSuffix(s, ".service")
 					},
@@ -511,6 +513,7 @@ func TestUpdateWithStrategy(t *testing.T) {
 				}
 
 				unitStatusList, err := f.GetStatusWithMatcher(
+					context.Background(),
 					func(s string) bool {
 						return strings.HasPrefix(s, "sparrow-unit@") && strings.HasSuffix(s, ".service")
 					},
@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"sort"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/common"
+	"github.com/giantswarm/inago/fleet"
+)
+
+// StatusChange represents the change of the aggregated status of one slice of
+// a group between two polls.
+type StatusChange struct {
+	// SliceID is the ID of the slice having changed. This is empty for unsliced
+	// groups.
+	SliceID string
+
+	// From is the status of the slice seen at the previous poll. Slices
+	// appearing have the status StatusNotFound here.
+	From Status
+
+	// To is the status of the slice seen at the current poll. Slices
+	// disappearing have the status StatusNotFound here.
+	To Status
+}
+
+// StatusEvent represents the result of one poll of WatchStatus.
+type StatusEvent struct {
+	// Time is the time the poll happened.
+	Time time.Time
+
+	// UnitStatusList is the status of all units of the group found at the
+	// time of the poll.
+	UnitStatusList []fleet.UnitStatus
+
+	// Changes contains the slices whose status changed since the previous
+	// poll. This is empty for the first event.
+	Changes []StatusChange
+
+	// UpdatePaused is true when updates of the group are paused. See
+	// PauseUpdate.
+	UpdatePaused bool
+
+	// Error is the error occurred during the poll, if any. Polling continues
+	// regardless.
+	Error error
+}
+
+func (c controller) WatchStatus(ctx context.Context, req Request) <-chan StatusEvent {
+	c.Config.Logger.Debug(ctx, "controller: watching status of group '%v'", req.Group)
+
+	// New slices are created during updates. Thus the whole group is watched.
+	req.SliceIDs = nil
+
+	events := make(chan StatusEvent)
+
+	go func() {
+		defer close(events)
+
+		var previous map[string]Status
+		for {
+			event := StatusEvent{
+				Time: time.Now(),
+			}
+
+			unitStatusList, err := c.groupStatus(ctx, req)
+			if err != nil && !IsUnitNotFound(err) {
+				event.Error = maskAny(err)
+			} else {
+				event.UnitStatusList = unitStatusList
+
+				current, err := c.sliceStatuses(unitStatusList)
+				if err != nil {
+					event.Error = maskAny(err)
+				} else {
+					if previous != nil {
+						event.Changes = statusChanges(previous, current)
+					}
+					previous = current
+				}
+			}
+
+			paused, err := c.IsUpdatePaused(ctx, req)
+			if err != nil && event.Error == nil {
+				event.Error = maskAny(err)
+			}
+			event.UpdatePaused = paused
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+
+			if err := sleep(ctx, c.WaitSleep); err != nil {
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+// sliceStatusPriority orders statuses by how much attention they need. When
+// the units of a slice have different statuses, the status of the slice is
+// the one with the highest priority.
+var sliceStatusPriority = []Status{
+	StatusFailed,
+	StatusStopping,
+	StatusStarting,
+	StatusStopped,
+	StatusRunning,
+}
+
+// sliceStatuses aggregates the statuses of the units of each slice within the
+// given unit status list, indexed by slice ID.
+func (c controller) sliceStatuses(unitStatusList []fleet.UnitStatus) (map[string]Status, error) {
+	aggregator := Aggregator{
+		Logger: c.Config.Logger,
+	}
+
+	unitStatuses := map[string]map[Status]struct{}{}
+	for _, us := range UnitStatusList(unitStatusList).ResolveTriggered() {
+		sliceID, err := common.SliceID(us.Name)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		status, err := aggregator.AggregateUnitStatus(us)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+
+		if _, ok := unitStatuses[sliceID]; !ok {
+			unitStatuses[sliceID] = map[Status]struct{}{}
+		}
+		unitStatuses[sliceID][status] = struct{}{}
+	}
+
+	sliceStatuses := map[string]Status{}
+	for sliceID, statuses := range unitStatuses {
+		for _, status := range sliceStatusPriority {
+			if _, ok := statuses[status]; ok {
+				sliceStatuses[sliceID] = status
+				break
+			}
+		}
+	}
+
+	return sliceStatuses, nil
+}
+
+// statusChanges returns the changes between the given slice statuses, ordered
+// by slice ID.
+func statusChanges(previous, current map[string]Status) []StatusChange {
+	var sliceIDs []string
+	for sliceID := range previous {
+		sliceIDs = append(sliceIDs, sliceID)
+	}
+	for sliceID := range current {
+		if _, ok := previous[sliceID]; !ok {
+			sliceIDs = append(sliceIDs, sliceID)
+		}
+	}
+	sort.Strings(sliceIDs)
+
+	var changes []StatusChange
+	for _, sliceID := range sliceIDs {
+		from, ok := previous[sliceID]
+		if !ok {
+			from = StatusNotFound
+		}
+		to, ok := current[sliceID]
+		if !ok {
+			to = StatusNotFound
+		}
+
+		if from != to {
+			changes = append(changes, StatusChange{
+				SliceID: sliceID,
+				From:    from,
+				To:      to,
+			})
+		}
+	}
+
+	return changes
+}
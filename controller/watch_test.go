@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+// TestWatchStatus tests that slices appearing, changing and disappearing are
+// reported as status changes.
+func TestWatchStatus(t *testing.T) {
+	testController, dummyFleet := getTestController()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dummyFleet.Submit(ctx, "app-server@1.service", "[Service]\nExecStart=/bin/server\n")
+
+	events := testController.WatchStatus(ctx, Request{
+		RequestConfig: RequestConfig{
+			Group: "app",
+		},
+	})
+
+	event := <-events
+	if event.Error != nil {
+		t.Fatal("Error returned by watch:", event.Error)
+	}
+	if len(event.UnitStatusList) != 1 {
+		t.Fatal("Expected 1 unit status, got:", event.UnitStatusList)
+	}
+	if len(event.Changes) != 0 {
+		t.Fatal("Expected no changes for first event, got:", event.Changes)
+	}
+
+	dummyFleet.Start(ctx, "app-server@1.service")
+	dummyFleet.Submit(ctx, "app-server@2.service", "[Service]\nExecStart=/bin/server\n")
+
+	event = <-events
+	expected := []StatusChange{
+		{SliceID: "1", From: StatusStopped, To: StatusRunning},
+		{SliceID: "2", From: StatusNotFound, To: StatusStopped},
+	}
+	if !reflect.DeepEqual(event.Changes, expected) {
+		t.Fatalf("Expected %#v, got %#v", expected, event.Changes)
+	}
+
+	dummyFleet.Destroy(ctx, "app-server@1.service")
+
+	event = <-events
+	expected = []StatusChange{
+		{SliceID: "1", From: StatusRunning, To: StatusNotFound},
+	}
+	if !reflect.DeepEqual(event.Changes, expected) {
+		t.Fatalf("Expected %#v, got %#v", expected, event.Changes)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func Test_statusChanges(t *testing.T) {
+	testCases := []struct {
+		Previous map[string]Status
+		Current  map[string]Status
+		Expected []StatusChange
+	}{
+		{
+			Previous: map[string]Status{},
+			Current:  map[string]Status{},
+			Expected: nil,
+		},
+		{
+			Previous: map[string]Status{"a": StatusRunning},
+			Current:  map[string]Status{"a": StatusRunning},
+			Expected: nil,
+		},
+		{
+			Previous: map[string]Status{"b": StatusStarting, "c": StatusRunning},
+			Current:  map[string]Status{"a": StatusStopped, "b": StatusRunning},
+			Expected: []StatusChange{
+				{SliceID: "a", From: StatusNotFound, To: StatusStopped},
+				{SliceID: "b", From: StatusStarting, To: StatusRunning},
+				{SliceID: "c", From: StatusRunning, To: StatusNotFound},
+			},
+		},
+		{
+			Previous: map[string]Status{"": StatusStopped},
+			Current:  map[string]Status{"": StatusFailed},
+			Expected: []StatusChange{
+				{SliceID: "", From: StatusStopped, To: StatusFailed},
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		output := statusChanges(testCase.Previous, testCase.Current)
+		if !reflect.DeepEqual(output, testCase.Expected) {
+			t.Fatalf("test case %d: expected %#v, got %#v", i+1, testCase.Expected, output)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package fakefleet
+
+import (
+	"fmt"
+
+	"github.com/juju/errgo"
+)
+
+var (
+	maskAny = errgo.MaskFunc(errgo.Any)
+)
+
+// maskAnyf returns a new github.com/juju/errgo error wrapping the given one.
+// The message will contain the message of f and v (see fmt.Printf), prefixed
+// with the message of err.
+func maskAnyf(err error, f string, v ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+
+	f = fmt.Sprintf("%s: %s", err.Error(), f)
+	newErr := errgo.WithCausef(nil, errgo.Cause(err), f, v...)
+	newErr.(*errgo.Err).SetLocation(1)
+
+	return newErr
+}
+
+var unitNotFoundError = errgo.New("unit does not exist")
+
+// IsUnitNotFound checks whether the given error indicates the problem of a
+// unit not being known to the fake fleet server.
+func IsUnitNotFound(err error) bool {
+	return errgo.Cause(err) == unitNotFoundError
+}
+
+var invalidUnitError = errgo.New("invalid unit")
+
+// IsInvalidUnit checks whether the given error indicates the problem of a
+// request describing a unit fleet would reject.
+func IsInvalidUnit(err error) bool {
+	return errgo.Cause(err) == invalidUnitError
+}
+
+var unitConflictError = errgo.New("unit conflict")
+
+// IsUnitConflict checks whether the given error indicates the problem of a
+// request conflicting with the current state of a unit. E.g. setting the
+// target state of a unit that was never submitted.
+func IsUnitConflict(err error) bool {
+	return errgo.Cause(err) == unitConflictError
+}
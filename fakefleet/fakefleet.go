@@ -0,0 +1,393 @@
+// Package fakefleet implements an in-process server speaking the fleet v1 HTTP
+// API. It keeps units in memory and simulates the state transitions fleet and
+// systemd would report for them. It is intended to be used for hermetic tests
+// that need to exercise the real fleet client without a fleet cluster.
+package fakefleet
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/fleet/machine"
+	"github.com/coreos/fleet/schema"
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/logging"
+)
+
+const (
+	unitStateInactive = "inactive"
+	unitStateLoaded   = "loaded"
+	unitStateLaunched = "launched"
+)
+
+// Config provides all necessary and injectable configurations for a new
+// fake fleet server.
+type Config struct {
+	// Machines represents the machines of the simulated cluster. Units are
+	// scheduled on them in a round robin fashion. Global units are scheduled
+	// on all of them.
+	Machines []machine.MachineState
+
+	// TransitionDelay represents the time a unit needs to reach its desired
+	// state. In the meantime the unit reports its previous state, together with
+	// the systemd states of a unit being activated or deactivated.
+	TransitionDelay time.Duration
+
+	// Logger provides an initialised logger.
+	Logger logging.Logger
+}
+
+// DefaultConfig provides a set of configurations with default values by best
+// effort.
+func DefaultConfig() Config {
+	newConfig := Config{
+		Machines: []machine.MachineState{
+			{
+				ID:       "b1c8a0b9e6b34e7f9c5a3d2f1e0d9c8b",
+				PublicIP: "127.0.0.1",
+			},
+		},
+		TransitionDelay: 1 * time.Second,
+		Logger:          logging.NewLogger(logging.DefaultConfig()),
+	}
+
+	return newConfig
+}
+
+// NewServer creates a new fake fleet server that is configured with the given
+// settings. The returned handler serves the fleet v1 HTTP API below
+// /fleet/v1/, which is what the fleet client expects.
+//
+//   newConfig := fakefleet.DefaultConfig()
+//   newServer := fakefleet.NewServer(newConfig)
+//   http.ListenAndServe("127.0.0.1:49153", newServer)
+//
+func NewServer(config Config) *Server {
+	newServer := &Server{
+		Config: config,
+		units:  map[string]*fakeUnit{},
+		now:    time.Now,
+	}
+
+	return newServer
+}
+
+// Server implements the fleet v1 HTTP API on top of an in-memory unit
+// registry. See NewServer.
+type Server struct {
+	Config Config
+
+	// units holds all units submitted to the server, indexed by their name.
+	units map[string]*fakeUnit
+	mutex sync.Mutex
+
+	// next is the index of the machine the next scheduled unit is placed on.
+	next int
+
+	// now is used to determine the current time. Tests can replace it to
+	// control state transitions.
+	now func() time.Time
+}
+
+type fakeUnit struct {
+	Name    string
+	Options []*schema.UnitOption
+
+	// Previous is the state the unit had before its desired state was changed
+	// the last time. It is reported as current state until the transition
+	// delay passed.
+	Previous string
+	Desired  string
+	Changed  time.Time
+
+	// MachineIDs contains the IDs of the machines the unit is scheduled on.
+	MachineIDs []string
+}
+
+// CreateUnit adds the given unit to the server. In case the unit already
+// exists, only its desired state is changed, just like fleet does.
+func (s *Server) CreateUnit(u *schema.Unit) error {
+	s.Config.Logger.Debug(context.Background(), "fakefleet: creating unit '%v'", u.Name)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.units[u.Name]; ok {
+		return maskAny(s.setUnitTargetState(u.Name, u.DesiredState))
+	}
+
+	if len(u.Options) == 0 {
+		return maskAnyf(unitConflictError, "unit does not exist and options field empty")
+	}
+	if err := validateUnit(u.Name, u.DesiredState); err != nil {
+		return maskAny(err)
+	}
+
+	desired := u.DesiredState
+	if desired == "" {
+		desired = unitStateInactive
+	}
+
+	s.units[u.Name] = &fakeUnit{
+		Name:     u.Name,
+		Options:  u.Options,
+		Previous: unitStateInactive,
+		Desired:  unitStateInactive,
+		Changed:  s.now(),
+	}
+	s.transition(s.units[u.Name], desired)
+
+	return nil
+}
+
+// SetUnitTargetState changes the desired state of the unit given by name.
+func (s *Server) SetUnitTargetState(name, target string) error {
+	s.Config.Logger.Debug(context.Background(), "fakefleet: setting target state of unit '%v' to '%v'", name, target)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return maskAny(s.setUnitTargetState(name, target))
+}
+
+// DestroyUnit removes the unit given by name from the server.
+func (s *Server) DestroyUnit(name string) error {
+	s.Config.Logger.Debug(context.Background(), "fakefleet: destroying unit '%v'", name)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.units[name]; !ok {
+		return maskAnyf(unitNotFoundError, "%s", name)
+	}
+	delete(s.units, name)
+
+	return nil
+}
+
+// Unit returns the unit given by name, as fleet would report it.
+func (s *Server) Unit(name string) (*schema.Unit, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	u, ok := s.units[name]
+	if !ok {
+		return nil, maskAnyf(unitNotFoundError, "%s", name)
+	}
+
+	return s.schemaUnit(u), nil
+}
+
+// ListUnits returns all units known to the server, ordered by name.
+func (s *Server) ListUnits() []*schema.Unit {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var units []*schema.Unit
+	for _, name := range s.unitNames() {
+		units = append(units, s.schemaUnit(s.units[name]))
+	}
+
+	return units
+}
+
+// ListUnitStates returns the systemd states of all scheduled units, ordered by
+// unit name.
+func (s *Server) ListUnitStates() []*schema.UnitState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var unitStates []*schema.UnitState
+	for _, name := range s.unitNames() {
+		unitStates = append(unitStates, s.schemaUnitStates(s.units[name])...)
+	}
+
+	return unitStates
+}
+
+// ListMachines returns all machines of the simulated cluster.
+func (s *Server) ListMachines() []*schema.Machine {
+	var machines []*schema.Machine
+	for i := range s.Config.Machines {
+		machines = append(machines, schema.MapMachineStateToSchema(&s.Config.Machines[i]))
+	}
+
+	return machines
+}
+
+func (s *Server) setUnitTargetState(name, target string) error {
+	u, ok := s.units[name]
+	if !ok {
+		return maskAnyf(unitConflictError, "unit does not exist and options field empty")
+	}
+	if target == "" {
+		return maskAnyf(unitConflictError, "must provide DesiredState to update existing unit")
+	}
+	if err := validateUnit(name, target); err != nil {
+		return maskAny(err)
+	}
+
+	s.transition(u, target)
+
+	return nil
+}
+
+// transition moves the given unit towards the given desired state and
+// schedules, or unschedules, it accordingly.
+func (s *Server) transition(u *fakeUnit, desired string) {
+	if u.Desired == desired {
+		return
+	}
+
+	u.Previous = s.current(u)
+	u.Desired = desired
+	u.Changed = s.now()
+
+	if desired == unitStateInactive {
+		u.MachineIDs = nil
+		return
+	}
+	if len(u.MachineIDs) > 0 || len(s.Config.Machines) == 0 {
+		return
+	}
+
+	if isGlobalUnit(u.Options) {
+		for _, m := range s.Config.Machines {
+			u.MachineIDs = append(u.MachineIDs, m.ID)
+		}
+		return
+	}
+
+	u.MachineIDs = []string{s.Config.Machines[s.next%len(s.Config.Machines)].ID}
+	s.next++
+}
+
+// current returns the state the given unit currently reports.
+func (s *Server) current(u *fakeUnit) string {
+	if s.settled(u) {
+		return u.Desired
+	}
+
+	return u.Previous
+}
+
+// settled checks whether the transition delay passed since the desired state
+// of the given unit was changed.
+func (s *Server) settled(u *fakeUnit) bool {
+	return s.now().Sub(u.Changed) >= s.Config.TransitionDelay
+}
+
+func (s *Server) schemaUnit(u *fakeUnit) *schema.Unit {
+	su := &schema.Unit{
+		Name:         u.Name,
+		Options:      u.Options,
+		DesiredState: u.Desired,
+		CurrentState: s.current(u),
+	}
+	if len(u.MachineIDs) == 1 && !isGlobalUnit(u.Options) {
+		su.MachineID = u.MachineIDs[0]
+	}
+
+	return su
+}
+
+func (s *Server) schemaUnitStates(u *fakeUnit) []*schema.UnitState {
+	if s.current(u) == unitStateInactive {
+		// Units are only reported by the fleet agents after they got loaded.
+		return nil
+	}
+
+	active, sub := "inactive", "dead"
+	switch {
+	case s.settled(u) && u.Desired == unitStateLaunched:
+		active, sub = s.launchedStates(u)
+	case !s.settled(u) && u.Desired == unitStateLaunched:
+		active, sub = "activating", "start"
+	case !s.settled(u) && u.Previous == unitStateLaunched:
+		active, sub = "deactivating", "stop-sigterm"
+	}
+
+	hash := schema.MapSchemaUnitOptionsToUnitFile(u.Options).Hash().String()
+
+	var unitStates []*schema.UnitState
+	for _, machineID := range u.MachineIDs {
+		unitStates = append(unitStates, &schema.UnitState{
+			Name:               u.Name,
+			Hash:               hash,
+			MachineID:          machineID,
+			SystemdLoadState:   "loaded",
+			SystemdActiveState: active,
+			SystemdSubState:    sub,
+		})
+	}
+
+	return unitStates
+}
+
+// launchedStates returns the systemd active and sub state of the given unit
+// once it is launched, depending on its unit type. Services activated by a
+// timer, socket or path unit of the same name stay inactive, since they are
+// only run when being triggered.
+func (s *Server) launchedStates(u *fakeUnit) (string, string) {
+	ext := path.Ext(u.Name)
+	switch ext {
+	case ".timer", ".path":
+		return "active", "waiting"
+	case ".socket":
+		return "active", "listening"
+	case ".mount":
+		return "active", "mounted"
+	case ".service":
+		base := strings.TrimSuffix(u.Name, ext)
+		for _, triggerType := range []string{".timer", ".socket", ".path"} {
+			if _, ok := s.units[base+triggerType]; ok {
+				return "inactive", "dead"
+			}
+		}
+	}
+
+	return "active", "running"
+}
+
+func (s *Server) unitNames() []string {
+	var names []string
+	for name := range s.units {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func validateUnit(name, desired string) error {
+	if !strings.Contains(name, ".") {
+		return maskAnyf(invalidUnitError, "unit name %q is missing a unit type", name)
+	}
+
+	switch desired {
+	case "", unitStateInactive, unitStateLoaded, unitStateLaunched:
+	default:
+		return maskAnyf(invalidUnitError, "invalid desired state %q", desired)
+	}
+
+	if strings.Contains(name, "@.") && desired != "" && desired != unitStateInactive {
+		return maskAnyf(invalidUnitError, "cannot activate template %q", name)
+	}
+
+	return nil
+}
+
+func isGlobalUnit(options []*schema.UnitOption) bool {
+	for _, option := range options {
+		if strings.EqualFold(option.Section, "X-Fleet") &&
+			strings.EqualFold(option.Name, "Global") &&
+			strings.EqualFold(option.Value, "true") {
+			return true
+		}
+	}
+	return false
+}
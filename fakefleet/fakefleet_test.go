@@ -0,0 +1,272 @@
+package fakefleet
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/fleet"
+)
+
+const (
+	testUnitContent = "[Unit]\nDescription=Test Unit\n\n[Service]\nExecStart=/bin/true\n"
+)
+
+// testClock provides a time source for the fake fleet server that only moves
+// when told to.
+type testClock struct {
+	Now time.Time
+}
+
+func (c *testClock) now() time.Time {
+	return c.Now
+}
+
+func givenServerAndClient(t *testing.T) (*Server, *testClock, fleet.Fleet, func()) {
+	clock := &testClock{Now: time.Now()}
+
+	newServerConfig := DefaultConfig()
+	newServerConfig.TransitionDelay = 1 * time.Second
+	newServer := NewServer(newServerConfig)
+	newServer.now = clock.now
+
+	httpServer := httptest.NewServer(newServer)
+
+	URL, err := url.Parse(httpServer.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	newFleetConfig := fleet.DefaultConfig()
+	newFleetConfig.Endpoint = *URL
+	newFleet, err := fleet.NewFleet(newFleetConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+
+	return newServer, clock, newFleet, httpServer.Close
+}
+
+// Test_FakeFleet_Lifecycle verifies that units submitted through the fleet
+// client go through the states fleet would report.
+func Test_FakeFleet_Lifecycle(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, clock, newFleet, closer := givenServerAndClient(t)
+	defer closer()
+	ctx := context.Background()
+
+	err := newFleet.Submit(ctx, "foo@1.service", testUnitContent)
+	Expect(err).To(BeNil())
+
+	// The unit is not yet loaded by any fleet agent.
+	unitStatus, err := newFleet.GetStatus(ctx, "foo@1.service")
+	Expect(err).To(BeNil())
+	Expect(unitStatus.Current).To(Equal("inactive"))
+	Expect(unitStatus.Desired).To(Equal("loaded"))
+	Expect(unitStatus.SliceID).To(Equal("1"))
+	Expect(unitStatus.Machine).To(BeEmpty())
+
+	clock.Now = clock.Now.Add(1 * time.Second)
+
+	unitStatus, err = newFleet.GetStatus(ctx, "foo@1.service")
+	Expect(err).To(BeNil())
+	Expect(unitStatus.Current).To(Equal("loaded"))
+	Expect(unitStatus.Machine).To(HaveLen(1))
+	Expect(unitStatus.Machine[0].IP.String()).To(Equal("127.0.0.1"))
+	Expect(unitStatus.Machine[0].SystemdActive).To(Equal("inactive"))
+
+	err = newFleet.Start(ctx, "foo@1.service")
+	Expect(err).To(BeNil())
+
+	unitStatus, err = newFleet.GetStatus(ctx, "foo@1.service")
+	Expect(err).To(BeNil())
+	Expect(unitStatus.Current).To(Equal("loaded"))
+	Expect(unitStatus.Desired).To(Equal("launched"))
+	Expect(unitStatus.Machine[0].SystemdActive).To(Equal("activating"))
+
+	clock.Now = clock.Now.Add(1 * time.Second)
+
+	unitStatus, err = newFleet.GetStatus(ctx, "foo@1.service")
+	Expect(err).To(BeNil())
+	Expect(unitStatus.Current).To(Equal("launched"))
+	Expect(unitStatus.Machine[0].SystemdActive).To(Equal("active"))
+	Expect(unitStatus.Machine[0].SystemdSub).To(Equal("running"))
+
+	err = newFleet.Stop(ctx, "foo@1.service")
+	Expect(err).To(BeNil())
+
+	unitStatus, err = newFleet.GetStatus(ctx, "foo@1.service")
+	Expect(err).To(BeNil())
+	Expect(unitStatus.Machine[0].SystemdActive).To(Equal("deactivating"))
+
+	clock.Now = clock.Now.Add(1 * time.Second)
+
+	unitStatus, err = newFleet.GetStatus(ctx, "foo@1.service")
+	Expect(err).To(BeNil())
+	Expect(unitStatus.Current).To(Equal("loaded"))
+	Expect(unitStatus.Machine[0].SystemdActive).To(Equal("inactive"))
+
+	err = newFleet.Destroy(ctx, "foo@1.service")
+	Expect(err).To(BeNil())
+
+	_, err = newFleet.GetStatus(ctx, "foo@1.service")
+	Expect(fleet.IsUnitNotFound(err)).To(BeTrue())
+}
+
+// Test_FakeFleet_UnitContent verifies that the unit hash and the unit content
+// reported by the fake fleet server match the submitted unit.
+func Test_FakeFleet_UnitContent(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, clock, newFleet, closer := givenServerAndClient(t)
+	defer closer()
+	ctx := context.Background()
+
+	err := newFleet.Submit(ctx, "foo.service", testUnitContent)
+	Expect(err).To(BeNil())
+	clock.Now = clock.Now.Add(1 * time.Second)
+
+	content, err := newFleet.GetUnitContent(ctx, "foo.service")
+	Expect(err).To(BeNil())
+	Expect(content).To(Equal(testUnitContent))
+
+	unitStatus, err := newFleet.GetStatus(ctx, "foo.service")
+	Expect(err).To(BeNil())
+	Expect(unitStatus.Machine[0].UnitHash).NotTo(BeEmpty())
+
+	_, err = newFleet.GetUnitContent(ctx, "bar.service")
+	Expect(fleet.IsUnitNotFound(err)).To(BeTrue())
+}
+
+// Test_FakeFleet_Errors verifies that the fake fleet server rejects requests
+// fleet would reject.
+func Test_FakeFleet_Errors(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, _, newFleet, closer := givenServerAndClient(t)
+	defer closer()
+	ctx := context.Background()
+
+	// Templates cannot be scheduled.
+	err := newFleet.Submit(ctx, "foo@.service", testUnitContent)
+	Expect(err).NotTo(BeNil())
+
+	// Units that were never submitted cannot be started.
+	err = newFleet.Start(ctx, "foo.service")
+	Expect(err).NotTo(BeNil())
+
+	// Units that were never submitted cannot be destroyed.
+	err = newFleet.Destroy(ctx, "foo.service")
+	Expect(err).NotTo(BeNil())
+}
+
+// Test_FakeFleet_GlobalUnit verifies that global units are scheduled on all
+// machines.
+func Test_FakeFleet_GlobalUnit(t *testing.T) {
+	RegisterTestingT(t)
+
+	newServer, clock, newFleet, closer := givenServerAndClient(t)
+	defer closer()
+	newServer.Config.Machines = append(newServer.Config.Machines, DefaultConfig().Machines[0])
+	newServer.Config.Machines[1].ID = "c2d9b1cafe7c45f0ad6b4e3f2a1e0dac"
+	newServer.Config.Machines[1].PublicIP = "127.0.0.2"
+	ctx := context.Background()
+
+	err := newFleet.Submit(ctx, "foo.service", testUnitContent+"\n[X-Fleet]\nGlobal=true\n")
+	Expect(err).To(BeNil())
+	err = newFleet.Submit(ctx, "bar@1.service", testUnitContent)
+	Expect(err).To(BeNil())
+	err = newFleet.Submit(ctx, "bar@2.service", testUnitContent)
+	Expect(err).To(BeNil())
+	clock.Now = clock.Now.Add(1 * time.Second)
+
+	unitStatus, err := newFleet.GetStatus(ctx, "foo.service")
+	Expect(err).To(BeNil())
+	Expect(unitStatus.Machine).To(HaveLen(2))
+
+	unitStatusList, err := newFleet.GetStatusWithMatcher(context.Background(), func(s string) bool {
+		return s == "bar@1.service" || s == "bar@2.service"
+	})
+	Expect(err).To(BeNil())
+	Expect(unitStatusList).To(HaveLen(2))
+	Expect(unitStatusList[0].Machine[0].ID).NotTo(Equal(unitStatusList[1].Machine[0].ID))
+}
+
+// Test_FakeFleet_UnitTypes verifies that launched units report the systemd
+// sub state of their unit type, and that services activated by a timer stay
+// inactive.
+func Test_FakeFleet_UnitTypes(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, clock, newFleet, closer := givenServerAndClient(t)
+	defer closer()
+	ctx := context.Background()
+
+	units := map[string]string{
+		"foo@1.service": testUnitContent,
+		"foo@1.timer":   "[Timer]\nOnCalendar=weekly\n",
+		"bar@1.socket":  "[Socket]\nListenStream=8080\n",
+		"baz@1.mount":   "[Mount]\nWhat=/dev/sdb\nWhere=/baz\n",
+	}
+	for name, content := range units {
+		err := newFleet.Submit(ctx, name, content)
+		Expect(err).To(BeNil())
+		err = newFleet.Start(ctx, name)
+		Expect(err).To(BeNil())
+	}
+	clock.Now = clock.Now.Add(1 * time.Second)
+
+	expected := map[string][]string{
+		"foo@1.service": {"inactive", "dead"},
+		"foo@1.timer":   {"active", "waiting"},
+		"bar@1.socket":  {"active", "listening"},
+		"baz@1.mount":   {"active", "mounted"},
+	}
+	for name, states := range expected {
+		unitStatus, err := newFleet.GetStatus(ctx, name)
+		Expect(err).To(BeNil())
+		Expect(unitStatus.Current).To(Equal("launched"))
+		Expect(unitStatus.Machine[0].SystemdActive).To(Equal(states[0]), name)
+		Expect(unitStatus.Machine[0].SystemdSub).To(Equal(states[1]), name)
+	}
+}
+
+// Test_FakeFleet_UnixSocket verifies that the fake fleet server can be reached
+// using the unix socket dialer of the fleet client.
+func Test_FakeFleet_UnixSocket(t *testing.T) {
+	RegisterTestingT(t)
+
+	dir, err := ioutil.TempDir("", "fakefleet")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+	sockPath := filepath.Join(dir, "fleet.sock")
+
+	l, err := net.Listen("unix", sockPath)
+	Expect(err).To(BeNil())
+	defer l.Close()
+
+	newServerConfig := DefaultConfig()
+	newServerConfig.TransitionDelay = 0
+	go http.Serve(l, NewServer(newServerConfig))
+
+	newFleetConfig := fleet.DefaultConfig()
+	newFleetConfig.Endpoint = url.URL{Scheme: "unix", Path: sockPath}
+	newFleet, err := fleet.NewFleet(newFleetConfig)
+	Expect(err).To(BeNil())
+
+	err = newFleet.Submit(context.Background(), "foo.service", testUnitContent)
+	Expect(err).To(BeNil())
+
+	unitStatus, err := newFleet.GetStatus(context.Background(), "foo.service")
+	Expect(err).To(BeNil())
+	Expect(unitStatus.Current).To(Equal("loaded"))
+}
@@ -0,0 +1,57 @@
+// Command fakefleetd runs a fake fleet server. It can be used to run the
+// integration tests against, without having a fleet cluster at hand.
+//
+//   fakefleetd --listen=127.0.0.1:49153 &
+//   inagoctl --fleet-endpoint=http://127.0.0.1:49153 status
+//
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/giantswarm/inago/fakefleet"
+	"github.com/giantswarm/inago/logging"
+)
+
+var (
+	listen          = flag.String("listen", "127.0.0.1:49153", "TCP address to serve the fleet API on")
+	socket          = flag.String("socket", "", "unix socket to serve the fleet API on, instead of a TCP address")
+	transitionDelay = flag.Duration("transition-delay", 1*time.Second, "time units need to reach their desired state")
+	verbose         = flag.Bool("verbose", false, "verbose output")
+)
+
+func main() {
+	flag.Parse()
+
+	loggingConfig := logging.DefaultConfig()
+	if *verbose {
+		loggingConfig.LogLevel = "DEBUG"
+	}
+
+	newServerConfig := fakefleet.DefaultConfig()
+	newServerConfig.TransitionDelay = *transitionDelay
+	newServerConfig.Logger = logging.NewLogger(loggingConfig)
+	newServer := fakefleet.NewServer(newServerConfig)
+
+	network, address := "tcp", *listen
+	if *socket != "" {
+		network, address = "unix", *socket
+		os.Remove(address)
+	}
+
+	l, err := net.Listen(network, address)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err := http.Serve(l, newServer); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
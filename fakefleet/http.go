@@ -0,0 +1,170 @@
+package fakefleet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/coreos/fleet/schema"
+	"golang.org/x/net/context"
+)
+
+const (
+	apiPrefix = "/fleet/v1/"
+)
+
+// errorResponse mirrors the error entity fleet sends, which is what the
+// googleapi package used by the fleet client parses.
+type errorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// ServeHTTP implements http.Handler. Paging is not simulated. All items are
+// always returned within the first page.
+func (s *Server) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	s.Config.Logger.Debug(context.Background(), "fakefleet: handling %s %s", req.Method, req.URL.Path)
+
+	if !strings.HasPrefix(req.URL.Path, apiPrefix) {
+		sendError(rw, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+	resource := strings.TrimPrefix(req.URL.Path, apiPrefix)
+
+	switch {
+	case resource == "units":
+		if req.Method != "GET" {
+			sendError(rw, http.StatusMethodNotAllowed, fmt.Errorf("only GET supported against this resource"))
+			return
+		}
+		sendResponse(rw, http.StatusOK, schema.UnitPage{Units: s.ListUnits()})
+	case strings.HasPrefix(resource, "units/"):
+		s.serveUnit(rw, req, strings.TrimPrefix(resource, "units/"))
+	case resource == "state":
+		if req.Method != "GET" {
+			sendError(rw, http.StatusMethodNotAllowed, fmt.Errorf("only GET supported against this resource"))
+			return
+		}
+		sendResponse(rw, http.StatusOK, schema.UnitStatePage{States: filterUnitStates(s.ListUnitStates(), req)})
+	case resource == "machines":
+		if req.Method != "GET" {
+			sendError(rw, http.StatusMethodNotAllowed, fmt.Errorf("only GET supported against this resource"))
+			return
+		}
+		sendResponse(rw, http.StatusOK, schema.MachinePage{Machines: s.ListMachines()})
+	default:
+		sendError(rw, http.StatusNotFound, fmt.Errorf("not found"))
+	}
+}
+
+func (s *Server) serveUnit(rw http.ResponseWriter, req *http.Request, name string) {
+	switch req.Method {
+	case "GET":
+		u, err := s.Unit(name)
+		if err != nil {
+			sendError(rw, statusCode(err), err)
+			return
+		}
+		sendResponse(rw, http.StatusOK, u)
+	case "PUT":
+		if contentType := strings.SplitN(req.Header.Get("Content-Type"), ";", 2)[0]; strings.TrimSpace(contentType) != "application/json" {
+			sendError(rw, http.StatusUnsupportedMediaType, fmt.Errorf("only acceptable Content-Type is application/json"))
+			return
+		}
+
+		var u schema.Unit
+		if err := json.NewDecoder(req.Body).Decode(&u); err != nil {
+			sendError(rw, http.StatusBadRequest, fmt.Errorf("unable to decode body: %v", err))
+			return
+		}
+		if u.Name == "" {
+			u.Name = name
+		}
+		if u.Name != name {
+			sendError(rw, http.StatusBadRequest, fmt.Errorf("name in URL %q differs from unit name in request body %q", name, u.Name))
+			return
+		}
+
+		_, err := s.Unit(name)
+		if IsUnitNotFound(err) {
+			if err := s.CreateUnit(&u); err != nil {
+				sendError(rw, statusCode(err), err)
+				return
+			}
+			rw.WriteHeader(http.StatusCreated)
+			return
+		}
+
+		if err := s.SetUnitTargetState(name, u.DesiredState); err != nil {
+			sendError(rw, statusCode(err), err)
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	case "DELETE":
+		if err := s.DestroyUnit(name); err != nil {
+			sendError(rw, statusCode(err), err)
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	default:
+		sendError(rw, http.StatusMethodNotAllowed, fmt.Errorf("only GET, PUT and DELETE supported against this resource"))
+	}
+}
+
+func filterUnitStates(unitStates []*schema.UnitState, req *http.Request) []*schema.UnitState {
+	machineID := req.URL.Query().Get("machineID")
+	unitName := req.URL.Query().Get("unitName")
+
+	var filtered []*schema.UnitState
+	for _, us := range unitStates {
+		if machineID != "" && us.MachineID != machineID {
+			continue
+		}
+		if unitName != "" && us.Name != unitName {
+			continue
+		}
+		filtered = append(filtered, us)
+	}
+
+	return filtered
+}
+
+func statusCode(err error) int {
+	switch {
+	case IsUnitNotFound(err):
+		return http.StatusNotFound
+	case IsInvalidUnit(err):
+		return http.StatusBadRequest
+	case IsUnitConflict(err):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func sendResponse(rw http.ResponseWriter, code int, resp interface{}) {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		sendError(rw, http.StatusInternalServerError, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(code)
+	rw.Write(b)
+}
+
+func sendError(rw http.ResponseWriter, code int, err error) {
+	var resp errorResponse
+	resp.Error.Code = code
+	resp.Error.Message = err.Error()
+
+	b, _ := json.Marshal(resp)
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(code)
+	rw.Write(b)
+}
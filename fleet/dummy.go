@@ -3,6 +3,7 @@ package fleet
 import (
 	"sync"
 
+	"github.com/coreos/fleet/unit"
 	"golang.org/x/net/context"
 
 	"github.com/giantswarm/inago/common"
@@ -18,9 +19,10 @@ type DummyConfig struct {
 // DummyFleet is an implementation of the Fleet interface,
 // that is primarily intended to be used for testing.
 type DummyFleet struct {
-	Config DummyConfig
-	Units  map[string]UnitStatus
-	Mutex  sync.Mutex
+	Config   DummyConfig
+	Units    map[string]UnitStatus
+	Contents map[string]string
+	Mutex    sync.Mutex
 }
 
 // DefaultDummyConfig returns a best-effort configuration for the DummyFleet struct.
@@ -33,8 +35,9 @@ func DefaultDummyConfig() DummyConfig {
 // NewDummyFleet returns a DummyFleet, given a DummyConfig.
 func NewDummyFleet(DummyConfig) *DummyFleet {
 	return &DummyFleet{
-		Config: DefaultDummyConfig(),
-		Units:  make(map[string]UnitStatus),
+		Config:   DefaultDummyConfig(),
+		Units:    make(map[string]UnitStatus),
+		Contents: make(map[string]string),
 	}
 }
 
@@ -51,17 +54,20 @@ func (f *DummyFleet) Submit(ctx context.Context, name, content string) error {
 	}
 
 	f.Units[name] = UnitStatus{
-		Current: unitStateLoaded,
-		Desired: unitStateLoaded,
-		Name:    name,
-		SliceID: sliceID,
+		Current:   unitStateLoaded,
+		Desired:   unitStateLoaded,
+		Name:      name,
+		SliceID:   sliceID,
+		SliceHash: dummySliceHash(content, sliceID),
 		Machine: []MachineStatus{
 			MachineStatus{
 				SystemdActive: "inactive",
 				SystemdSub:    "dead",
+				UnitHash:      dummyUnitHash(content),
 			},
 		},
 	}
+	f.Contents[name] = content
 
 	return nil
 }
@@ -86,6 +92,7 @@ func (f *DummyFleet) Start(ctx context.Context, name string) error {
 		MachineStatus{
 			SystemdActive: "active",
 			SystemdSub:    "running",
+			UnitHash:      dummyUnitHash(f.Contents[name]),
 		},
 	}
 
@@ -114,6 +121,7 @@ func (f *DummyFleet) Stop(ctx context.Context, name string) error {
 		MachineStatus{
 			SystemdActive: "inactive",
 			SystemdSub:    "running",
+			UnitHash:      dummyUnitHash(f.Contents[name]),
 		},
 	}
 
@@ -134,6 +142,7 @@ func (f *DummyFleet) Destroy(ctx context.Context, name string) error {
 	}
 
 	delete(f.Units, name)
+	delete(f.Contents, name)
 
 	return nil
 }
@@ -154,8 +163,8 @@ func (f *DummyFleet) GetStatus(ctx context.Context, name string) (UnitStatus, er
 }
 
 // GetStatusWithMatcher returns all UnitStatus that match.
-func (f *DummyFleet) GetStatusWithMatcher(m func(string) bool) ([]UnitStatus, error) {
-	f.Config.Logger.Debug(context.Background(), "dummy fleet: get status with matcher")
+func (f *DummyFleet) GetStatusWithMatcher(ctx context.Context, m func(string) bool) ([]UnitStatus, error) {
+	f.Config.Logger.Debug(ctx, "dummy fleet: get status with matcher")
 
 	f.Mutex.Lock()
 	defer f.Mutex.Unlock()
@@ -173,3 +182,42 @@ func (f *DummyFleet) GetStatusWithMatcher(m func(string) bool) ([]UnitStatus, er
 
 	return unitStatusList, nil
 }
+
+// GetUnitContent returns the content the unit with the given name was
+// submitted with.
+func (f *DummyFleet) GetUnitContent(ctx context.Context, name string) (string, error) {
+	f.Config.Logger.Debug(ctx, "dummy fleet: get unit content %v", name)
+
+	f.Mutex.Lock()
+	defer f.Mutex.Unlock()
+
+	content, ok := f.Contents[name]
+	if !ok {
+		return "", maskAny(unitNotFoundError)
+	}
+
+	return content, nil
+}
+
+// dummyUnitHash returns the hash fleet would report for a unit having the
+// given content. Content that cannot be parsed as unit file results in an
+// empty hash.
+func dummyUnitHash(content string) string {
+	unitFile, err := unit.NewUnitFile(content)
+	if err != nil {
+		return ""
+	}
+
+	return unitFile.Hash().String()
+}
+
+// dummySliceHash returns the slice hash fleet would report for a unit having
+// the given content. See also UnitStatus.SliceHash.
+func dummySliceHash(content, sliceID string) string {
+	unitFile, err := unit.NewUnitFile(content)
+	if err != nil {
+		return ""
+	}
+
+	return sliceHash(unitFile, sliceID)
+}
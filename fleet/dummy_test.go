@@ -114,11 +114,37 @@ func TestDummyFleet__Destroy(t *testing.T) {
 	}
 }
 
+// TestDummyFleet__GetUnitContent tests the DummyFleet GetUnitContent method.
+func TestDummyFleet__GetUnitContent(t *testing.T) {
+	dummyFleet := NewDummyFleet(DefaultDummyConfig())
+
+	if _, err := dummyFleet.GetUnitContent(context.Background(), UnitName); !IsUnitNotFound(err) {
+		t.Fatal("Unit not found err not returned")
+	}
+
+	dummyFleet.Submit(context.Background(), UnitName, UnitContent)
+
+	content, err := dummyFleet.GetUnitContent(context.Background(), UnitName)
+	if err != nil {
+		t.Fatal("Error getting test unit content:", err)
+	}
+	if content != UnitContent {
+		t.Fatal("Incorrect unit content:", content)
+	}
+
+	dummyFleet.Destroy(context.Background(), UnitName)
+
+	if _, err := dummyFleet.GetUnitContent(context.Background(), UnitName); !IsUnitNotFound(err) {
+		t.Fatal("Unit not found err not returned")
+	}
+}
+
 // TestDummyFleet__GetStatusWithMatcher tests the DummyFleet GetStatusWithMatcher method.
 func TestDummyFleet__GetStatusWithMatcher(t *testing.T) {
 	dummyFleet := NewDummyFleet(DefaultDummyConfig())
 
 	if _, err := dummyFleet.GetStatusWithMatcher(
+		context.Background(),
 		func(s string) bool { return true },
 	); !IsUnitNotFound(err) {
 		t.Fatal("Unit not found err not returned")
@@ -127,6 +153,7 @@ func TestDummyFleet__GetStatusWithMatcher(t *testing.T) {
 	dummyFleet.Submit(context.Background(), UnitName, UnitContent)
 
 	submitUnitStatusList, err := dummyFleet.GetStatusWithMatcher(
+		context.Background(),
 		func(s string) bool { return s == UnitName },
 	)
 	if err != nil {
@@ -137,6 +164,7 @@ func TestDummyFleet__GetStatusWithMatcher(t *testing.T) {
 	}
 
 	incorrectUnitStatusList, err := dummyFleet.GetStatusWithMatcher(
+		context.Background(),
 		func(s string) bool { return s != UnitName },
 	)
 	if err != nil {
@@ -149,6 +177,7 @@ func TestDummyFleet__GetStatusWithMatcher(t *testing.T) {
 	dummyFleet.Submit(context.Background(), "another-unit.service", UnitContent)
 
 	multipleUnitStatusList, err := dummyFleet.GetStatusWithMatcher(
+		context.Background(),
 		func(s string) bool { return s == UnitName },
 	)
 	if err != nil {
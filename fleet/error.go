@@ -2,6 +2,10 @@ package fleet
 
 import (
 	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"regexp"
 
 	"github.com/juju/errgo"
 )
@@ -66,3 +70,73 @@ var invalidEndpointError = errgo.Newf("invalid endpoint")
 func IsInvalidEndpoint(err error) bool {
 	return errgo.Cause(err) == invalidEndpointError
 }
+
+var invalidConfigError = errgo.New("invalid config")
+
+// IsInvalidConfig checks whether the given error indicates the problem of an
+// invalid configuration being given.
+func IsInvalidConfig(err error) bool {
+	return errgo.Cause(err) == invalidConfigError
+}
+
+var sshTimeoutError = errgo.New("ssh timeout")
+
+// IsSSHTimeout checks whether the given error indicates the problem of an ssh
+// connection not being established in time.
+func IsSSHTimeout(err error) bool {
+	return errgo.Cause(err) == sshTimeoutError
+}
+
+// apiStatusExp matches the HTTP status code within error messages of the fleet
+// API client. The client's error type lives in a package that is vendored by
+// fleet, so we cannot assert the type itself.
+var apiStatusExp = regexp.MustCompile(`googleapi: (?:Error|got HTTP response code) (\d{3})`)
+
+// isAPINotFound checks whether the given error is a response of the fleet API
+// having the HTTP status code 404.
+func isAPINotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	found := apiStatusExp.FindStringSubmatch(errgo.Cause(err).Error())
+	return found != nil && found[1] == "404"
+}
+
+// IsRetryable checks whether the given error is worth retrying the operation
+// that caused it. This is the case for transient errors of the underlying
+// transport, e.g. a refused or reset connection, a timeout or a broken SSH
+// tunnel, and for server errors of the fleet API, which are responded with a
+// HTTP status code of 5xx. Errors that would occur again on each attempt, e.g.
+// failing to verify the certificate of an https endpoint, are not retryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	cause := errgo.Cause(err)
+	if urlErr, ok := cause.(*url.Error); ok {
+		cause = errgo.Cause(urlErr.Err)
+	}
+
+	if cause == io.EOF || cause == io.ErrUnexpectedEOF || cause == sshTimeoutError {
+		return true
+	}
+	switch e := cause.(type) {
+	case *net.OpError:
+		if dnsErr, ok := e.Err.(*net.DNSError); ok {
+			return dnsErr.Timeout() || dnsErr.Temporary()
+		}
+		// TLS alerts are reported as operation errors as well. They are not
+		// transient.
+		return e.Op == "dial" || e.Op == "read" || e.Op == "write"
+	case net.Error:
+		return e.Timeout()
+	}
+
+	if found := apiStatusExp.FindStringSubmatch(cause.Error()); found != nil {
+		return found[1][0] == '5'
+	}
+
+	return false
+}
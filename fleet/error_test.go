@@ -1,7 +1,12 @@
 package fleet
 
 import (
+	"crypto/x509"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"syscall"
 	"testing"
 )
 
@@ -83,6 +88,22 @@ func Test_Fleet_errors(t *testing.T) {
 			Output:   IsInvalidEndpoint(invalidUnitStatusError),
 			Expected: false,
 		},
+		{
+			Output:   IsInvalidConfig(invalidConfigError),
+			Expected: true,
+		},
+		{
+			Output:   IsInvalidConfig(sshTimeoutError),
+			Expected: false,
+		},
+		{
+			Output:   IsSSHTimeout(sshTimeoutError),
+			Expected: true,
+		},
+		{
+			Output:   IsSSHTimeout(invalidEndpointError),
+			Expected: false,
+		},
 	}
 
 	for i, testCase := range testCases {
@@ -91,3 +112,90 @@ func Test_Fleet_errors(t *testing.T) {
 		}
 	}
 }
+
+func Test_Fleet_IsRetryable(t *testing.T) {
+	testCases := []struct {
+		Input    error
+		Expected bool
+	}{
+		{
+			Input:    nil,
+			Expected: false,
+		},
+		{
+			Input:    unitNotFoundError,
+			Expected: false,
+		},
+		{
+			Input:    fmt.Errorf("some error"),
+			Expected: false,
+		},
+		{
+			Input:    io.EOF,
+			Expected: true,
+		},
+		{
+			Input:    maskAny(io.ErrUnexpectedEOF),
+			Expected: true,
+		},
+		{
+			Input:    &url.Error{Op: "Get", URL: "http://domain-sock/fleet/v1/units", Err: io.EOF},
+			Expected: true,
+		},
+		{
+			Input:    maskAny(&url.Error{Op: "Get", URL: "http://domain-sock/fleet/v1/units", Err: fmt.Errorf("ssh: rejected")}),
+			Expected: false,
+		},
+		{
+			Input:    &url.Error{Op: "Get", URL: "http://domain-sock/fleet/v1/units", Err: maskAny(sshTimeoutError)},
+			Expected: true,
+		},
+		{
+			Input:    &url.Error{Op: "Get", URL: "http://127.0.0.1:49153/fleet/v1/units", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}},
+			Expected: true,
+		},
+		{
+			Input:    &url.Error{Op: "Get", URL: "http://127.0.0.1:49153/fleet/v1/units", Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}},
+			Expected: true,
+		},
+		{
+			Input:    &url.Error{Op: "Get", URL: "https://127.0.0.1:49153/fleet/v1/units", Err: &net.OpError{Op: "remote error", Err: fmt.Errorf("tls: bad certificate")}},
+			Expected: false,
+		},
+		{
+			Input:    &url.Error{Op: "Get", URL: "https://127.0.0.1:49153/fleet/v1/units", Err: x509.UnknownAuthorityError{}},
+			Expected: false,
+		},
+		{
+			Input:    &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "fleet.invalid"}},
+			Expected: false,
+		},
+		{
+			Input:    &net.DNSError{Err: "i/o timeout", Name: "fleet.example.com", IsTimeout: true},
+			Expected: true,
+		},
+		{
+			Input:    fmt.Errorf("googleapi: Error 503: service unavailable"),
+			Expected: true,
+		},
+		{
+			Input:    fmt.Errorf("googleapi: got HTTP response code 502 with body: bad gateway"),
+			Expected: true,
+		},
+		{
+			Input:    fmt.Errorf("googleapi: Error 409: unit already exists"),
+			Expected: false,
+		},
+		{
+			Input:    fmt.Errorf("googleapi: got HTTP response code 404 with body: not found"),
+			Expected: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		output := IsRetryable(testCase.Input)
+		if output != testCase.Expected {
+			t.Fatalf("test case %d: expected %t got %t", i+1, testCase.Expected, output)
+		}
+	}
+}
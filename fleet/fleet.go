@@ -7,7 +7,9 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/coreos/fleet/client"
 	"github.com/coreos/fleet/machine"
@@ -17,6 +19,7 @@ import (
 
 	"github.com/giantswarm/inago/common"
 	"github.com/giantswarm/inago/logging"
+	"github.com/giantswarm/inago/retry"
 )
 
 const (
@@ -28,12 +31,33 @@ const (
 // Config provides all necessary and injectable configurations for a new
 // fleet client.
 type Config struct {
-	Client    *http.Client
-	Endpoint  url.URL
+	// CAFile is the path of a PEM encoded CA certificate bundle used to verify
+	// https endpoints. The CAs of the system are used in case it is empty.
+	CAFile string
+
+	// CertFile is the path of a PEM encoded client certificate presented to
+	// https endpoints. It must be given together with KeyFile.
+	CertFile string
+
+	Client   *http.Client
+	Endpoint url.URL
+
+	// InsecureSkipVerify disables verifying the certificate of https
+	// endpoints.
+	InsecureSkipVerify bool
+
+	// KeyFile is the path of the PEM encoded private key of CertFile.
+	KeyFile string
+
 	SSHTunnel SSHTunnel
 
 	// Logger provides an initialised logger.
 	Logger logging.Logger
+
+	// RetryPolicy is used to execute each request against the fleet API.
+	// Requests failing with errors identified by IsRetryable are retried. In
+	// case it is nil, requests are not retried.
+	RetryPolicy retry.Policy
 }
 
 // DefaultConfig provides a set of configurations with default values by best
@@ -44,11 +68,21 @@ func DefaultConfig() Config {
 		panic(err)
 	}
 
+	newRetryPolicyConfig := retry.DefaultConfig()
+	newRetryPolicyConfig.InitialBackoff = 100 * time.Millisecond
+	newRetryPolicyConfig.MaxBackoff = 2 * time.Second
+	newRetryPolicyConfig.IsRetryable = IsRetryable
+
 	newConfig := Config{
-		Client:    &http.Client{},
-		Endpoint:  *URL,
-		Logger:    logging.NewLogger(logging.DefaultConfig()),
-		SSHTunnel: nil,
+		CAFile:             "",
+		CertFile:           "",
+		Client:             &http.Client{},
+		Endpoint:           *URL,
+		InsecureSkipVerify: false,
+		KeyFile:            "",
+		Logger:             logging.NewLogger(logging.DefaultConfig()),
+		RetryPolicy:        retry.NewPolicy(newRetryPolicyConfig),
+		SSHTunnel:          nil,
 	}
 
 	return newConfig
@@ -93,6 +127,13 @@ type UnitStatus struct {
 
 	// Slice represents the slice ID. E.g. 1, or foo, or 5., etc..
 	SliceID string
+
+	// SliceHash represents a token to identify the content of the unit file
+	// regardless of the slice it is rendered for. Each occurrence of SliceID as
+	// a whole word is replaced before hashing. Thus units rendered from the
+	// same template for different slices have the same SliceHash. It is empty
+	// in case the content of the unit is unknown.
+	SliceHash string
 }
 
 // Fleet defines the interface a fleet client needs to implement to provide
@@ -111,7 +152,8 @@ type Fleet interface {
 	Stop(ctx context.Context, name string) error
 
 	// Destroy delets a unit on the configured fleet cluster. This is done by
-	// setting the unit's target state to inactive.
+	// setting the unit's target state to inactive. If the unit cannot be found,
+	// an error that you can identify using IsUnitNotFound is returned.
 	Destroy(ctx context.Context, name string) error
 
 	// GetStatus fetches the current status of a unit. If the unit cannot be
@@ -120,7 +162,12 @@ type Fleet interface {
 
 	// GetStatusWithMatcher returns a []UnitStatus, with an element for
 	// each unit where the given matcher returns true.
-	GetStatusWithMatcher(func(string) bool) ([]UnitStatus, error)
+	GetStatusWithMatcher(ctx context.Context, matcher func(string) bool) ([]UnitStatus, error)
+
+	// GetUnitContent fetches the content of the unit file fleet knows under the
+	// given name. If the unit cannot be found, an error that you can identify
+	// using IsUnitNotFound is returned.
+	GetUnitContent(ctx context.Context, name string) (string, error)
 }
 
 // NewFleet creates a new Fleet that is configured with the given settings.
@@ -130,8 +177,23 @@ type Fleet interface {
 //   newFleet := fleet.NewFleet(newConfig)
 //
 func NewFleet(config Config) (Fleet, error) {
+	if config.RetryPolicy == nil {
+		config.RetryPolicy = retry.NewNoRetryPolicy()
+	}
+
 	var trans http.RoundTripper
 
+	// TLS options only apply to https endpoints. Silently ignoring them would
+	// let users believe their connection to fleet is verified.
+	if usesTLSConfig(config) {
+		if config.SSHTunnel != nil && config.SSHTunnel.IsActive() {
+			return nil, maskAnyf(invalidConfigError, "TLS options cannot be used with a tunnel")
+		}
+		if config.Endpoint.Scheme != "https" {
+			return nil, maskAnyf(invalidConfigError, "TLS options cannot be used with scheme %q", config.Endpoint.Scheme)
+		}
+	}
+
 	// If a tunnel is provided we need to overwrite the http.Transport.Dial function
 	// to use the tunnel
 	if config.SSHTunnel != nil && config.SSHTunnel.IsActive() {
@@ -160,7 +222,16 @@ func NewFleet(config Config) (Fleet, error) {
 					return net.Dial("unix", sockPath)
 				},
 			}
-		case "http", "https":
+		case "https":
+			trans = http.DefaultTransport
+			if usesTLSConfig(config) {
+				var err error
+				trans, err = newTLSTransport(config)
+				if err != nil {
+					return nil, maskAny(err)
+				}
+			}
+		case "http":
 			trans = http.DefaultTransport
 		default:
 			return nil, maskAnyf(invalidEndpointError, "invalid scheme %q", config.Endpoint.Scheme)
@@ -200,7 +271,24 @@ func (f fleet) Submit(ctx context.Context, name, content string) error {
 		DesiredState: "loaded",
 	}
 
-	err = f.Client.CreateUnit(unit)
+	attempted := false
+	err = f.Config.RetryPolicy.Execute(ctx, func() error {
+		if attempted {
+			// Creating a unit is not idempotent. The failed attempt might have
+			// reached fleet nevertheless. In this case the unit already exists
+			// and creating it again would fail.
+			existing, err := f.Client.Unit(name)
+			if err != nil {
+				return err
+			}
+			if existing != nil && schema.MapSchemaUnitOptionsToUnitFile(existing.Options).Hash() == unitFile.Hash() {
+				return nil
+			}
+		}
+		attempted = true
+
+		return f.Client.CreateUnit(unit)
+	})
 	if err != nil {
 		return maskAny(err)
 	}
@@ -211,7 +299,9 @@ func (f fleet) Submit(ctx context.Context, name, content string) error {
 func (f fleet) Start(ctx context.Context, name string) error {
 	f.Config.Logger.Debug(ctx, "fleet: starting unit '%v'", name)
 
-	err := f.Client.SetUnitTargetState(name, unitStateLaunched)
+	err := f.Config.RetryPolicy.Execute(ctx, func() error {
+		return f.Client.SetUnitTargetState(name, unitStateLaunched)
+	})
 	if err != nil {
 		return maskAny(err)
 	}
@@ -222,7 +312,9 @@ func (f fleet) Start(ctx context.Context, name string) error {
 func (f fleet) Stop(ctx context.Context, name string) error {
 	f.Config.Logger.Debug(ctx, "fleet: stopping unit '%v'", name)
 
-	err := f.Client.SetUnitTargetState(name, unitStateLoaded)
+	err := f.Config.RetryPolicy.Execute(ctx, func() error {
+		return f.Client.SetUnitTargetState(name, unitStateLoaded)
+	})
 	if err != nil {
 		return maskAny(err)
 	}
@@ -233,7 +325,22 @@ func (f fleet) Stop(ctx context.Context, name string) error {
 func (f fleet) Destroy(ctx context.Context, name string) error {
 	f.Config.Logger.Debug(ctx, "fleet: destroying unit '%v'", name)
 
-	err := f.Client.DestroyUnit(name)
+	attempted := false
+	err := f.Config.RetryPolicy.Execute(ctx, func() error {
+		err := f.Client.DestroyUnit(name)
+		if isAPINotFound(err) {
+			if attempted {
+				// Destroying a unit is not idempotent. The failed attempt might
+				// have reached fleet nevertheless. In this case the unit is gone
+				// already.
+				return nil
+			}
+			return maskAnyf(unitNotFoundError, "%s", name)
+		}
+		attempted = true
+
+		return err
+	})
 	if err != nil {
 		return maskAny(err)
 	}
@@ -247,7 +354,7 @@ func (f fleet) GetStatus(ctx context.Context, name string) (UnitStatus, error) {
 	matcher := func(s string) bool {
 		return name == s
 	}
-	unitStatus, err := f.GetStatusWithMatcher(matcher)
+	unitStatus, err := f.GetStatusWithMatcher(ctx, matcher)
 	if err != nil {
 		return UnitStatus{}, maskAny(err)
 	}
@@ -261,9 +368,14 @@ func (f fleet) GetStatus(ctx context.Context, name string) (UnitStatus, error) {
 
 // GetStatusWithMatcher returns a []UnitStatus, with an element for
 // each unit where the given matcher returns true.
-func (f fleet) GetStatusWithMatcher(matcher func(s string) bool) ([]UnitStatus, error) {
+func (f fleet) GetStatusWithMatcher(ctx context.Context, matcher func(s string) bool) ([]UnitStatus, error) {
 	// Lookup fleet cluster state.
-	fleetUnits, err := f.Client.Units()
+	var fleetUnits []*schema.Unit
+	err := f.Config.RetryPolicy.Execute(ctx, func() error {
+		var err error
+		fleetUnits, err = f.Client.Units()
+		return err
+	})
 	if err != nil {
 		return []UnitStatus{}, maskAny(err)
 	}
@@ -280,7 +392,12 @@ func (f fleet) GetStatusWithMatcher(matcher func(s string) bool) ([]UnitStatus,
 	}
 
 	// Lookup machine states.
-	fleetUnitStates, err := f.Client.UnitStates()
+	var fleetUnitStates []*schema.UnitState
+	err = f.Config.RetryPolicy.Execute(ctx, func() error {
+		var err error
+		fleetUnitStates, err = f.Client.UnitStates()
+		return err
+	})
 	if err != nil {
 		return []UnitStatus{}, maskAny(err)
 	}
@@ -292,7 +409,12 @@ func (f fleet) GetStatusWithMatcher(matcher func(s string) bool) ([]UnitStatus,
 	}
 
 	// Lookup machines
-	machineStates, err := f.Client.Machines()
+	var machineStates []machine.MachineState
+	err = f.Config.RetryPolicy.Execute(ctx, func() error {
+		var err error
+		machineStates, err = f.Client.Machines()
+		return err
+	})
 	if err != nil {
 		return nil, maskAny(err)
 	}
@@ -306,6 +428,29 @@ func (f fleet) GetStatusWithMatcher(matcher func(s string) bool) ([]UnitStatus,
 	return ourStatusList, nil
 }
 
+func (f fleet) GetUnitContent(ctx context.Context, name string) (string, error) {
+	f.Config.Logger.Debug(ctx, "fleet: getting content of unit '%v'", name)
+
+	var fleetUnit *schema.Unit
+	err := f.Config.RetryPolicy.Execute(ctx, func() error {
+		var err error
+		fleetUnit, err = f.Client.Unit(name)
+		return err
+	})
+	if err != nil {
+		return "", maskAny(err)
+	}
+	if fleetUnit == nil {
+		// The fleet client does not return an error when the unit does not
+		// exist. It simply returns nil.
+		return "", maskAnyf(unitNotFoundError, "%s", name)
+	}
+
+	unitFile := schema.MapSchemaUnitOptionsToUnitFile(fleetUnit.Options)
+
+	return unitFile.String(), nil
+}
+
 func ipFromUnitState(unitState *schema.UnitState, machineStates []machine.MachineState) (net.IP, error) {
 	for _, ms := range machineStates {
 		if unitState.MachineID == ms.ID {
@@ -332,6 +477,9 @@ func mapFleetStateToUnitStatusList(foundFleetUnits []*schema.Unit, foundFleetUni
 			Name:    ffu.Name,
 			SliceID: ID,
 		}
+		if len(ffu.Options) > 0 {
+			ourUnitStatus.SliceHash = sliceHash(schema.MapSchemaUnitOptionsToUnitFile(ffu.Options), ID)
+		}
 
 		// FLEET-WEIRDNESS: In case of global units, the CurrentState seems to be always "inactive"
 		// To make the output a bit nicer, we overwrite it with DesiredState
@@ -363,6 +511,29 @@ func mapFleetStateToUnitStatusList(foundFleetUnits []*schema.Unit, foundFleetUni
 	return ourStatusList, nil
 }
 
+// sliceIDPlaceholder replaces the slice ID within unit contents hashed by
+// sliceHash. Unit files never contain NUL bytes, so the placeholder cannot
+// clash with their contents.
+const sliceIDPlaceholder = "\x00slice-id\x00"
+
+// sliceHash returns the hash of the given unit file having each occurrence of
+// the given slice ID as a whole word replaced. Unrelated words equal to the
+// slice ID, like the "1" of "--workers=1" in case of slice "1", are replaced
+// as well. See also UnitStatus.SliceHash.
+func sliceHash(unitFile *unit.UnitFile, sliceID string) string {
+	if sliceID == "" {
+		return unitFile.Hash().String()
+	}
+
+	sliceIDExp := regexp.MustCompile(`\b` + regexp.QuoteMeta(sliceID) + `\b`)
+	normalized, err := unit.NewUnitFile(sliceIDExp.ReplaceAllLiteralString(unitFile.String(), sliceIDPlaceholder))
+	if err != nil {
+		return ""
+	}
+
+	return normalized.Hash().String()
+}
+
 func isFleetGlobalUnit(options []*schema.UnitOption) bool {
 	for _, option := range options {
 		if strings.EqualFold(option.Section, "X-Fleet") &&
@@ -1,16 +1,22 @@
 package fleet
 
 import (
+	"errors"
+	"io"
 	"net"
 	"reflect"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"golang.org/x/net/context"
 
 	"github.com/coreos/fleet/machine"
 	"github.com/coreos/fleet/schema"
+	"github.com/coreos/fleet/unit"
 	"github.com/stretchr/testify/mock"
+
+	"github.com/giantswarm/inago/retry"
 )
 
 // Test_Fleet_DefaultConfig_Success verifies that the default config contains a
@@ -84,6 +90,15 @@ func givenMockedFleet() (*fleetClientMock, *fleet) {
 	}
 }
 
+// givenRetryPolicy returns a retry policy retrying quickly.
+func givenRetryPolicy() retry.Policy {
+	newRetryPolicyConfig := retry.DefaultConfig()
+	newRetryPolicyConfig.InitialBackoff = 1 * time.Millisecond
+	newRetryPolicyConfig.IsRetryable = IsRetryable
+
+	return retry.NewPolicy(newRetryPolicyConfig)
+}
+
 func givenMockedFleetWithMachines(machines []machine.MachineState) (*fleetClientMock, *fleet) {
 	fleetClientMock, fleet := givenMockedFleet()
 	fleetClientMock.On("Machines").Return(machines, nil)
@@ -112,6 +127,69 @@ func TestFleetSubmit_Success(t *testing.T) {
 	)
 }
 
+// TestFleetSubmit_RetryCreated verifies that a unit being created by an
+// attempt that failed nevertheless is not created again.
+func TestFleetSubmit_RetryCreated(t *testing.T) {
+	RegisterTestingT(t)
+
+	content := "[Service]\nExecStart=/bin/echo Hello World!\n"
+	unitFile, err := unit.NewUnitFile(content)
+	Expect(err).To(Not(HaveOccurred()))
+
+	fleetClientMock, fleet := givenMockedFleet()
+	fleet.Config.RetryPolicy = givenRetryPolicy()
+	fleetClientMock.On("CreateUnit", mock.AnythingOfType("*schema.Unit")).Once().Return(io.EOF)
+	fleetClientMock.On("Unit", "unit.service").Once().Return(&schema.Unit{
+		Name:    "unit.service",
+		Options: schema.MapUnitFileToSchemaUnitOptions(unitFile),
+	}, nil)
+
+	err = fleet.Submit(context.Background(), "unit.service", content)
+
+	Expect(err).To(Not(HaveOccurred()))
+	fleetClientMock.AssertExpectations(t)
+	fleetClientMock.AssertNumberOfCalls(t, "CreateUnit", 1)
+}
+
+// TestFleetSubmit_RetryNotCreated verifies that a unit not being created by a
+// failed attempt is created by the next attempt.
+func TestFleetSubmit_RetryNotCreated(t *testing.T) {
+	RegisterTestingT(t)
+
+	fleetClientMock, fleet := givenMockedFleet()
+	fleet.Config.RetryPolicy = givenRetryPolicy()
+	fleetClientMock.On("CreateUnit", mock.AnythingOfType("*schema.Unit")).Once().Return(io.EOF)
+	fleetClientMock.On("CreateUnit", mock.AnythingOfType("*schema.Unit")).Once().Return(nil)
+	fleetClientMock.On("Unit", "unit.service").Once().Return((*schema.Unit)(nil), nil)
+
+	err := fleet.Submit(context.Background(), "unit.service", "[Service]\nExecStart=/bin/echo Hello World!\n")
+
+	Expect(err).To(Not(HaveOccurred()))
+	fleetClientMock.AssertExpectations(t)
+}
+
+// TestNewFleet_NilRetryPolicy verifies that a fleet client created without
+// retry policy does not retry requests.
+func TestNewFleet_NilRetryPolicy(t *testing.T) {
+	RegisterTestingT(t)
+
+	cfg := DefaultConfig()
+	cfg.RetryPolicy = nil
+
+	newFleet, err := NewFleet(cfg)
+	Expect(err).To(BeNil())
+
+	fleetClientMock := &fleetClientMock{}
+	f := newFleet.(fleet)
+	f.Client = fleetClientMock
+	fleetClientMock.On("SetUnitTargetState", "unit.service", unitStateLaunched).Once().Return(io.EOF)
+
+	err = f.Start(context.Background(), "unit.service")
+
+	Expect(err).To(HaveOccurred())
+	fleetClientMock.AssertExpectations(t)
+}
+
 func TestFleetStart_Success(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -146,6 +224,70 @@ func TestFleetDestroy_Success(t *testing.T) {
 	mock.AssertExpectations(t)
 }
 
+// TestFleetDestroy_NotFound verifies that destroying a unit fleet does not know
+// results in an error that can be identified using IsUnitNotFound.
+func TestFleetDestroy_NotFound(t *testing.T) {
+	RegisterTestingT(t)
+
+	mock, fleet := givenMockedFleet()
+	fleet.Config.RetryPolicy = givenRetryPolicy()
+	mock.On("DestroyUnit", "unit.service").Once().Return(errors.New("googleapi: Error 404: unit does not exist"))
+	err := fleet.Destroy(context.Background(), "unit.service")
+
+	Expect(IsUnitNotFound(err)).To(BeTrue())
+	mock.AssertExpectations(t)
+}
+
+// TestFleetDestroy_RetryDestroyed verifies that a unit being destroyed by an
+// attempt that failed nevertheless is considered destroyed.
+func TestFleetDestroy_RetryDestroyed(t *testing.T) {
+	RegisterTestingT(t)
+
+	mock, fleet := givenMockedFleet()
+	fleet.Config.RetryPolicy = givenRetryPolicy()
+	mock.On("DestroyUnit", "unit.service").Once().Return(io.EOF)
+	mock.On("DestroyUnit", "unit.service").Once().Return(errors.New("googleapi: Error 404: unit does not exist"))
+	err := fleet.Destroy(context.Background(), "unit.service")
+
+	Expect(err).To(Not(HaveOccurred()))
+	mock.AssertExpectations(t)
+}
+
+func TestFleetGetUnitContent_Success(t *testing.T) {
+	RegisterTestingT(t)
+
+	mock, fleet := givenMockedFleet()
+	mock.On("Unit", "unit.service").Once().Return(&schema.Unit{
+		Name: "unit.service",
+		Options: []*schema.UnitOption{
+			{Section: "Unit", Name: "Description", Value: "This is a test unit"},
+			{Section: "Service", Name: "ExecStart", Value: "/bin/echo Hello World!"},
+		},
+	}, nil)
+
+	content, err := fleet.GetUnitContent(context.Background(), "unit.service")
+
+	Expect(err).To(Not(HaveOccurred()))
+	Expect(content).To(Equal("[Unit]\n" +
+		"Description=This is a test unit\n" +
+		"\n" +
+		"[Service]\n" +
+		"ExecStart=/bin/echo Hello World!\n"))
+	mock.AssertExpectations(t)
+}
+
+func TestFleetGetUnitContent_NotFound(t *testing.T) {
+	RegisterTestingT(t)
+
+	mock, fleet := givenMockedFleet()
+	mock.On("Unit", "unit.service").Once().Return((*schema.Unit)(nil), nil)
+
+	_, err := fleet.GetUnitContent(context.Background(), "unit.service")
+
+	Expect(IsUnitNotFound(err)).To(BeTrue())
+	mock.AssertExpectations(t)
+}
+
 func TestFleetGetStatusWithMatcher__Success(t *testing.T) {
 	machineID := "12345"
 	machineIP := "10.0.0.100"
@@ -176,7 +318,7 @@ func TestFleetGetStatusWithMatcher__Success(t *testing.T) {
 	matcher := func(s string) bool {
 		return s == "unit.service"
 	}
-	status, err := fleet.GetStatusWithMatcher(matcher)
+	status, err := fleet.GetStatusWithMatcher(context.Background(), matcher)
 
 	// Assertion
 	Expect(err).To(Not(HaveOccurred()))
@@ -323,7 +465,8 @@ func Test_Fleet_mapFleetStateToUnitStatusList(t *testing.T) {
 							UnitHash:      "1234",
 						},
 					},
-					Name: "name-1",
+					Name:      "name-1",
+					SliceHash: "b581c67253dc7becdf15e007fb3b349b09009fbc",
 				},
 			},
 		},
@@ -340,3 +483,33 @@ func Test_Fleet_mapFleetStateToUnitStatusList(t *testing.T) {
 		}
 	}
 }
+
+// Test_Fleet_sliceHash tests that units rendered for different slices have
+// the same slice hash.
+func Test_Fleet_sliceHash(t *testing.T) {
+	newUnitFile := func(content string) *unit.UnitFile {
+		unitFile, err := unit.NewUnitFile(content)
+		if err != nil {
+			t.Fatal("Error returned parsing unit file:", err)
+		}
+		return unitFile
+	}
+
+	hash1 := sliceHash(newUnitFile("[Service]\nExecStart=/bin/app --name=app-1\n"), "1")
+	hash2 := sliceHash(newUnitFile("[Service]\nExecStart=/bin/app --name=app-2\n"), "2")
+	if hash1 != hash2 {
+		t.Fatalf("Expected slice hashes to be equal, got '%s' and '%s'", hash1, hash2)
+	}
+
+	// Slice IDs being part of other words are not replaced.
+	hash3 := sliceHash(newUnitFile("[Service]\nExecStart=/bin/app --name=app-12\n"), "1")
+	if hash1 == hash3 {
+		t.Fatal("Expected slice hashes to differ")
+	}
+
+	// Unsliced units have their unit hash as slice hash.
+	unitFile := newUnitFile("[Service]\nExecStart=/bin/app\n")
+	if hash := sliceHash(unitFile, ""); hash != unitFile.Hash().String() {
+		t.Fatalf("Expected slice hash '%s', got '%s'", unitFile.Hash().String(), hash)
+	}
+}
@@ -0,0 +1,306 @@
+package fleet
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/coreos/fleet/unit"
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/common"
+	"github.com/giantswarm/inago/logging"
+)
+
+// PlanConfig holds configuration for the PlanFleet struct.
+type PlanConfig struct {
+	// Fleet is the fleet client used to look up the current state of the fleet
+	// cluster. Operations changing the fleet cluster are never forwarded to it.
+	Fleet Fleet
+
+	// Logger provides an initialised logger.
+	Logger logging.Logger
+}
+
+// DefaultPlanConfig returns a best-effort configuration for the PlanFleet
+// struct.
+func DefaultPlanConfig() PlanConfig {
+	newFleet, err := NewFleet(DefaultConfig())
+	if err != nil {
+		panic(err)
+	}
+
+	return PlanConfig{
+		Fleet:  newFleet,
+		Logger: logging.NewLogger(logging.DefaultConfig()),
+	}
+}
+
+// Operation represents a single operation changing the state of a unit
+// within the fleet cluster.
+type Operation struct {
+	// Action is one of "submit", "start", "stop" or "destroy".
+	Action string
+
+	// Name represents the unit file name the operation is executed against.
+	Name string
+
+	// TargetState represents the fleet target state the unit is set to by the
+	// operation.
+	TargetState string
+}
+
+// String returns a human readable representation of the operation, e.g.
+// "start app@1.service (target state: launched)".
+func (o Operation) String() string {
+	return fmt.Sprintf("%s %s (target state: %s)", o.Action, o.Name, o.TargetState)
+}
+
+// PlanFleet is an implementation of the Fleet interface that records all
+// operations changing the state of the fleet cluster instead of executing
+// them. The effects of recorded operations are simulated in memory on top of
+// the current state of the fleet cluster, so that callers waiting for units
+// to reach a certain state behave as they would against the real cluster.
+// This is used to plan the operations a controller would execute.
+type PlanFleet struct {
+	Config PlanConfig
+
+	// units holds the simulated status of all units touched by recorded
+	// operations.
+	units map[string]UnitStatus
+
+	// contents holds the content of all units submitted by recorded
+	// operations.
+	contents map[string]string
+
+	// destroyed holds the names of all units destroyed by recorded operations.
+	destroyed map[string]bool
+
+	operations []Operation
+	mutex      sync.Mutex
+}
+
+// NewPlanFleet returns a PlanFleet, given a PlanConfig.
+func NewPlanFleet(config PlanConfig) *PlanFleet {
+	return &PlanFleet{
+		Config:    config,
+		units:     map[string]UnitStatus{},
+		contents:  map[string]string{},
+		destroyed: map[string]bool{},
+	}
+}
+
+// Flush returns all operations recorded since the last call to Flush, in the
+// order they were recorded.
+func (f *PlanFleet) Flush() []Operation {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	operations := f.operations
+	f.operations = nil
+
+	return operations
+}
+
+// Submit records the submission of the given unit.
+func (f *PlanFleet) Submit(ctx context.Context, name, content string) error {
+	f.Config.Logger.Debug(ctx, "plan fleet: submit %v", name)
+
+	unitFile, err := unit.NewUnitFile(content)
+	if err != nil {
+		return maskAny(err)
+	}
+	sliceID, err := common.SliceID(name)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.units[name] = UnitStatus{
+		Current:   unitStateLoaded,
+		Desired:   unitStateLoaded,
+		Name:      name,
+		SliceID:   sliceID,
+		SliceHash: sliceHash(unitFile, sliceID),
+		Machine: []MachineStatus{
+			MachineStatus{
+				SystemdActive: "inactive",
+				SystemdSub:    "dead",
+				UnitHash:      unitFile.Hash().String(),
+			},
+		},
+	}
+	f.contents[name] = content
+	delete(f.destroyed, name)
+	f.record("submit", name, unitStateLoaded)
+
+	return nil
+}
+
+// Start records setting the target state of the given unit to launched.
+func (f *PlanFleet) Start(ctx context.Context, name string) error {
+	f.Config.Logger.Debug(ctx, "plan fleet: start %v", name)
+
+	err := f.setState(ctx, "start", name, unitStateLaunched, "active", "running")
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// Stop records setting the target state of the given unit to loaded.
+func (f *PlanFleet) Stop(ctx context.Context, name string) error {
+	f.Config.Logger.Debug(ctx, "plan fleet: stop %v", name)
+
+	err := f.setState(ctx, "stop", name, unitStateLoaded, "inactive", "dead")
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+// Destroy records the removal of the given unit.
+func (f *PlanFleet) Destroy(ctx context.Context, name string) error {
+	f.Config.Logger.Debug(ctx, "plan fleet: destroy %v", name)
+
+	_, err := f.GetStatus(ctx, name)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	delete(f.units, name)
+	delete(f.contents, name)
+	f.destroyed[name] = true
+	f.record("destroy", name, unitStateInactive)
+
+	return nil
+}
+
+// GetStatus returns the simulated status of the given unit.
+func (f *PlanFleet) GetStatus(ctx context.Context, name string) (UnitStatus, error) {
+	f.Config.Logger.Debug(ctx, "plan fleet: get status %v", name)
+
+	matcher := func(s string) bool {
+		return name == s
+	}
+	unitStatusList, err := f.GetStatusWithMatcher(ctx, matcher)
+	if err != nil {
+		return UnitStatus{}, maskAny(err)
+	}
+	if len(unitStatusList) != 1 {
+		return UnitStatus{}, maskAny(invalidUnitStatusError)
+	}
+
+	return unitStatusList[0], nil
+}
+
+// GetStatusWithMatcher returns the simulated status of all units the given
+// matcher returns true for. That is the status of the fleet cluster, having
+// all recorded operations applied.
+func (f *PlanFleet) GetStatusWithMatcher(ctx context.Context, matcher func(string) bool) ([]UnitStatus, error) {
+	clusterStatusList, err := f.Config.Fleet.GetStatusWithMatcher(ctx, matcher)
+	if IsUnitNotFound(err) {
+		clusterStatusList = nil
+	} else if err != nil {
+		return nil, maskAny(err)
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	seen := map[string]bool{}
+	unitStatusList := []UnitStatus{}
+	for _, us := range clusterStatusList {
+		seen[us.Name] = true
+		if f.destroyed[us.Name] {
+			continue
+		}
+		if planned, ok := f.units[us.Name]; ok {
+			us = planned
+		}
+		unitStatusList = append(unitStatusList, us)
+	}
+	for name, us := range f.units {
+		if !seen[name] && matcher(name) {
+			unitStatusList = append(unitStatusList, us)
+		}
+	}
+
+	if len(unitStatusList) == 0 {
+		return nil, maskAny(unitNotFoundError)
+	}
+
+	return unitStatusList, nil
+}
+
+// GetUnitContent returns the content of the given unit, taking recorded
+// submissions into account.
+func (f *PlanFleet) GetUnitContent(ctx context.Context, name string) (string, error) {
+	f.Config.Logger.Debug(ctx, "plan fleet: get unit content %v", name)
+
+	f.mutex.Lock()
+	content, ok := f.contents[name]
+	destroyed := f.destroyed[name]
+	f.mutex.Unlock()
+
+	if destroyed {
+		return "", maskAnyf(unitNotFoundError, "%s", name)
+	}
+	if ok {
+		return content, nil
+	}
+
+	content, err := f.Config.Fleet.GetUnitContent(ctx, name)
+	if err != nil {
+		return "", maskAny(err)
+	}
+
+	return content, nil
+}
+
+// setState records the given action and simulates the given unit reaching the
+// given fleet and systemd states. Units scheduled within the fleet cluster
+// keep their machines.
+func (f *PlanFleet) setState(ctx context.Context, action, name, state, systemdActive, systemdSub string) error {
+	us, err := f.GetStatus(ctx, name)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	us.Current = state
+	us.Desired = state
+	if len(us.Machine) == 0 {
+		us.Machine = []MachineStatus{MachineStatus{}}
+	}
+	machines := make([]MachineStatus, 0, len(us.Machine))
+	for _, ms := range us.Machine {
+		ms.SystemdActive = systemdActive
+		ms.SystemdSub = systemdSub
+		machines = append(machines, ms)
+	}
+	us.Machine = machines
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.units[name] = us
+	f.record(action, name, state)
+
+	return nil
+}
+
+// record appends an operation to the list of recorded operations. The mutex
+// must be held by the caller.
+func (f *PlanFleet) record(action, name, targetState string) {
+	f.operations = append(f.operations, Operation{
+		Action:      action,
+		Name:        name,
+		TargetState: targetState,
+	})
+}
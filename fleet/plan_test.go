@@ -0,0 +1,143 @@
+package fleet
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	planUnitContent = "[Service]\nExecStart=/bin/true\n"
+)
+
+func givenPlanFleet() (*PlanFleet, *DummyFleet) {
+	dummyFleet := NewDummyFleet(DefaultDummyConfig())
+
+	newPlanConfig := DefaultPlanConfig()
+	newPlanConfig.Fleet = dummyFleet
+	planFleet := NewPlanFleet(newPlanConfig)
+
+	return planFleet, dummyFleet
+}
+
+// TestPlanFleet_RecordsOperations tests that operations are recorded in order
+// and not forwarded to the underlying fleet.
+func TestPlanFleet_RecordsOperations(t *testing.T) {
+	planFleet, dummyFleet := givenPlanFleet()
+	ctx := context.Background()
+
+	if err := planFleet.Submit(ctx, "app@1.service", planUnitContent); err != nil {
+		t.Fatal("Error submitting unit:", err)
+	}
+	if err := planFleet.Start(ctx, "app@1.service"); err != nil {
+		t.Fatal("Error starting unit:", err)
+	}
+	if err := planFleet.Stop(ctx, "app@1.service"); err != nil {
+		t.Fatal("Error stopping unit:", err)
+	}
+	if err := planFleet.Destroy(ctx, "app@1.service"); err != nil {
+		t.Fatal("Error destroying unit:", err)
+	}
+
+	expected := []string{
+		"submit app@1.service (target state: loaded)",
+		"start app@1.service (target state: launched)",
+		"stop app@1.service (target state: loaded)",
+		"destroy app@1.service (target state: inactive)",
+	}
+	operations := planFleet.Flush()
+	if len(operations) != len(expected) {
+		t.Fatalf("Expected %d operations, got: %v", len(expected), operations)
+	}
+	for i, o := range operations {
+		if o.String() != expected[i] {
+			t.Fatalf("Expected operation %d to be '%s', got: '%s'", i, expected[i], o.String())
+		}
+	}
+
+	if len(planFleet.Flush()) != 0 {
+		t.Fatal("Expected operations to be flushed")
+	}
+	if len(dummyFleet.Units) != 0 {
+		t.Fatal("Expected underlying fleet to be unchanged, got:", dummyFleet.Units)
+	}
+}
+
+// TestPlanFleet_SimulatesState tests that the state of the underlying fleet is
+// reported having the recorded operations applied.
+func TestPlanFleet_SimulatesState(t *testing.T) {
+	planFleet, dummyFleet := givenPlanFleet()
+	ctx := context.Background()
+
+	dummyFleet.Submit(ctx, "app@1.service", planUnitContent)
+	dummyFleet.Start(ctx, "app@1.service")
+	dummyFleet.Submit(ctx, "app@2.service", planUnitContent)
+
+	if err := planFleet.Start(ctx, "app@2.service"); err != nil {
+		t.Fatal("Error starting unit:", err)
+	}
+	if err := planFleet.Destroy(ctx, "app@1.service"); err != nil {
+		t.Fatal("Error destroying unit:", err)
+	}
+	if err := planFleet.Submit(ctx, "app@3.service", planUnitContent); err != nil {
+		t.Fatal("Error submitting unit:", err)
+	}
+
+	if _, err := planFleet.GetStatus(ctx, "app@1.service"); !IsUnitNotFound(err) {
+		t.Fatal("Expected destroyed unit to be not found, got:", err)
+	}
+	if _, err := planFleet.GetUnitContent(ctx, "app@1.service"); !IsUnitNotFound(err) {
+		t.Fatal("Expected content of destroyed unit to be not found, got:", err)
+	}
+
+	us, err := planFleet.GetStatus(ctx, "app@2.service")
+	if err != nil {
+		t.Fatal("Error getting status:", err)
+	}
+	if us.Current != unitStateLaunched || us.Machine[0].SystemdActive != "active" {
+		t.Fatal("Expected started unit to be launched, got:", us)
+	}
+	if us.Machine[0].UnitHash != dummyUnitHash(planUnitContent) {
+		t.Fatal("Expected started unit to keep its hash, got:", us.Machine[0].UnitHash)
+	}
+
+	content, err := planFleet.GetUnitContent(ctx, "app@3.service")
+	if err != nil {
+		t.Fatal("Error getting content:", err)
+	}
+	if content != planUnitContent {
+		t.Fatal("Incorrect content of submitted unit:", content)
+	}
+
+	unitStatusList, err := planFleet.GetStatusWithMatcher(context.Background(), func(s string) bool { return true })
+	if err != nil {
+		t.Fatal("Error getting statuses:", err)
+	}
+	if len(unitStatusList) != 2 {
+		t.Fatal("Expected 2 units, got:", unitStatusList)
+	}
+
+	if dummyFleet.Units["app@2.service"].Current != unitStateLoaded {
+		t.Fatal("Expected underlying fleet to be unchanged")
+	}
+	if _, ok := dummyFleet.Units["app@1.service"]; !ok {
+		t.Fatal("Expected underlying fleet to be unchanged")
+	}
+}
+
+// TestPlanFleet_NotFound tests that operations against units that neither
+// exist nor were submitted fail.
+func TestPlanFleet_NotFound(t *testing.T) {
+	planFleet, _ := givenPlanFleet()
+	ctx := context.Background()
+
+	if err := planFleet.Start(ctx, "app@1.service"); !IsUnitNotFound(err) {
+		t.Fatal("Expected unit not found error, got:", err)
+	}
+	if err := planFleet.Destroy(ctx, "app@1.service"); !IsUnitNotFound(err) {
+		t.Fatal("Expected unit not found error, got:", err)
+	}
+	if len(planFleet.Flush()) != 0 {
+		t.Fatal("Expected no operations to be recorded")
+	}
+}
@@ -6,18 +6,129 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	gossh "github.com/coreos/fleet/Godeps/_workspace/src/golang.org/x/crypto/ssh"
+	gosshagent "github.com/coreos/fleet/Godeps/_workspace/src/golang.org/x/crypto/ssh/agent"
 	"github.com/coreos/fleet/ssh"
+	"golang.org/x/net/context"
+
 	"github.com/giantswarm/inago/logging"
 )
 
+const (
+	sshDefaultPort = "22"
+)
+
+// SSHHop represents a jump host the ssh tunnel connects through, like ssh's
+// ProxyJump option.
+type SSHHop struct {
+	// Host is the address of the jump host. Port 22 is used in case no port is
+	// given.
+	Host string
+
+	// Username is the user used to log in to the jump host.
+	Username string
+
+	// StrictHostKeyChecking defines whether the host key of the jump host is
+	// verified against the known hosts file.
+	StrictHostKeyChecking bool
+
+	// KnownHostsFile is the file the host key of the jump host is verified
+	// against. The KnownHostsFile of the tunnel is used in case it is empty.
+	KnownHostsFile string
+}
+
+// ParseSSHHop parses a jump host given as
+// [user@]host[:port][?option=value[&option=value]]. Supported options are
+// strict-host-key-checking and known-hosts-file. Everything not given is taken
+// from defaults.
+func ParseSSHHop(hop string, defaults SSHHop) (SSHHop, error) {
+	newHop := defaults
+	newHop.Host = hop
+
+	var options string
+	if i := strings.Index(hop, "?"); i >= 0 {
+		newHop.Host = hop[:i]
+		options = hop[i+1:]
+	}
+	if i := strings.LastIndex(newHop.Host, "@"); i >= 0 {
+		newHop.Username = newHop.Host[:i]
+		newHop.Host = newHop.Host[i+1:]
+		if newHop.Username == "" {
+			return SSHHop{}, maskAnyf(invalidEndpointError, "empty username in jump host '%s'", hop)
+		}
+	}
+	if newHop.Host == "" || strings.HasPrefix(newHop.Host, ":") {
+		return SSHHop{}, maskAnyf(invalidEndpointError, "empty host in jump host '%s'", hop)
+	}
+
+	values, err := url.ParseQuery(options)
+	if err != nil {
+		return SSHHop{}, maskAnyf(invalidEndpointError, "invalid options in jump host '%s': %s", hop, err)
+	}
+	for key := range values {
+		value := values.Get(key)
+		switch key {
+		case "strict-host-key-checking":
+			strict, err := strconv.ParseBool(value)
+			if err != nil {
+				return SSHHop{}, maskAnyf(invalidEndpointError, "invalid strict-host-key-checking '%s' in jump host '%s'", value, hop)
+			}
+			newHop.StrictHostKeyChecking = strict
+		case "known-hosts-file":
+			if value == "" {
+				return SSHHop{}, maskAnyf(invalidEndpointError, "empty known-hosts-file in jump host '%s'", hop)
+			}
+			newHop.KnownHostsFile = value
+		default:
+			return SSHHop{}, maskAnyf(invalidEndpointError, "unknown option '%s' in jump host '%s'", key, hop)
+		}
+	}
+
+	return newHop, nil
+}
+
 // SSHTunnelConfig contains the information needed to create a new ssh tunnel.
 type SSHTunnelConfig struct {
-	Endpoint              url.URL
-	KnownHostsFile        string
-	Logger                logging.Logger
+	// AgentSocket is the path of the socket the ssh agent listens on. The
+	// SSH_AUTH_SOCK environment variable is used in case it is empty. Without
+	// any agent, only IdentityFile is used for authentication.
+	AgentSocket string
+
+	Endpoint url.URL
+
+	// ForwardAgent defines whether the ssh agent is forwarded to the tunnel
+	// host when executing fleetctl fd-forward.
+	ForwardAgent bool
+
+	// IdentityFile is the path of a PEM encoded private key used for
+	// authentication in addition to the keys of the ssh agent.
+	IdentityFile string
+
+	// IdentityPassphrase returns the passphrase of IdentityFile. It is only
+	// called in case IdentityFile is encrypted.
+	IdentityPassphrase func() ([]byte, error)
+
+	// JumpHosts are connected through in the given order before connecting to
+	// Tunnel. The fleet endpoint is then reached from Tunnel, the last hop.
+	JumpHosts []SSHHop
+
+	KnownHostsFile string
+	Logger         logging.Logger
+
+	// MaxConnections is the maximum number of SSH connections used to send
+	// requests in parallel. Each connection serves one request at a time.
+	MaxConnections int
+
+	// KeepAliveInterval is the interval keepalive probes are sent in using
+	// each SSH connection. Connections not answering a probe within Timeout
+	// are replaced by new ones. Zero disables keepalive probes.
+	KeepAliveInterval time.Duration
+
 	StrictHostKeyChecking bool
 	Tunnel                string
 	Timeout               time.Duration
@@ -31,9 +142,15 @@ func DefaultSSHTunnelConfig() SSHTunnelConfig {
 	}
 
 	newConfig := SSHTunnelConfig{
+		AgentSocket:           "",
 		Endpoint:              *URL,
+		ForwardAgent:          true,
+		IdentityFile:          "",
+		IdentityPassphrase:    nil,
 		KnownHostsFile:        "~/.fleetctl/known_hosts",
 		Logger:                logging.NewLogger(logging.DefaultConfig()),
+		MaxConnections:        4,
+		KeepAliveInterval:     30 * time.Second,
 		StrictHostKeyChecking: true,
 		Tunnel:                "",
 		Timeout:               10 * time.Second,
@@ -52,22 +169,45 @@ type SSHTunnel interface {
 	// the given tunnel configuration. In case this is empty, the SSH tunnel is
 	// not active.
 	IsActive() bool
+
+	// Dial connects to the given address from the tunnel host, e.g. to reach
+	// machines of the fleet cluster which are only reachable from there. The
+	// SSH connection used is not used by any request until the returned
+	// connection is closed.
+	Dial(network, addr string) (net.Conn, error)
 }
 
 // NewSSHTunnel creates a new SSH tunnel that is configured with the given
-// settings.
+// settings. The first SSH connection is established right away, further ones
+// once requests are sent in parallel.
 func NewSSHTunnel(config SSHTunnelConfig) (SSHTunnel, error) {
+	if config.MaxConnections < 1 {
+		return nil, maskAnyf(invalidConfigError, "max connections must be at least 1")
+	}
+
 	newSSHTunnel := &sshTunnel{
 		SSHTunnelConfig: config,
-		Mutex:           sync.Mutex{},
+		Connections:     make(chan *sshConnection, config.MaxConnections),
 	}
 
-	newDialFunc, err := newSSHTunnel.NewDialFunc()
+	if config.IdentityFile != "" {
+		// The identity is loaded once, so the passphrase is only asked for
+		// once as well.
+		identity, err := loadIdentity(config.IdentityFile, config.IdentityPassphrase)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		newSSHTunnel.Identity = identity
+	}
+
+	newConnection, err := newSSHTunnel.NewConnection()
 	if err != nil {
 		return nil, maskAny(err)
 	}
-	newSSHTunnel.HTTPTransport = &http.Transport{
-		Dial: newDialFunc,
+	newSSHTunnel.Connections <- newConnection
+	for i := 1; i < config.MaxConnections; i++ {
+		// Connections not established yet are represented by nil.
+		newSSHTunnel.Connections <- nil
 	}
 
 	return newSSHTunnel, nil
@@ -76,83 +216,516 @@ func NewSSHTunnel(config SSHTunnelConfig) (SSHTunnel, error) {
 type sshTunnel struct {
 	SSHTunnelConfig
 
-	HTTPTransport http.RoundTripper
-	Mutex         sync.Mutex
+	// Connections holds the SSH connections currently not in use.
+	Connections chan *sshConnection
+
+	// Identity is the signer loaded from IdentityFile, if any.
+	Identity gossh.Signer
 }
 
-func (t *sshTunnel) IsActive() bool {
-	return t.Tunnel != ""
+// sshConnection represents a single SSH connection of the tunnel, serving one
+// request at a time.
+type sshConnection struct {
+	// AgentConn is the connection to the ssh agent, if any.
+	AgentConn net.Conn
+
+	// Clients are the SSH clients of all hops. The last one is connected to
+	// the tunnel host.
+	Clients []*gossh.Client
+
+	HTTPTransport *http.Transport
+
+	broken    chan struct{}
+	closeOnce sync.Once
 }
 
-// NewDialFunc returns an http.Dial function which uses the given ssh tunnel to
-// connect to the configured endpoint.
-func (t *sshTunnel) NewDialFunc() (func(string, string) (net.Conn, error), error) {
-	forwardAgent := true
-	sshClient, err := ssh.NewSSHClient(t.Username, t.Tunnel, t.NewHostKeyChecker(), forwardAgent, t.Timeout)
+// IsBroken returns whether the connection was closed or found to be broken.
+func (c *sshConnection) IsBroken() bool {
+	select {
+	case <-c.broken:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close closes the SSH clients of all hops. Requests currently using the
+// connection fail.
+func (c *sshConnection) Close() {
+	c.closeOnce.Do(func() {
+		close(c.broken)
+
+		// Closing the clients in reverse order closes the connections
+		// tunneled through them first.
+		for i := len(c.Clients) - 1; i >= 0; i-- {
+			c.Clients[i].Close()
+		}
+		if c.AgentConn != nil {
+			c.AgentConn.Close()
+		}
+		c.HTTPTransport.CloseIdleConnections()
+	})
+}
+
+// NewConnection establishes a new SSH connection to the configured endpoint.
+// The connection is kept alive until it is closed.
+func (t *sshTunnel) NewConnection() (*sshConnection, error) {
+	agentConn, err := t.DialAgent()
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	var agentClient gosshagent.Agent
+	if agentConn != nil {
+		agentClient = gosshagent.NewClient(agentConn)
+	}
+
+	newConnection := &sshConnection{
+		AgentConn:     agentConn,
+		HTTPTransport: &http.Transport{},
+		broken:        make(chan struct{}),
+	}
+
+	newConnection.Clients, err = t.NewSSHClients(agentClient)
 	if err != nil {
+		newConnection.Close()
 		return nil, maskAny(err)
 	}
+	sshClient := newConnection.Clients[len(newConnection.Clients)-1]
 
+	forwardAgent := t.ForwardAgent && agentClient != nil
+	if forwardAgent {
+		if err := gosshagent.ForwardToAgent(sshClient, agentClient); err != nil {
+			newConnection.Close()
+			return nil, maskAny(err)
+		}
+	}
+	newConnection.HTTPTransport.Dial = t.newDialFunc(sshClient, forwardAgent)
+
+	go t.keepAlive(newConnection)
+
+	return newConnection, nil
+}
+
+func (t *sshTunnel) IsActive() bool {
+	return t.Tunnel != ""
+}
+
+// newDialFunc returns an http.Dial function which uses the given SSH client to
+// connect to the configured endpoint.
+func (t *sshTunnel) newDialFunc(sshClient *gossh.Client, forwardAgent bool) func(string, string) (net.Conn, error) {
 	if t.Endpoint.Scheme == "unix" || t.Endpoint.Scheme == "file" {
 		return func(string, string) (net.Conn, error) {
 			cmd := fmt.Sprintf(`fleetctl fd-forward %s`, t.Endpoint.Path)
-			return ssh.DialCommand(sshClient, cmd)
-		}, nil
+			return dialCommand(sshClient, cmd, forwardAgent)
+		}
 	}
 
-	return sshClient.Dial, nil
+	return sshClient.Dial
 }
 
-// NewHostKeyChecker creates a new HostKeyChecker, or nil if any error is
-// encountered.
-func (t *sshTunnel) NewHostKeyChecker() *ssh.HostKeyChecker {
-	if !t.StrictHostKeyChecking {
+// keepAlive sends keepalive probes using the given connection every
+// KeepAliveInterval. Connections not answering in time, or being shut down by
+// the remote side, are closed. They are replaced by new connections once they
+// are used again. See RoundTrip.
+func (t *sshTunnel) keepAlive(conn *sshConnection) {
+	ctx := context.Background()
+	sshClient := conn.Clients[len(conn.Clients)-1]
+
+	go func() {
+		err := sshClient.Wait()
+		if !conn.IsBroken() {
+			t.Logger.Warning(ctx, "fleet: ssh connection to '%s' lost: %v", t.Tunnel, err)
+			conn.Close()
+		}
+	}()
+
+	if t.KeepAliveInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(t.KeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-conn.broken:
+			return
+		case <-ticker.C:
+		}
+
+		if !t.isAlive(conn) {
+			return
+		}
+	}
+}
+
+// isAlive sends a keepalive probe using the given connection. In case it is
+// not answered within Timeout, the connection is closed.
+func (t *sshTunnel) isAlive(conn *sshConnection) bool {
+	if conn.IsBroken() {
+		return false
+	}
+
+	sshClient := conn.Clients[len(conn.Clients)-1]
+	errs := make(chan error, 1)
+	go func() {
+		// The reply does not matter, as servers usually refuse the request.
+		_, _, err := sshClient.SendRequest("keepalive@openssh.com", true, nil)
+		errs <- err
+	}()
+
+	var err error
+	select {
+	case err = <-errs:
+	case <-time.After(t.Timeout):
+		err = maskAnyf(sshTimeoutError, "keepalive not answered")
+	}
+	if err != nil {
+		if !conn.IsBroken() {
+			t.Logger.Warning(context.Background(), "fleet: ssh connection to '%s' broken: %v", t.Tunnel, err)
+		}
+		conn.Close()
+		return false
+	}
+
+	return true
+}
+
+// NewSSHClients connects to the configured tunnel host using the configured
+// identity and the keys of the given agent, which may be nil. In case jump
+// hosts are configured, the connection is established through all of them in
+// order. The clients of all hops are returned, the last one being connected
+// to the tunnel host.
+func (t *sshTunnel) NewSSHClients(agentClient gosshagent.Agent) ([]*gossh.Client, error) {
+	authMethods, err := t.authMethods(agentClient)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	hops := append([]SSHHop{}, t.JumpHosts...)
+	hops = append(hops, SSHHop{
+		Host:                  t.Tunnel,
+		Username:              t.Username,
+		StrictHostKeyChecking: t.StrictHostKeyChecking,
+	})
+
+	var clients []*gossh.Client
+	for _, hop := range hops {
+		var via *gossh.Client
+		if len(clients) > 0 {
+			via = clients[len(clients)-1]
+		}
+
+		client, err := t.dialHop(via, hop, authMethods)
+		if err != nil {
+			// Closing the clients in reverse order closes the connections
+			// tunneled through them first.
+			for i := len(clients) - 1; i >= 0; i-- {
+				clients[i].Close()
+			}
+			return nil, maskAnyf(err, "connecting to '%s'", hop.Host)
+		}
+		clients = append(clients, client)
+	}
+
+	return clients, nil
+}
+
+// dialHop connects to the given hop. The connection is established through
+// via, or directly in case via is nil.
+func (t *sshTunnel) dialHop(via *gossh.Client, hop SSHHop, authMethods []gossh.AuthMethod) (*gossh.Client, error) {
+	addr := hop.Host
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, sshDefaultPort)
+	}
+
+	clientConfig := t.newClientConfig(hop, addr, authMethods)
+
+	type dialResult struct {
+		Client *gossh.Client
+		Err    error
+	}
+	results := make(chan dialResult, 1)
+	go func() {
+		var conn net.Conn
+		var err error
+		if via == nil {
+			conn, err = net.DialTimeout("tcp", addr, t.Timeout)
+		} else {
+			conn, err = via.Dial("tcp", addr)
+		}
+		if err != nil {
+			results <- dialResult{Err: err}
+			return
+		}
+
+		c, chans, reqs, err := gossh.NewClientConn(conn, addr, clientConfig)
+		if err != nil {
+			conn.Close()
+			results <- dialResult{Err: err}
+			return
+		}
+		results <- dialResult{Client: gossh.NewClient(c, chans, reqs)}
+	}()
+
+	select {
+	case result := <-results:
+		if result.Err != nil {
+			return nil, maskAny(result.Err)
+		}
+		return result.Client, nil
+	case <-time.After(t.Timeout):
+		go func() {
+			// The connection might still be established after giving up on it.
+			if result := <-results; result.Client != nil {
+				result.Client.Close()
+			}
+		}()
+		return nil, maskAnyf(sshTimeoutError, "%s", addr)
+	}
+}
+
+// newClientConfig returns the ssh client configuration used to log in to the
+// given hop.
+func (t *sshTunnel) newClientConfig(hop SSHHop, addr string, authMethods []gossh.AuthMethod) *gossh.ClientConfig {
+	clientConfig := &gossh.ClientConfig{
+		User: hop.Username,
+		Auth: authMethods,
+	}
+	if checker := t.NewHostKeyChecker(hop); checker != nil {
+		clientConfig.HostKeyCallback = checker.Check
+		clientConfig.HostKeyAlgorithms = checker.GetHostKeyAlgorithms(addr)
+	}
+
+	return clientConfig
+}
+
+// NewHostKeyChecker creates a new HostKeyChecker using the known hosts file of
+// the given hop, or nil in case strict host key checking is disabled for it.
+// The configured known hosts file is used in case the hop defines none.
+func (t *sshTunnel) NewHostKeyChecker(hop SSHHop) *ssh.HostKeyChecker {
+	if !hop.StrictHostKeyChecking {
 		return nil
 	}
 
-	keyFile := ssh.NewHostKeyFile(t.KnownHostsFile)
+	knownHostsFile := hop.KnownHostsFile
+	if knownHostsFile == "" {
+		knownHostsFile = t.KnownHostsFile
+	}
+	keyFile := ssh.NewHostKeyFile(knownHostsFile)
 	return ssh.NewHostKeyChecker(keyFile)
 }
 
-// RoundTrip provides implementation of http.RoundTripper. This method is
-// simply a synchronized wrapper to the underlying http.Transport.
+// RoundTrip provides implementation of http.RoundTripper. Each request is
+// sent using one of the tunnel's SSH connections. Requests wait for a
+// connection to become available in case all of them are in use. Broken
+// connections are replaced by new ones.
 func (t *sshTunnel) RoundTrip(req *http.Request) (*http.Response, error) {
-	t.Mutex.Lock()
+	conn, err := t.acquire()
+	if err != nil {
+		return nil, maskAny(err)
+	}
 
-	resp, err := t.HTTPTransport.RoundTrip(req)
+	resp, err := conn.HTTPTransport.RoundTrip(req)
+	if err != nil && req.Body == nil && !t.isAlive(conn) {
+		// The connection broke while sending the request. Requests without a
+		// body can be sent again right away using a new connection. Others are
+		// left to the retry policy of the caller, as their body is consumed.
+		t.Connections <- conn
+		conn, err = t.acquire()
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		resp, err = conn.HTTPTransport.RoundTrip(req)
+	}
+	if err != nil {
+		// There is no response body that could be closed. Thus the connection
+		// is not in use anymore and we can release it right away.
+		t.Connections <- conn
+		return nil, err
+	}
 
 	/**
 	Learning:
 
 	The fleet ssh tunnel does not support (for unknown reasons) more than one
-	command-session in parallel. Just adding a mutex here does not 100% help,
-	as the connection is only returned to the idle pool when the body gets closed.
-	If multiple go routines try to call RoundTrip() the first one performs the request,
-	then return the response. Then the second goroutine would start calling its
-	request and since the initial connection is not yet closed would try to open a second
-	connection which would fail with "forward request denied".
-
-	Thus we only unlock the mutex when the body of the response has been closed.
-	This ensures the connection has been returned to the pool.
+	command-session in parallel on a single SSH connection. The connection is
+	only returned to the idle pool of its transport when the body gets closed.
+	If another request was sent on the same SSH connection before, it would
+	try to open a second session which would fail with "forward request denied".
+
+	Thus each SSH connection serves one request at a time, and is only released
+	when the body of the response has been closed. Requests are sent in
+	parallel using multiple SSH connections instead.
 	**/
-	resp.Body = &synchronousReadCloser{
-		body:  resp.Body,
-		mutex: &t.Mutex,
+	resp.Body = &releasingReadCloser{
+		body: resp.Body,
+		release: func() {
+			t.Connections <- conn
+		},
 	}
 
 	return resp, err
 }
 
-type synchronousReadCloser struct {
-	body  io.ReadCloser
-	mutex *sync.Mutex
+func (t *sshTunnel) Dial(network, addr string) (net.Conn, error) {
+	conn, err := t.acquire()
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	sshClient := conn.Clients[len(conn.Clients)-1]
+	netConn, err := sshClient.Dial(network, addr)
+	if err != nil {
+		t.Connections <- conn
+		return nil, maskAny(err)
+	}
+
+	return &releasingConn{
+		Conn: netConn,
+		release: func() {
+			t.Connections <- conn
+		},
+	}, nil
+}
+
+// acquire returns a connection not used by any other request until it is
+// given back to Connections. Broken connections are replaced by new ones.
+func (t *sshTunnel) acquire() (*sshConnection, error) {
+	ctx := context.Background()
+
+	conn := <-t.Connections
+	if conn != nil && !conn.IsBroken() {
+		return conn, nil
+	}
+
+	if conn != nil {
+		t.Logger.Info(ctx, "fleet: reconnecting ssh tunnel to '%s'", t.Tunnel)
+		conn.Close()
+	}
+	newConnection, err := t.NewConnection()
+	if err != nil {
+		if conn != nil {
+			t.Logger.Error(ctx, "fleet: reconnecting ssh tunnel to '%s' failed: %v", t.Tunnel, err)
+		}
+		// The next request tries to connect again.
+		t.Connections <- nil
+		return nil, maskAny(err)
+	}
+	if conn != nil {
+		t.Logger.Info(ctx, "fleet: reconnected ssh tunnel to '%s'", t.Tunnel)
+	}
+
+	return newConnection, nil
+}
+
+// releasingReadCloser calls release once the wrapped body is closed.
+type releasingReadCloser struct {
+	body    io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (r *releasingReadCloser) Read(p []byte) (n int, err error) {
+	return r.body.Read(p)
+}
+
+func (r *releasingReadCloser) Close() error {
+	defer r.once.Do(r.release)
+	return r.body.Close()
+}
+
+// releasingConn calls release once the wrapped connection is closed.
+type releasingConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *releasingConn) Close() error {
+	defer c.once.Do(c.release)
+	return c.Conn.Close()
+}
+
+// dialCommand executes the given command on the given client. The returned
+// connection writes to the command's stdin and reads from its stdout.
+func dialCommand(client *gossh.Client, cmd string, forwardAgent bool) (net.Conn, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, maskAny(err)
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, maskAny(err)
+	}
+
+	if forwardAgent {
+		if err := gosshagent.RequestAgentForwarding(session); err != nil {
+			session.Close()
+			return nil, maskAny(err)
+		}
+	}
+
+	if err := session.Start(cmd); err != nil {
+		session.Close()
+		return nil, maskAny(err)
+	}
+
+	conn := &commandConn{
+		session: session,
+		writer:  stdin,
+		reader:  stdout,
+		errs:    make(chan error, 1),
+	}
+	go func() {
+		if err := session.Wait(); err != nil {
+			conn.errs <- err
+		}
+		close(conn.errs)
+	}()
+
+	return conn, nil
+}
+
+// commandConn is the connection returned by dialCommand.
+type commandConn struct {
+	session *gossh.Session
+	writer  io.WriteCloser
+	reader  io.Reader
+	errs    chan error
+
+	// commandConn does not fully implement the net.Conn interface, so it is
+	// embedded here, like fleet does for its own command connections.
+	net.Conn
+}
+
+func (c *commandConn) Read(b []byte) (int, error) {
+	n, err := c.reader.Read(b)
+	if err == nil {
+		return n, err
+	}
+
+	// Errors of the command are more meaningful than the end of its output.
+	if werr := <-c.errs; werr != nil {
+		err = werr
+	}
+
+	return n, err
 }
 
-func (s *synchronousReadCloser) Read(p []byte) (n int, err error) {
-	return s.body.Read(p)
+func (c *commandConn) Write(b []byte) (int, error) {
+	return c.writer.Write(b)
 }
 
-func (s *synchronousReadCloser) Close() error {
-	defer s.mutex.Unlock()
-	return s.body.Close()
+func (c *commandConn) Close() error {
+	c.session.Signal(gossh.SIGTERM)
+	c.session.Close()
+	c.writer.Close()
+	return nil
 }
@@ -0,0 +1,102 @@
+package fleet
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"os"
+
+	gossh "github.com/coreos/fleet/Godeps/_workspace/src/golang.org/x/crypto/ssh"
+	gosshagent "github.com/coreos/fleet/Godeps/_workspace/src/golang.org/x/crypto/ssh/agent"
+	"github.com/coreos/fleet/pkg"
+)
+
+// DialAgent connects to the configured ssh agent. In case no agent socket is
+// configured and SSH_AUTH_SOCK is not set, nil is returned.
+func (t *sshTunnel) DialAgent() (net.Conn, error) {
+	sock := t.AgentSocket
+	if sock == "" {
+		sock = os.Getenv("SSH_AUTH_SOCK")
+	}
+	if sock == "" {
+		return nil, nil
+	}
+
+	conn, err := net.Dial("unix", pkg.ParseFilepath(sock))
+	if err != nil {
+		return nil, maskAnyf(err, "connecting to ssh agent")
+	}
+
+	return conn, nil
+}
+
+// authMethods returns the methods used to log in to all hops. The identity is
+// tried before the keys of the given agent, which may be nil.
+func (t *sshTunnel) authMethods(agentClient gosshagent.Agent) ([]gossh.AuthMethod, error) {
+	var authMethods []gossh.AuthMethod
+
+	if t.Identity != nil {
+		authMethods = append(authMethods, gossh.PublicKeys(t.Identity))
+	}
+	if agentClient != nil {
+		authMethods = append(authMethods, gossh.PublicKeysCallback(agentClient.Signers))
+	}
+
+	if len(authMethods) == 0 {
+		return nil, maskAnyf(invalidConfigError, "neither identity file nor ssh agent given, set SSH_AUTH_SOCK or use an identity file")
+	}
+
+	return authMethods, nil
+}
+
+// loadIdentity reads the PEM encoded private key from the given file. See
+// parseIdentity.
+func loadIdentity(file string, passphrase func() ([]byte, error)) (gossh.Signer, error) {
+	pemBytes, err := ioutil.ReadFile(pkg.ParseFilepath(file))
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	identity, err := parseIdentity(pemBytes, passphrase)
+	if err != nil {
+		return nil, maskAnyf(err, "identity file '%s'", file)
+	}
+
+	return identity, nil
+}
+
+// parseIdentity parses the given PEM encoded private key. RSA, DSA and ECDSA
+// keys are supported. The passphrase function is only called in case the key
+// is encrypted.
+func parseIdentity(pemBytes []byte, passphrase func() ([]byte, error)) (gossh.Signer, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, maskAnyf(invalidConfigError, "no PEM encoded private key found")
+	}
+
+	if x509.IsEncryptedPEMBlock(block) {
+		if passphrase == nil {
+			return nil, maskAnyf(invalidConfigError, "private key is encrypted, but no passphrase is given")
+		}
+		p, err := passphrase()
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		der, err := x509.DecryptPEMBlock(block, p)
+		if err != nil {
+			return nil, maskAnyf(invalidConfigError, "decrypting private key: %s", err)
+		}
+		block = &pem.Block{
+			Type:  block.Type,
+			Bytes: der,
+		}
+	}
+
+	identity, err := gossh.ParsePrivateKey(pem.EncodeToMemory(block))
+	if err != nil {
+		return nil, maskAnyf(invalidConfigError, "%s", err)
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,974 @@
+package fleet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gossh "github.com/coreos/fleet/Godeps/_workspace/src/golang.org/x/crypto/ssh"
+	gosshagent "github.com/coreos/fleet/Godeps/_workspace/src/golang.org/x/crypto/ssh/agent"
+	"golang.org/x/net/context"
+)
+
+// sshStandIn is a minimal SSH server standing in for jump hosts and CoreOS
+// machines. It forwards TCP connections and executes fleetctl fd-forward by
+// connecting to the given unix socket. Agent forwarding requests are accepted,
+// but agent channels are never opened. Users, forwarded addresses, executed
+// commands, session request types and global request types are recorded.
+type sshStandIn struct {
+	Addr    string
+	HostKey gossh.PublicKey
+
+	mutex    sync.Mutex
+	users    []string
+	forwards []string
+	commands []string
+	requests []string
+	global   []string
+
+	conns        []gossh.Conn
+	unresponsive bool
+}
+
+// drop closes all connections to the stand-in, like a network failure would.
+func (s *sshStandIn) drop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+// setUnresponsive defines whether global requests, e.g. keepalive probes, are
+// answered.
+func (s *sshStandIn) setUnresponsive(unresponsive bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.unresponsive = unresponsive
+}
+
+func (s *sshStandIn) globalRequests(reqs <-chan *gossh.Request) {
+	for req := range reqs {
+		s.record(&s.global, req.Type)
+
+		s.mutex.Lock()
+		unresponsive := s.unresponsive
+		s.mutex.Unlock()
+		if !unresponsive && req.WantReply {
+			req.Reply(false, nil)
+		}
+	}
+}
+
+func (s *sshStandIn) record(list *[]string, item string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	*list = append(*list, item)
+}
+
+func (s *sshStandIn) recorded(list *[]string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string{}, *list...)
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("Error returned generating key:", err)
+	}
+	return key
+}
+
+// newSSHStandIn starts a SSH server stand-in accepting the given client key.
+// The returned function stops it.
+func newSSHStandIn(t *testing.T, clientKey gossh.PublicKey) (*sshStandIn, func()) {
+	return newSSHStandInAt(t, clientKey, "127.0.0.1:0")
+}
+
+// newSSHStandInAt is like newSSHStandIn, listening on the given address.
+func newSSHStandInAt(t *testing.T, clientKey gossh.PublicKey, addr string) (*sshStandIn, func()) {
+	hostKey, err := gossh.NewSignerFromKey(newTestKey(t))
+	if err != nil {
+		t.Fatal("Error returned creating host key:", err)
+	}
+
+	standIn := &sshStandIn{
+		HostKey: hostKey.PublicKey(),
+	}
+
+	serverConfig := &gossh.ServerConfig{
+		PublicKeyCallback: func(conn gossh.ConnMetadata, key gossh.PublicKey) (*gossh.Permissions, error) {
+			if string(key.Marshal()) != string(clientKey.Marshal()) {
+				return nil, fmt.Errorf("unknown key for user '%s'", conn.User())
+			}
+			standIn.record(&standIn.users, conn.User())
+			return nil, nil
+		},
+	}
+	serverConfig.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal("Error returned by listen:", err)
+	}
+	standIn.Addr = listener.Addr().String()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go standIn.serve(conn, serverConfig)
+		}
+	}()
+
+	return standIn, func() { listener.Close() }
+}
+
+func (s *sshStandIn) serve(conn net.Conn, serverConfig *gossh.ServerConfig) {
+	serverConn, chans, reqs, err := gossh.NewServerConn(conn, serverConfig)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer serverConn.Close()
+	s.mutex.Lock()
+	s.conns = append(s.conns, serverConn)
+	s.mutex.Unlock()
+	go s.globalRequests(reqs)
+
+	for newChannel := range chans {
+		switch newChannel.ChannelType() {
+		case "direct-tcpip":
+			go s.forward(newChannel)
+		case "session":
+			go s.session(newChannel)
+		default:
+			newChannel.Reject(gossh.UnknownChannelType, newChannel.ChannelType())
+		}
+	}
+}
+
+func (s *sshStandIn) forward(newChannel gossh.NewChannel) {
+	var payload struct {
+		Host     string
+		Port     uint32
+		OrigHost string
+		OrigPort uint32
+	}
+	if err := gossh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+		newChannel.Reject(gossh.ConnectionFailed, err.Error())
+		return
+	}
+	addr := net.JoinHostPort(payload.Host, fmt.Sprintf("%d", payload.Port))
+	s.record(&s.forwards, addr)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		newChannel.Reject(gossh.ConnectionFailed, err.Error())
+		return
+	}
+	channel, reqs, err := newChannel.Accept()
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go gossh.DiscardRequests(reqs)
+
+	pipe(channel, conn)
+}
+
+func (s *sshStandIn) session(newChannel gossh.NewChannel) {
+	channel, reqs, err := newChannel.Accept()
+	if err != nil {
+		return
+	}
+
+	for req := range reqs {
+		s.record(&s.requests, req.Type)
+		if req.Type == "auth-agent-req@openssh.com" {
+			req.Reply(true, nil)
+			continue
+		}
+		if req.Type != "exec" {
+			req.Reply(false, nil)
+			continue
+		}
+
+		var payload struct {
+			Command string
+		}
+		if err := gossh.Unmarshal(req.Payload, &payload); err != nil {
+			req.Reply(false, nil)
+			continue
+		}
+		s.record(&s.commands, payload.Command)
+
+		fields := strings.Fields(payload.Command)
+		if len(fields) != 3 || fields[0] != "fleetctl" || fields[1] != "fd-forward" {
+			req.Reply(false, nil)
+			continue
+		}
+		conn, err := net.Dial("unix", fields[2])
+		if err != nil {
+			req.Reply(false, nil)
+			continue
+		}
+		req.Reply(true, nil)
+
+		go func() {
+			go gossh.DiscardRequests(reqs)
+			pipe(channel, conn)
+		}()
+		return
+	}
+}
+
+// pipe copies data between the given channel and connection until either of
+// them is closed.
+func pipe(channel gossh.Channel, conn net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(channel, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, channel)
+		done <- struct{}{}
+	}()
+	<-done
+	channel.Close()
+	conn.Close()
+}
+
+// givenSSHAgent serves an in-memory ssh agent holding the given key, and
+// points SSH_AUTH_SOCK to it. The returned function stops the agent and
+// restores SSH_AUTH_SOCK.
+func givenSSHAgent(t *testing.T, sock string, key *ecdsa.PrivateKey) func() {
+	keyring := gosshagent.NewKeyring()
+	if err := keyring.Add(gosshagent.AddedKey{PrivateKey: key}); err != nil {
+		t.Fatal("Error returned adding key to agent:", err)
+	}
+
+	listener, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal("Error returned by listen:", err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				gosshagent.ServeAgent(keyring, conn)
+				conn.Close()
+			}()
+		}
+	}()
+
+	oldSock := os.Getenv("SSH_AUTH_SOCK")
+	os.Setenv("SSH_AUTH_SOCK", sock)
+
+	return func() {
+		listener.Close()
+		os.Setenv("SSH_AUTH_SOCK", oldSock)
+	}
+}
+
+// writeKnownHosts writes a known hosts file trusting the given keys of the
+// given addresses.
+func writeKnownHosts(t *testing.T, dir string, keys map[string]gossh.PublicKey) string {
+	var content string
+	for addr, key := range keys {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			t.Fatal("Error returned splitting address:", err)
+		}
+		content += fmt.Sprintf("[%s]:%s %s", host, port, gossh.MarshalAuthorizedKey(key))
+	}
+
+	file := filepath.Join(dir, "known_hosts")
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal("Error returned writing known hosts:", err)
+	}
+	return file
+}
+
+type sshTunnelTestEnv struct {
+	AgentSocket string
+	Dir         string
+	Key         *ecdsa.PrivateKey
+	ClientKey   gossh.PublicKey
+}
+
+func givenSSHTunnelTestEnv(t *testing.T) (sshTunnelTestEnv, func()) {
+	dir, err := ioutil.TempDir("", "inago-ssh")
+	if err != nil {
+		t.Fatal("Error returned creating temporary directory:", err)
+	}
+
+	key := newTestKey(t)
+	clientKey, err := gossh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal("Error returned creating public key:", err)
+	}
+	sock := filepath.Join(dir, "agent.sock")
+	stopAgent := givenSSHAgent(t, sock, key)
+
+	env := sshTunnelTestEnv{
+		AgentSocket: sock,
+		Dir:         dir,
+		Key:         key,
+		ClientKey:   clientKey,
+	}
+
+	return env, func() {
+		stopAgent()
+		os.RemoveAll(dir)
+	}
+}
+
+// getThroughTunnel requests the given URL using the tunnel created from the
+// given configuration, and returns the response body.
+func getThroughTunnel(t *testing.T, config SSHTunnelConfig, URL string) string {
+	tunnel, err := NewSSHTunnel(config)
+	if err != nil {
+		t.Fatal("Error returned by NewSSHTunnel:", err)
+	}
+
+	client := &http.Client{Transport: tunnel}
+	resp, err := client.Get(URL)
+	if err != nil {
+		t.Fatal("Error returned requesting through tunnel:", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal("Error returned reading response:", err)
+	}
+
+	return string(body)
+}
+
+func expectRecorded(t *testing.T, name string, recorded, expected []string) {
+	if len(recorded) != len(expected) {
+		t.Fatalf("%s: expected %v, got %v", name, expected, recorded)
+	}
+	for i := range expected {
+		if recorded[i] != expected[i] {
+			t.Fatalf("%s: expected %v, got %v", name, expected, recorded)
+		}
+	}
+}
+
+// TestSSHTunnel_JumpHosts tests that the tunnel reaches a TCP endpoint by
+// connecting through all jump hosts in order, each using its own user.
+func TestSSHTunnel_JumpHosts(t *testing.T) {
+	env, cleanup := givenSSHTunnelTestEnv(t)
+	defer cleanup()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "units")
+	}))
+	defer server.Close()
+
+	bastion, stop := newSSHStandIn(t, env.ClientKey)
+	defer stop()
+	inner, stop := newSSHStandIn(t, env.ClientKey)
+	defer stop()
+	machine, stop := newSSHStandIn(t, env.ClientKey)
+	defer stop()
+
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal("Error returned parsing URL:", err)
+	}
+
+	config := DefaultSSHTunnelConfig()
+	config.Endpoint = *endpoint
+	config.KnownHostsFile = writeKnownHosts(t, env.Dir, map[string]gossh.PublicKey{
+		bastion.Addr: bastion.HostKey,
+	})
+	config.JumpHosts = []SSHHop{
+		{Host: bastion.Addr, Username: "bastion", StrictHostKeyChecking: true},
+		{Host: inner.Addr, Username: "inner"},
+	}
+	config.StrictHostKeyChecking = false
+	config.Tunnel = machine.Addr
+	config.Timeout = 5 * time.Second
+
+	if body := getThroughTunnel(t, config, server.URL+"/fleet/v1/units"); body != "units" {
+		t.Fatal("Expected response of fleet endpoint, got:", body)
+	}
+
+	expectRecorded(t, "bastion users", bastion.recorded(&bastion.users), []string{"bastion"})
+	expectRecorded(t, "bastion forwards", bastion.recorded(&bastion.forwards), []string{inner.Addr})
+	expectRecorded(t, "inner users", inner.recorded(&inner.users), []string{"inner"})
+	expectRecorded(t, "inner forwards", inner.recorded(&inner.forwards), []string{machine.Addr})
+	expectRecorded(t, "machine users", machine.recorded(&machine.users), []string{"core"})
+	expectRecorded(t, "machine forwards", machine.recorded(&machine.forwards), []string{server.Listener.Addr().String()})
+}
+
+// TestSSHTunnel_JumpHosts_FDForward tests that fleetctl fd-forward is executed
+// on the last hop in case the fleet endpoint is a unix socket.
+func TestSSHTunnel_JumpHosts_FDForward(t *testing.T) {
+	env, cleanup := givenSSHTunnelTestEnv(t)
+	defer cleanup()
+
+	sock := filepath.Join(env.Dir, "fleet.sock")
+	listener, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal("Error returned by listen:", err)
+	}
+	defer listener.Close()
+	go http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "units")
+	}))
+
+	bastion, stop := newSSHStandIn(t, env.ClientKey)
+	defer stop()
+	machine, stop := newSSHStandIn(t, env.ClientKey)
+	defer stop()
+
+	config := DefaultSSHTunnelConfig()
+	config.Endpoint = url.URL{Scheme: "unix", Path: sock}
+	config.JumpHosts = []SSHHop{
+		{Host: bastion.Addr, Username: "bastion"},
+	}
+	config.StrictHostKeyChecking = false
+	config.Tunnel = machine.Addr
+	config.Timeout = 5 * time.Second
+
+	if body := getThroughTunnel(t, config, "http://domain-sock/fleet/v1/units"); body != "units" {
+		t.Fatal("Expected response of fleet endpoint, got:", body)
+	}
+
+	expectRecorded(t, "bastion commands", bastion.recorded(&bastion.commands), nil)
+	expectRecorded(t, "bastion forwards", bastion.recorded(&bastion.forwards), []string{machine.Addr})
+	expectRecorded(t, "machine commands", machine.recorded(&machine.commands), []string{"fleetctl fd-forward " + sock})
+}
+
+// TestSSHTunnel_JumpHosts_HostKeyMismatch tests that the tunnel is not
+// established in case a jump host presents an unknown host key.
+func TestSSHTunnel_JumpHosts_HostKeyMismatch(t *testing.T) {
+	env, cleanup := givenSSHTunnelTestEnv(t)
+	defer cleanup()
+
+	bastion, stop := newSSHStandIn(t, env.ClientKey)
+	defer stop()
+	machine, stop := newSSHStandIn(t, env.ClientKey)
+	defer stop()
+
+	config := DefaultSSHTunnelConfig()
+	config.KnownHostsFile = writeKnownHosts(t, env.Dir, map[string]gossh.PublicKey{
+		bastion.Addr: machine.HostKey,
+	})
+	config.JumpHosts = []SSHHop{
+		{Host: bastion.Addr, Username: "bastion", StrictHostKeyChecking: true},
+	}
+	config.StrictHostKeyChecking = false
+	config.Tunnel = machine.Addr
+	config.Timeout = 5 * time.Second
+
+	if _, err := NewSSHTunnel(config); err == nil {
+		t.Fatal("Expected error, got none")
+	}
+	if users := machine.recorded(&machine.users); len(users) != 0 {
+		t.Fatal("Expected machine not to be connected, got users:", users)
+	}
+}
+
+func Test_ParseSSHHop(t *testing.T) {
+	defaults := SSHHop{Username: "core", StrictHostKeyChecking: true}
+
+	testCases := []struct {
+		Input         string
+		Expected      SSHHop
+		ExpectedError bool
+	}{
+		{
+			Input:    "bastion.example.com",
+			Expected: SSHHop{Host: "bastion.example.com", Username: "core", StrictHostKeyChecking: true},
+		},
+		{
+			Input:    "admin@bastion.example.com:2222",
+			Expected: SSHHop{Host: "bastion.example.com:2222", Username: "admin", StrictHostKeyChecking: true},
+		},
+		{
+			Input:    "admin@bastion.example.com?strict-host-key-checking=false",
+			Expected: SSHHop{Host: "bastion.example.com", Username: "admin", StrictHostKeyChecking: false},
+		},
+		{
+			Input:    "bastion.example.com:2222?known-hosts-file=~/.ssh/bastion_hosts&strict-host-key-checking=true",
+			Expected: SSHHop{Host: "bastion.example.com:2222", Username: "core", StrictHostKeyChecking: true, KnownHostsFile: "~/.ssh/bastion_hosts"},
+		},
+		{
+			Input:         "@bastion.example.com",
+			ExpectedError: true,
+		},
+		{
+			Input:         "admin@",
+			ExpectedError: true,
+		},
+		{
+			Input:         ":22",
+			ExpectedError: true,
+		},
+		{
+			Input:         "?strict-host-key-checking=false",
+			ExpectedError: true,
+		},
+		{
+			Input:         "bastion.example.com?strict-host-key-checking=maybe",
+			ExpectedError: true,
+		},
+		{
+			Input:         "bastion.example.com?known-hosts-file=",
+			ExpectedError: true,
+		},
+		{
+			Input:         "bastion.example.com?port=22",
+			ExpectedError: true,
+		},
+	}
+
+	for i, testCase := range testCases {
+		hop, err := ParseSSHHop(testCase.Input, defaults)
+		if testCase.ExpectedError {
+			if !IsInvalidEndpoint(err) {
+				t.Fatalf("test case %d: expected invalid endpoint error, got: %v", i+1, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test case %d: unexpected error: %v", i+1, err)
+		}
+		if hop != testCase.Expected {
+			t.Fatalf("test case %d: expected %#v, got %#v", i+1, testCase.Expected, hop)
+		}
+	}
+}
+
+// TestSSHTunnel_MaxConnections tests that requests are sent in parallel using
+// up to the configured number of SSH connections.
+func TestSSHTunnel_MaxConnections(t *testing.T) {
+	env, cleanup := givenSSHTunnelTestEnv(t)
+	defer cleanup()
+
+	var mutex sync.Mutex
+	var inFlight, maxInFlight int
+	sock := filepath.Join(env.Dir, "fleet.sock")
+	listener, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal("Error returned by listen:", err)
+	}
+	defer listener.Close()
+	go http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mutex.Unlock()
+
+		time.Sleep(100 * time.Millisecond)
+
+		mutex.Lock()
+		inFlight--
+		mutex.Unlock()
+		fmt.Fprint(w, "units")
+	}))
+
+	machine, stop := newSSHStandIn(t, env.ClientKey)
+	defer stop()
+
+	config := DefaultSSHTunnelConfig()
+	config.Endpoint = url.URL{Scheme: "unix", Path: sock}
+	config.MaxConnections = 2
+	config.StrictHostKeyChecking = false
+	config.Tunnel = machine.Addr
+	config.Timeout = 5 * time.Second
+	tunnel, err := NewSSHTunnel(config)
+	if err != nil {
+		t.Fatal("Error returned by NewSSHTunnel:", err)
+	}
+	client := &http.Client{Transport: tunnel}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get("http://domain-sock/fleet/v1/units")
+			if err != nil {
+				errs <- err
+				return
+			}
+			ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal("Error returned requesting through tunnel:", err)
+	}
+
+	if maxInFlight != 2 {
+		t.Fatal("Expected 2 requests in parallel, got:", maxInFlight)
+	}
+	if users := machine.recorded(&machine.users); len(users) != 2 {
+		t.Fatal("Expected 2 SSH connections, got:", len(users))
+	}
+}
+
+func TestSSHTunnel_InvalidMaxConnections(t *testing.T) {
+	config := DefaultSSHTunnelConfig()
+	config.MaxConnections = 0
+	config.Tunnel = "127.0.0.1"
+
+	if _, err := NewSSHTunnel(config); !IsInvalidConfig(err) {
+		t.Fatal("Expected invalid config error, got:", err)
+	}
+}
+
+// givenIdentityFile writes the given key to a PEM encoded identity file. The
+// key is encrypted in case a passphrase is given.
+func givenIdentityFile(t *testing.T, dir string, key *ecdsa.PrivateKey, passphrase string) string {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal("Error returned marshaling key:", err)
+	}
+
+	block := &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	if passphrase != "" {
+		block, err = x509.EncryptPEMBlock(rand.Reader, block.Type, der, []byte(passphrase), x509.PEMCipherAES256)
+		if err != nil {
+			t.Fatal("Error returned encrypting key:", err)
+		}
+	}
+
+	file := filepath.Join(dir, "id_ecdsa")
+	if err := ioutil.WriteFile(file, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal("Error returned writing identity file:", err)
+	}
+	return file
+}
+
+// TestSSHTunnel_IdentityFile tests that the tunnel authenticates using an
+// encrypted identity file in case no ssh agent is running.
+func TestSSHTunnel_IdentityFile(t *testing.T) {
+	env, cleanup := givenSSHTunnelTestEnv(t)
+	defer cleanup()
+	os.Setenv("SSH_AUTH_SOCK", "")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "units")
+	}))
+	defer server.Close()
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal("Error returned parsing URL:", err)
+	}
+
+	machine, stop := newSSHStandIn(t, env.ClientKey)
+	defer stop()
+
+	config := DefaultSSHTunnelConfig()
+	config.Endpoint = *endpoint
+	config.StrictHostKeyChecking = false
+	config.Tunnel = machine.Addr
+	config.Timeout = 5 * time.Second
+
+	// Without agent and identity file, there is no way to authenticate.
+	if _, err := NewSSHTunnel(config); !IsInvalidConfig(err) {
+		t.Fatal("Expected invalid config error, got:", err)
+	}
+
+	config.IdentityFile = givenIdentityFile(t, env.Dir, env.Key, "secret")
+	if _, err := NewSSHTunnel(config); !IsInvalidConfig(err) {
+		t.Fatal("Expected invalid config error without passphrase, got:", err)
+	}
+
+	config.IdentityPassphrase = func() ([]byte, error) { return []byte("wrong"), nil }
+	if _, err := NewSSHTunnel(config); !IsInvalidConfig(err) {
+		t.Fatal("Expected invalid config error using wrong passphrase, got:", err)
+	}
+
+	var asked int
+	config.IdentityPassphrase = func() ([]byte, error) {
+		asked++
+		return []byte("secret"), nil
+	}
+	config.MaxConnections = 2
+	if body := getThroughTunnel(t, config, server.URL+"/fleet/v1/units"); body != "units" {
+		t.Fatal("Expected response of fleet endpoint, got:", body)
+	}
+	if asked != 1 {
+		t.Fatal("Expected passphrase to be asked for once, got:", asked)
+	}
+	expectRecorded(t, "machine users", machine.recorded(&machine.users), []string{"core"})
+}
+
+// TestSSHTunnel_ForwardAgent tests that the ssh agent given by socket is only
+// forwarded to the tunnel host in case agent forwarding is enabled.
+func TestSSHTunnel_ForwardAgent(t *testing.T) {
+	env, cleanup := givenSSHTunnelTestEnv(t)
+	defer cleanup()
+	os.Setenv("SSH_AUTH_SOCK", "")
+
+	sock := filepath.Join(env.Dir, "fleet.sock")
+	listener, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal("Error returned by listen:", err)
+	}
+	defer listener.Close()
+	go http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "units")
+	}))
+
+	for _, forwardAgent := range []bool{true, false} {
+		machine, stop := newSSHStandIn(t, env.ClientKey)
+		defer stop()
+
+		config := DefaultSSHTunnelConfig()
+		config.AgentSocket = env.AgentSocket
+		config.Endpoint = url.URL{Scheme: "unix", Path: sock}
+		config.ForwardAgent = forwardAgent
+		config.StrictHostKeyChecking = false
+		config.Tunnel = machine.Addr
+		config.Timeout = 5 * time.Second
+
+		if body := getThroughTunnel(t, config, "http://domain-sock/fleet/v1/units"); body != "units" {
+			t.Fatal("Expected response of fleet endpoint, got:", body)
+		}
+
+		var forwarded bool
+		for _, request := range machine.recorded(&machine.requests) {
+			if request == "auth-agent-req@openssh.com" {
+				forwarded = true
+			}
+		}
+		if forwarded != forwardAgent {
+			t.Fatalf("Expected agent forwarding to be %t, got %t", forwardAgent, forwarded)
+		}
+	}
+}
+
+// recordingLogger records the messages of all log levels.
+type recordingLogger struct {
+	mutex    sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) record(f string, v ...interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.messages = append(l.messages, fmt.Sprintf(f, v...))
+}
+
+func (l *recordingLogger) Debug(ctx context.Context, f string, v ...interface{}) {
+	l.record(f, v...)
+}
+
+func (l *recordingLogger) Info(ctx context.Context, f string, v ...interface{}) {
+	l.record(f, v...)
+}
+
+func (l *recordingLogger) Notice(ctx context.Context, f string, v ...interface{}) {
+	l.record(f, v...)
+}
+
+func (l *recordingLogger) Warning(ctx context.Context, f string, v ...interface{}) {
+	l.record(f, v...)
+}
+
+func (l *recordingLogger) Error(ctx context.Context, f string, v ...interface{}) {
+	l.record(f, v...)
+}
+
+func (l *recordingLogger) Critical(ctx context.Context, f string, v ...interface{}) {
+	l.record(f, v...)
+}
+
+// logged returns whether any message containing the given string was logged.
+func (l *recordingLogger) logged(s string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, message := range l.messages {
+		if strings.Contains(message, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// givenReconnectingTunnel returns a tunnel to the given stand-in, sending
+// requests to a HTTP server responding "units", using a single connection.
+func givenReconnectingTunnel(t *testing.T, machine *sshStandIn, keepAliveInterval time.Duration) (*http.Client, *recordingLogger, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "units")
+	}))
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal("Error returned parsing URL:", err)
+	}
+
+	logger := &recordingLogger{}
+	config := DefaultSSHTunnelConfig()
+	config.Endpoint = *endpoint
+	config.KeepAliveInterval = keepAliveInterval
+	config.Logger = logger
+	config.MaxConnections = 1
+	config.StrictHostKeyChecking = false
+	config.Tunnel = machine.Addr
+	config.Timeout = time.Second
+	tunnel, err := NewSSHTunnel(config)
+	if err != nil {
+		t.Fatal("Error returned by NewSSHTunnel:", err)
+	}
+
+	client := &http.Client{
+		Transport: &urlRewriter{Host: endpoint.Host, RoundTripper: tunnel},
+	}
+
+	return client, logger, server.Close
+}
+
+// urlRewriter sends all requests to the given host.
+type urlRewriter struct {
+	Host string
+	http.RoundTripper
+}
+
+func (r *urlRewriter) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Host = r.Host
+	return r.RoundTripper.RoundTrip(req)
+}
+
+func expectUnits(t *testing.T, client *http.Client) {
+	resp, err := client.Get("http://fleet/fleet/v1/units")
+	if err != nil {
+		t.Fatal("Error returned requesting through tunnel:", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal("Error returned reading response:", err)
+	}
+	if string(body) != "units" {
+		t.Fatal("Expected response of fleet endpoint, got:", string(body))
+	}
+}
+
+// TestSSHTunnel_Reconnect tests that requests sent after the SSH connection
+// dropped are sent using a new connection.
+func TestSSHTunnel_Reconnect(t *testing.T) {
+	env, cleanup := givenSSHTunnelTestEnv(t)
+	defer cleanup()
+
+	machine, stop := newSSHStandIn(t, env.ClientKey)
+	defer stop()
+	client, logger, stopServer := givenReconnectingTunnel(t, machine, 0)
+	defer stopServer()
+
+	expectUnits(t, client)
+	machine.drop()
+	expectUnits(t, client)
+
+	if users := machine.recorded(&machine.users); len(users) != 2 {
+		t.Fatal("Expected tunnel to reconnect once, got connections:", len(users))
+	}
+	if !logger.logged("reconnected ssh tunnel") {
+		t.Fatal("Expected reconnect to be logged, got:", logger.messages)
+	}
+}
+
+// TestSSHTunnel_Reconnect_Failed tests that requests fail while the tunnel
+// host cannot be reached, and succeed again once it can.
+func TestSSHTunnel_Reconnect_Failed(t *testing.T) {
+	env, cleanup := givenSSHTunnelTestEnv(t)
+	defer cleanup()
+
+	machine, stop := newSSHStandIn(t, env.ClientKey)
+	defer stop()
+	client, logger, stopServer := givenReconnectingTunnel(t, machine, 0)
+	defer stopServer()
+
+	expectUnits(t, client)
+	// Connections are refused until the listener is restarted.
+	stop()
+	machine.drop()
+	if _, err := client.Get("http://fleet/fleet/v1/units"); err == nil {
+		t.Fatal("Expected request to fail, got none")
+	}
+	if !logger.logged("reconnecting ssh tunnel") || !logger.logged("failed") {
+		t.Fatal("Expected failed reconnect to be logged, got:", logger.messages)
+	}
+
+	restarted, stop := newSSHStandInAt(t, env.ClientKey, machine.Addr)
+	defer stop()
+
+	expectUnits(t, client)
+	if users := restarted.recorded(&restarted.users); len(users) != 1 {
+		t.Fatal("Expected tunnel to reconnect to restarted host, got connections:", len(users))
+	}
+}
+
+// TestSSHTunnel_KeepAlive tests that connections not answering keepalive
+// probes are replaced by new ones.
+func TestSSHTunnel_KeepAlive(t *testing.T) {
+	env, cleanup := givenSSHTunnelTestEnv(t)
+	defer cleanup()
+
+	machine, stop := newSSHStandIn(t, env.ClientKey)
+	defer stop()
+	client, logger, stopServer := givenReconnectingTunnel(t, machine, 10*time.Millisecond)
+	defer stopServer()
+
+	expectUnits(t, client)
+	time.Sleep(100 * time.Millisecond)
+	var probes int
+	for _, request := range machine.recorded(&machine.global) {
+		if request == "keepalive@openssh.com" {
+			probes++
+		}
+	}
+	if probes == 0 {
+		t.Fatal("Expected keepalive probes to be sent, got none")
+	}
+
+	machine.setUnresponsive(true)
+	for i := 0; !logger.logged("broken"); i++ {
+		if i == 100 {
+			t.Fatal("Expected unresponsive connection to be detected, got:", logger.messages)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	machine.setUnresponsive(false)
+
+	expectUnits(t, client)
+	if users := machine.recorded(&machine.users); len(users) != 2 {
+		t.Fatal("Expected tunnel to reconnect once, got connections:", len(users))
+	}
+}
@@ -0,0 +1,59 @@
+package fleet
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+)
+
+// usesTLSConfig returns whether any of the TLS options of the given
+// configuration is set.
+func usesTLSConfig(config Config) bool {
+	return config.CAFile != "" || config.CertFile != "" || config.KeyFile != "" || config.InsecureSkipVerify
+}
+
+// newTLSTransport returns a transport for https endpoints, configured using
+// the TLS options of the given configuration.
+func newTLSTransport(config Config) (http.RoundTripper, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	if config.CAFile != "" {
+		pemBytes, err := ioutil.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(pemBytes) {
+			return nil, maskAnyf(invalidConfigError, "no certificates found in CA file '%s'", config.CAFile)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		if config.CertFile == "" || config.KeyFile == "" {
+			return nil, maskAnyf(invalidConfigError, "cert file and key file must be given together")
+		}
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, maskAnyf(invalidConfigError, "%s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	newTransport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		Dial: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).Dial,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+
+	return newTransport, nil
+}
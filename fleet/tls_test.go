@@ -0,0 +1,225 @@
+package fleet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/retry"
+)
+
+// writePEM writes the given DER bytes PEM encoded to the given file.
+func writePEM(t *testing.T, file, blockType string, der []byte) {
+	block := &pem.Block{Type: blockType, Bytes: der}
+	if err := ioutil.WriteFile(file, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal("Error returned writing PEM file:", err)
+	}
+}
+
+// givenClientCert creates a CA and a client certificate signed by it. The
+// client certificate and its key are written to the given directory. The CA
+// is returned.
+func givenClientCert(t *testing.T, dir string) *x509.CertPool {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("Error returned generating key:", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "inago test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal("Error returned creating CA certificate:", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal("Error returned parsing CA certificate:", err)
+	}
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("Error returned generating key:", err)
+	}
+	clientTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "inagoctl"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	clientDER, err := x509.CreateCertificate(rand.Reader, clientTemplate, caCert, &clientKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal("Error returned creating client certificate:", err)
+	}
+	clientKeyDER, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatal("Error returned marshaling key:", err)
+	}
+
+	writePEM(t, filepath.Join(dir, "client.pem"), "CERTIFICATE", clientDER)
+	writePEM(t, filepath.Join(dir, "client-key.pem"), "EC PRIVATE KEY", clientKeyDER)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+	return pool
+}
+
+func TestNewFleet_TLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inago-tls")
+	if err != nil {
+		t.Fatal("Error returned creating temporary directory:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	clientCAs := givenClientCert(t, dir)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"units":[]}`)
+	}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	// The server's certificate is self-signed, so it serves as CA.
+	caFile := filepath.Join(dir, "ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", server.TLS.Certificates[0].Certificate[0])
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal("Error returned parsing URL:", err)
+	}
+
+	testCases := []struct {
+		CAFile             string
+		CertFile           string
+		KeyFile            string
+		InsecureSkipVerify bool
+		ExpectedSuccess    bool
+	}{
+		// Both sides are verified.
+		{
+			CAFile:          caFile,
+			CertFile:        certFile,
+			KeyFile:         keyFile,
+			ExpectedSuccess: true,
+		},
+		// The server requires a client certificate.
+		{
+			CAFile:          caFile,
+			ExpectedSuccess: false,
+		},
+		// The server's certificate is not signed by a CA of the system.
+		{
+			CertFile:        certFile,
+			KeyFile:         keyFile,
+			ExpectedSuccess: false,
+		},
+		// The server's certificate is not verified.
+		{
+			CertFile:           certFile,
+			KeyFile:            keyFile,
+			InsecureSkipVerify: true,
+			ExpectedSuccess:    true,
+		},
+	}
+
+	for i, testCase := range testCases {
+		newRetryPolicyConfig := retry.DefaultConfig()
+		newRetryPolicyConfig.MaxAttempts = 1
+
+		newConfig := DefaultConfig()
+		newConfig.CAFile = testCase.CAFile
+		newConfig.CertFile = testCase.CertFile
+		newConfig.Endpoint = *endpoint
+		newConfig.InsecureSkipVerify = testCase.InsecureSkipVerify
+		newConfig.KeyFile = testCase.KeyFile
+		newConfig.RetryPolicy = retry.NewPolicy(newRetryPolicyConfig)
+		newFleet, err := NewFleet(newConfig)
+		if err != nil {
+			t.Fatalf("test case %d: error returned by NewFleet: %v", i+1, err)
+		}
+
+		// The server knows no units. Thus reaching it results in a unit not
+		// found error.
+		_, err = newFleet.GetStatusWithMatcher(context.Background(), func(string) bool { return true })
+		if success := IsUnitNotFound(err); success != testCase.ExpectedSuccess {
+			t.Fatalf("test case %d: expected success to be %t, got error: %v", i+1, testCase.ExpectedSuccess, err)
+		}
+	}
+}
+
+func TestNewFleet_TLS_InvalidConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inago-tls")
+	if err != nil {
+		t.Fatal("Error returned creating temporary directory:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	givenClientCert(t, dir)
+	invalidFile := filepath.Join(dir, "invalid.pem")
+	if err := ioutil.WriteFile(invalidFile, []byte("invalid"), 0600); err != nil {
+		t.Fatal("Error returned writing file:", err)
+	}
+
+	testCases := []Config{
+		{CertFile: filepath.Join(dir, "client.pem")},
+		{KeyFile: filepath.Join(dir, "client-key.pem")},
+		{CAFile: invalidFile},
+		{CertFile: invalidFile, KeyFile: filepath.Join(dir, "client-key.pem")},
+	}
+
+	for i, testCase := range testCases {
+		newConfig := DefaultConfig()
+		newConfig.CAFile = testCase.CAFile
+		newConfig.CertFile = testCase.CertFile
+		newConfig.Endpoint = url.URL{Scheme: "https", Host: "127.0.0.1:49153"}
+		newConfig.KeyFile = testCase.KeyFile
+		if _, err := NewFleet(newConfig); !IsInvalidConfig(err) {
+			t.Fatalf("test case %d: expected invalid config error, got: %v", i+1, err)
+		}
+	}
+}
+
+func TestNewFleet_TLS_NonHTTPSEndpoint(t *testing.T) {
+	testCases := []url.URL{
+		{Scheme: "http", Host: "127.0.0.1:49153"},
+		{Scheme: "unix", Path: "/var/run/fleet.sock"},
+		{Scheme: "file", Path: "/var/run/fleet.sock"},
+	}
+
+	for i, testCase := range testCases {
+		newConfig := DefaultConfig()
+		newConfig.Endpoint = testCase
+		newConfig.InsecureSkipVerify = true
+		if _, err := NewFleet(newConfig); !IsInvalidConfig(err) {
+			t.Fatalf("test case %d: expected invalid config error, got: %v", i+1, err)
+		}
+	}
+}
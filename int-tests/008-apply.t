@@ -0,0 +1,48 @@
+Create a group to apply.
+  $ mkdir apply-group
+  $ printf "[Unit]\nDescription=Inago Apply Test Unit\n\n[Service]\nExecStart=/bin/bash -c \"while true; do echo Hi; sleep 10; done\"\n" > apply-group/apply-group-unit@.service
+
+
+Apply the group, which submits and starts two slices.
+  $ inagoctl --fleet-endpoint=${FLEET_ENDPOINT} apply --slices 2 apply-group 2>&1 | grep Succeeded
+  .*\|\scontext.Background: Succeeded to apply group 'apply-group'. (re)
+  $ sleep 5
+  $ inagoctl --fleet-endpoint=${FLEET_ENDPOINT} status apply-group
+  Group\s*Units\s*FDState\s*FCState\s*SAState\s*IP\s*Machine (re)
+  
+  apply-group@[a-z\d]{3}\s*\*\s*launched\s*launched\s*active\s*[0-9.]*\s*[a-z0-9]* (re)
+  apply-group@[a-z\d]{3}\s*\*\s*launched\s*launched\s*active\s*[0-9.]*\s*[a-z0-9]* (re)
+  
+
+
+Applying the unchanged group again keeps it as it is.
+  $ inagoctl --fleet-endpoint=${FLEET_ENDPOINT} apply apply-group 2>&1 | grep Succeeded
+  .*\|\scontext.Background: Succeeded to apply group 'apply-group'. (re)
+  $ inagoctl --fleet-endpoint=${FLEET_ENDPOINT} status apply-group
+  Group\s*Units\s*FDState\s*FCState\s*SAState\s*IP\s*Machine (re)
+  
+  apply-group@[a-z\d]{3}\s*\*\s*launched\s*launched\s*active\s*[0-9.]*\s*[a-z0-9]* (re)
+  apply-group@[a-z\d]{3}\s*\*\s*launched\s*launched\s*active\s*[0-9.]*\s*[a-z0-9]* (re)
+  
+
+
+Change the group and apply it with three slices.
+  $ printf "[Unit]\nDescription=Inago Apply Test Unit Changed\n\n[Service]\nExecStart=/bin/bash -c \"while true; do echo Hi; sleep 10; done\"\n" > apply-group/apply-group-unit@.service
+  $ inagoctl --fleet-endpoint=${FLEET_ENDPOINT} apply --slices 3 --ready-secs 5 apply-group 2>&1 | grep Succeeded
+  .*\|\scontext.Background: Succeeded to apply group 'apply-group'. (re)
+  $ sleep 5
+  $ inagoctl --fleet-endpoint=${FLEET_ENDPOINT} status apply-group
+  Group\s*Units\s*FDState\s*FCState\s*SAState\s*IP\s*Machine (re)
+  
+  apply-group@[a-z\d]{3}\s*\*\s*launched\s*launched\s*active\s*[0-9.]*\s*[a-z0-9]* (re)
+  apply-group@[a-z\d]{3}\s*\*\s*launched\s*launched\s*active\s*[0-9.]*\s*[a-z0-9]* (re)
+  apply-group@[a-z\d]{3}\s*\*\s*launched\s*launched\s*active\s*[0-9.]*\s*[a-z0-9]* (re)
+  
+
+
+Apply the group with zero slices, which destroys it.
+  $ inagoctl --fleet-endpoint=${FLEET_ENDPOINT} apply --slices 0 apply-group 2>&1 | grep Succeeded
+  .*\|\scontext.Background: Succeeded to apply group 'apply-group'. (re)
+  $ inagoctl --fleet-endpoint=${FLEET_ENDPOINT} status apply-group
+  .*\|\scontext.Background: Failed to find group 'apply-group'. (re)
+  [1]
@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	gologging "github.com/op/go-logging"
+	"golang.org/x/net/context"
+)
+
+// ContextFields maps the context keys known to Inago to the names of the
+// fields their values are logged as by the JSONLogger. "task-id" is the key of
+// task.ContextTaskID. The other keys are used by the controller during
+// updates.
+var ContextFields = map[string]string{
+	"task-id":      "task_id",
+	"slice ID":     "slice_id",
+	"add slice":    "add_slice",
+	"remove slice": "remove_slice",
+}
+
+// NewJSONLogger returns a JSONLogger, given a Config.
+func NewJSONLogger(config Config) *JSONLogger {
+	return newJSONLogger(config, os.Stderr)
+}
+
+func newJSONLogger(config Config, w io.Writer) *JSONLogger {
+	level, err := gologging.LogLevel(config.LogLevel)
+	if err != nil {
+		panic(err)
+	}
+
+	return &JSONLogger{
+		level:  level,
+		writer: w,
+	}
+}
+
+// JSONLogger is a Logger printing each log record as one JSON object per
+// line. Values of known context keys are printed as separate fields. See
+// ContextFields.
+type JSONLogger struct {
+	level  gologging.Level
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+// Debug logs on the Debug level.
+func (jl *JSONLogger) Debug(ctx context.Context, f string, v ...interface{}) {
+	jl.log(ctx, gologging.DEBUG, f, v...)
+}
+
+// Info logs on the Info level.
+func (jl *JSONLogger) Info(ctx context.Context, f string, v ...interface{}) {
+	jl.log(ctx, gologging.INFO, f, v...)
+}
+
+// Notice logs on the Notice level.
+func (jl *JSONLogger) Notice(ctx context.Context, f string, v ...interface{}) {
+	jl.log(ctx, gologging.NOTICE, f, v...)
+}
+
+// Warning logs on the Warning level.
+func (jl *JSONLogger) Warning(ctx context.Context, f string, v ...interface{}) {
+	jl.log(ctx, gologging.WARNING, f, v...)
+}
+
+// Error logs on the Error level.
+func (jl *JSONLogger) Error(ctx context.Context, f string, v ...interface{}) {
+	jl.log(ctx, gologging.ERROR, f, v...)
+}
+
+// Critical logs on the Critical level.
+func (jl *JSONLogger) Critical(ctx context.Context, f string, v ...interface{}) {
+	jl.log(ctx, gologging.CRITICAL, f, v...)
+}
+
+func (jl *JSONLogger) log(ctx context.Context, level gologging.Level, f string, v ...interface{}) {
+	// Levels are ordered from CRITICAL (0) to DEBUG (5).
+	if level > jl.level {
+		return
+	}
+
+	record := map[string]interface{}{
+		"time":    time.Now().UTC().Format(time.RFC3339Nano),
+		"level":   level.String(),
+		"message": fmt.Sprintf(f, v...),
+	}
+	if ctx != nil {
+		for key, field := range ContextFields {
+			if value := ctx.Value(key); value != nil {
+				record[field] = value
+			}
+		}
+	}
+
+	raw, err := json.Marshal(record)
+	if err != nil {
+		// Context values are not guaranteed to be serializable. Fall back to
+		// their string representation.
+		for _, field := range ContextFields {
+			if value, ok := record[field]; ok {
+				record[field] = fmt.Sprintf("%v", value)
+			}
+		}
+		raw, err = json.Marshal(record)
+		if err != nil {
+			return
+		}
+	}
+
+	jl.mutex.Lock()
+	defer jl.mutex.Unlock()
+	jl.writer.Write(append(raw, '\n'))
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func Test_JSONLogger(t *testing.T) {
+	newConfig := DefaultConfig()
+	newConfig.LogLevel = "INFO"
+	out := bytes.NewBuffer(nil)
+	newLogger := newJSONLogger(newConfig, out)
+
+	ctx := context.WithValue(context.Background(), "task-id", "1234")
+	ctx = context.WithValue(ctx, "add slice", []string{"a1b"})
+
+	newLogger.Debug(ctx, "not logged")
+	newLogger.Info(ctx, "adding %s", "units")
+	newLogger.Error(nil, "failed")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got: %q", lines)
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("log line is not valid JSON: %#v", err)
+	}
+	if record["level"] != "INFO" || record["message"] != "adding units" {
+		t.Fatalf("unexpected record: %#v", record)
+	}
+	if record["task_id"] != "1234" {
+		t.Fatalf("expected task_id field, got: %#v", record)
+	}
+	if addSlice, ok := record["add_slice"].([]interface{}); !ok || len(addSlice) != 1 || addSlice[0] != "a1b" {
+		t.Fatalf("expected add_slice field, got: %#v", record)
+	}
+	if _, ok := record["time"]; !ok {
+		t.Fatalf("expected time field, got: %#v", record)
+	}
+
+	record = nil
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Fatalf("log line is not valid JSON: %#v", err)
+	}
+	if record["level"] != "ERROR" || record["message"] != "failed" {
+		t.Fatalf("unexpected record: %#v", record)
+	}
+	if _, ok := record["task_id"]; ok {
+		t.Fatalf("expected no task_id field, got: %#v", record)
+	}
+}
+
+func Test_JSONLogger_UnserializableContextValue(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	newLogger := newJSONLogger(DefaultConfig(), out)
+
+	ctx := context.WithValue(context.Background(), "slice ID", func() {})
+	newLogger.Info(ctx, "test")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("log line is not valid JSON: %#v", err)
+	}
+	if _, ok := record["slice_id"].(string); !ok {
+		t.Fatalf("expected slice_id field as string, got: %#v", record)
+	}
+}
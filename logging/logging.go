@@ -2,6 +2,7 @@
 package logging
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/mattn/go-isatty"
@@ -41,18 +42,36 @@ type Config struct {
 	LogLevel string
 	// Color determines whether logs are printed with color, where supported.
 	Color bool
+	// Format determines the format logs are printed in. See FormatText and
+	// FormatJSON.
+	Format string
 }
 
+const (
+	// FormatText represents logs printed as human readable lines.
+	FormatText = "text"
+	// FormatJSON represents logs printed as one JSON object per line.
+	FormatJSON = "json"
+)
+
 // DefaultConfig returns a Config set by best effort.
 func DefaultConfig() Config {
 	return Config{
 		Name:     "inago",
 		LogLevel: "INFO",
 		Color:    isatty.IsTerminal(os.Stderr.Fd()),
+		Format:   FormatText,
 	}
 }
 
-// NewLogger returns a Logger.
+// NewLogger returns a Logger printing logs in the configured format.
 func NewLogger(config Config) Logger {
-	return NewGoLoggingLogger(config)
+	switch config.Format {
+	case "", FormatText:
+		return NewGoLoggingLogger(config)
+	case FormatJSON:
+		return NewJSONLogger(config)
+	default:
+		panic(fmt.Sprintf("unknown log format: %s", config.Format))
+	}
 }
@@ -0,0 +1,160 @@
+// Package retry provides retry policies to execute operations that might fail
+// temporarily, e.g. because of network hiccups or overloaded endpoints.
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/giantswarm/inago/logging"
+)
+
+// Operation represents any work to be done that might need to be retried.
+type Operation func() error
+
+// Classifier decides whether the given error is worth retrying the operation
+// that caused it.
+type Classifier func(err error) bool
+
+// Config represents the configurations for the retry policy that is going to
+// be created.
+type Config struct {
+	// Dependencies.
+
+	// Logger provides an initialised logger.
+	Logger logging.Logger
+
+	// Settings.
+
+	// MaxAttempts represents the maximum number of times an operation is
+	// executed. A value of 1 disables retrying.
+	MaxAttempts int
+
+	// InitialBackoff represents the time to wait after the first failed
+	// attempt.
+	InitialBackoff time.Duration
+
+	// MaxBackoff represents the upper limit of the time to wait between two
+	// attempts.
+	MaxBackoff time.Duration
+
+	// Multiplier represents the factor the backoff grows with after each failed
+	// attempt.
+	Multiplier float64
+
+	// Jitter represents the fraction of the backoff being randomized. E.g. a
+	// jitter of 0.2 and a backoff of 1 second results in a randomized backoff
+	// between 0.8 and 1.2 seconds.
+	Jitter float64
+
+	// IsRetryable decides whether a failed operation is retried. Errors that
+	// are not retryable are returned immediately.
+	IsRetryable Classifier
+}
+
+// DefaultConfig returns a best effort default configuration for the retry
+// policy. Note that the default policy considers all errors to be retryable.
+func DefaultConfig() Config {
+	newConfig := Config{
+		Logger:         logging.NewLogger(logging.DefaultConfig()),
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		IsRetryable: func(err error) bool {
+			return true
+		},
+	}
+
+	return newConfig
+}
+
+// Policy represents a retry policy being able to execute operations until they
+// succeed, or the policy decides to give up.
+type Policy interface {
+	// Execute executes the given operation. In case the operation fails with an
+	// error that is retryable, it is executed again after an exponentially
+	// growing backoff, until the maximum number of attempts is reached. The
+	// error of the last attempt is returned. In case the given context is
+	// cancelled while waiting for the next attempt, the error of the last
+	// attempt is returned immediately.
+	Execute(ctx context.Context, operation Operation) error
+}
+
+// NewPolicy returns a new configured retry policy.
+func NewPolicy(config Config) Policy {
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+
+	newPolicy := &policy{
+		Config: config,
+	}
+
+	return newPolicy
+}
+
+// NewNoRetryPolicy returns a policy executing each operation exactly once.
+// It is used in case retrying is left to another layer, or no policy is
+// configured at all.
+func NewNoRetryPolicy() Policy {
+	return noRetryPolicy{}
+}
+
+type noRetryPolicy struct{}
+
+func (p noRetryPolicy) Execute(ctx context.Context, operation Operation) error {
+	return maskAny(operation())
+}
+
+type policy struct {
+	Config
+}
+
+func (p *policy) Execute(ctx context.Context, operation Operation) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := operation()
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= p.MaxAttempts {
+			p.Logger.Debug(ctx, "retry: giving up after %d attempts", attempt)
+			return maskAny(err)
+		}
+		if !p.IsRetryable(err) {
+			p.Logger.Debug(ctx, "retry: error is not retryable: %v", err)
+			return maskAny(err)
+		}
+
+		backoff := p.Backoff(attempt)
+		p.Logger.Warning(ctx, "retry: attempt %d of %d failed, retrying in %v: %v", attempt, p.MaxAttempts, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return maskAny(err)
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// Backoff returns the randomized time to wait after the given failed attempt.
+func (p *policy) Backoff(attempt int) time.Duration {
+	backoff := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+
+	delta := p.Jitter * backoff
+	backoff = backoff - delta + rand.Float64()*2*delta
+
+	return time.Duration(backoff)
+}
@@ -0,0 +1,181 @@
+package retry
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/juju/errgo"
+	"golang.org/x/net/context"
+)
+
+func testConfig() Config {
+	newConfig := DefaultConfig()
+	newConfig.InitialBackoff = 1 * time.Millisecond
+	newConfig.MaxBackoff = 5 * time.Millisecond
+
+	return newConfig
+}
+
+func Test_Retry_Policy_Execute(t *testing.T) {
+	transientError := fmt.Errorf("transient error")
+	permanentError := fmt.Errorf("permanent error")
+
+	testCases := []struct {
+		MaxAttempts      int
+		Errors           []error
+		ExpectedAttempts int
+		ExpectedError    error
+	}{
+		// This test ensures that a succeeding operation is executed once.
+		{
+			MaxAttempts:      3,
+			Errors:           []error{nil},
+			ExpectedAttempts: 1,
+			ExpectedError:    nil,
+		},
+
+		// This test ensures that a failing operation is retried until it
+		// succeeds.
+		{
+			MaxAttempts:      3,
+			Errors:           []error{transientError, transientError, nil},
+			ExpectedAttempts: 3,
+			ExpectedError:    nil,
+		},
+
+		// This test ensures that the error of the last attempt is returned once
+		// the maximum number of attempts is reached.
+		{
+			MaxAttempts:      2,
+			Errors:           []error{transientError, transientError, nil},
+			ExpectedAttempts: 2,
+			ExpectedError:    transientError,
+		},
+
+		// This test ensures that errors which are not retryable are returned
+		// immediately.
+		{
+			MaxAttempts:      3,
+			Errors:           []error{permanentError, nil},
+			ExpectedAttempts: 1,
+			ExpectedError:    permanentError,
+		},
+
+		// This test ensures that an invalid maximum number of attempts still
+		// executes the operation once.
+		{
+			MaxAttempts:      0,
+			Errors:           []error{transientError, nil},
+			ExpectedAttempts: 1,
+			ExpectedError:    transientError,
+		},
+	}
+
+	for i, testCase := range testCases {
+		newConfig := testConfig()
+		newConfig.MaxAttempts = testCase.MaxAttempts
+		newConfig.IsRetryable = func(err error) bool {
+			return errgo.Cause(err) == transientError
+		}
+		newPolicy := NewPolicy(newConfig)
+
+		attempts := 0
+		err := newPolicy.Execute(context.Background(), func() error {
+			err := testCase.Errors[attempts]
+			attempts++
+			return err
+		})
+
+		if errgo.Cause(err) != testCase.ExpectedError {
+			t.Fatalf("test case %d: expected error %v got %v", i+1, testCase.ExpectedError, err)
+		}
+		if attempts != testCase.ExpectedAttempts {
+			t.Fatalf("test case %d: expected %d attempts got %d", i+1, testCase.ExpectedAttempts, attempts)
+		}
+	}
+}
+
+func Test_Retry_Policy_Execute_Cancel(t *testing.T) {
+	newConfig := testConfig()
+	newConfig.InitialBackoff = 1 * time.Hour
+	newConfig.MaxBackoff = 1 * time.Hour
+	newPolicy := NewPolicy(newConfig)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	attempts := 0
+	err := newPolicy.Execute(ctx, func() error {
+		attempts++
+		return fmt.Errorf("test error")
+	})
+
+	if err == nil {
+		t.Fatalf("expected error got nil")
+	}
+	if attempts != 1 {
+		t.Fatalf("expected 1 attempt got %d", attempts)
+	}
+}
+
+func Test_Retry_NoRetryPolicy_Execute(t *testing.T) {
+	newPolicy := NewNoRetryPolicy()
+
+	attempts := 0
+	err := newPolicy.Execute(context.Background(), func() error {
+		attempts++
+		return fmt.Errorf("test error")
+	})
+
+	if err == nil {
+		t.Fatalf("expected error got nil")
+	}
+	if attempts != 1 {
+		t.Fatalf("expected 1 attempt got %d", attempts)
+	}
+}
+
+func Test_Retry_Policy_Backoff(t *testing.T) {
+	newConfig := DefaultConfig()
+	newConfig.InitialBackoff = 100 * time.Millisecond
+	newConfig.MaxBackoff = 1 * time.Second
+	newConfig.Multiplier = 2
+	newConfig.Jitter = 0.2
+	newPolicy := NewPolicy(newConfig).(*policy)
+
+	testCases := []struct {
+		Attempt int
+		Min     time.Duration
+		Max     time.Duration
+	}{
+		{
+			Attempt: 1,
+			Min:     80 * time.Millisecond,
+			Max:     120 * time.Millisecond,
+		},
+		{
+			Attempt: 2,
+			Min:     160 * time.Millisecond,
+			Max:     240 * time.Millisecond,
+		},
+		{
+			Attempt: 3,
+			Min:     320 * time.Millisecond,
+			Max:     480 * time.Millisecond,
+		},
+		// The backoff is capped by MaxBackoff before applying the jitter.
+		{
+			Attempt: 10,
+			Min:     800 * time.Millisecond,
+			Max:     1200 * time.Millisecond,
+		},
+	}
+
+	for i, testCase := range testCases {
+		backoff := newPolicy.Backoff(testCase.Attempt)
+		if backoff < testCase.Min || backoff > testCase.Max {
+			t.Fatalf("test case %d: expected backoff between %v and %v got %v", i+1, testCase.Min, testCase.Max, backoff)
+		}
+	}
+}
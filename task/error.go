@@ -17,3 +17,37 @@ var taskObjectNotFoundError = errgo.New("task object not found")
 func IsTaskObjectNotFound(err error) bool {
 	return errgo.Cause(err) == taskObjectNotFoundError
 }
+
+var taskCancelledError = errgo.New("task cancelled")
+
+// IsTaskCancelled checks whether the given error indicates the problem of a
+// task being cancelled or not. The error of a task object having the final
+// status "cancelled" can be identified using this method.
+func IsTaskCancelled(err error) bool {
+	return errgo.Cause(err) == taskCancelledError
+}
+
+var taskAbandonedError = errgo.New("task abandoned")
+
+// IsTaskAbandoned checks whether the given error indicates the problem of a
+// task being abandoned or not. A task is abandoned in case the process
+// executing it exited before the task reached a final status.
+func IsTaskAbandoned(err error) bool {
+	return errgo.Cause(err) == taskAbandonedError
+}
+
+var invalidConfigError = errgo.New("invalid config")
+
+// IsInvalidConfig checks whether the given error indicates the problem of an
+// invalid configuration or not.
+func IsInvalidConfig(err error) bool {
+	return errgo.Cause(err) == invalidConfigError
+}
+
+var invalidTaskIDError = errgo.New("invalid task ID")
+
+// IsInvalidTaskID checks whether the given error indicates the problem of a
+// task ID that cannot be used to store a task object or not.
+func IsInvalidTaskID(err error) bool {
+	return errgo.Cause(err) == invalidTaskIDError
+}
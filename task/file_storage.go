@@ -0,0 +1,235 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FileStorageConfig represents the configuration used to create a new file
+// storage.
+type FileStorageConfig struct {
+	// Dir is the directory task objects are stored in. It is created in case it
+	// does not exist.
+	Dir string
+
+	// MaxAge is the age after which stored task objects are removed when
+	// creating a new file storage. Zero disables the removal.
+	MaxAge time.Duration
+}
+
+// DefaultFileStorageConfig returns a best effort default configuration for the
+// file storage. Tasks are stored in ~/.inago/tasks, the default task directory
+// of inagoctl.
+func DefaultFileStorageConfig() FileStorageConfig {
+	newConfig := FileStorageConfig{
+		Dir:    filepath.Join(os.Getenv("HOME"), ".inago", "tasks"),
+		MaxAge: 7 * 24 * time.Hour,
+	}
+
+	return newConfig
+}
+
+// NewFileStorage creates a backend implementation persisting task objects as
+// files, one per task. This way tasks can be looked up by processes other than
+// the one executing them. Files are written atomically, so concurrent readers
+// never see partially written task objects.
+func NewFileStorage(config FileStorageConfig) (Storage, error) {
+	if config.Dir == "" {
+		return nil, maskAny(invalidConfigError)
+	}
+
+	if err := os.MkdirAll(config.Dir, 0700); err != nil {
+		return nil, maskAny(err)
+	}
+
+	newStorage := &fileStorage{
+		FileStorageConfig: config,
+		local:             NewMemoryStorage(),
+	}
+
+	if err := newStorage.removeExpired(); err != nil {
+		return nil, maskAny(err)
+	}
+
+	return newStorage, nil
+}
+
+type fileStorage struct {
+	FileStorageConfig
+
+	// local holds the task objects set by this process. Errors of task objects
+	// read from files lose their cause. Task objects executed by this process
+	// are thus served from local, so their errors can still be checked using
+	// e.g. IsTaskCancelled.
+	local Storage
+	mutex sync.Mutex
+}
+
+// fileTask represents the content of a task file.
+type fileTask struct {
+	ActiveStatus ActiveStatus `json:"active_status"`
+	Error        string       `json:"error,omitempty"`
+	FinalStatus  FinalStatus  `json:"final_status"`
+	ID           string       `json:"id"`
+
+	// PID is the ID of the process executing the task.
+	PID int `json:"pid"`
+}
+
+func (fs *fileStorage) Get(taskID string) (*Task, error) {
+	taskObject, err := fs.local.Get(taskID)
+	if err == nil {
+		return taskObject, nil
+	} else if !IsTaskObjectNotFound(err) {
+		return nil, maskAny(err)
+	}
+
+	if !isValidTaskID(taskID) {
+		return nil, maskAny(taskObjectNotFoundError)
+	}
+
+	raw, err := ioutil.ReadFile(fs.taskFile(taskID))
+	if os.IsNotExist(err) {
+		return nil, maskAny(taskObjectNotFoundError)
+	} else if err != nil {
+		return nil, maskAny(err)
+	}
+
+	var ft fileTask
+	if err := json.Unmarshal(raw, &ft); err != nil {
+		return nil, maskAny(err)
+	}
+
+	taskObject = &Task{
+		ActiveStatus: ft.ActiveStatus,
+		FinalStatus:  ft.FinalStatus,
+		ID:           ft.ID,
+	}
+	switch {
+	case HasCancelledStatus(taskObject):
+		taskObject.Error = maskAny(taskCancelledError)
+	case ft.Error != "":
+		taskObject.Error = errors.New(ft.Error)
+	}
+
+	if !HasFinalStatus(taskObject) && !processExists(ft.PID) {
+		// The process executing the task exited before the task finished. The
+		// task will thus never reach a final status on its own.
+		taskObject.ActiveStatus = StatusStopped
+		taskObject.Error = maskAny(taskAbandonedError)
+		taskObject.FinalStatus = StatusFailed
+	}
+
+	return taskObject, nil
+}
+
+func (fs *fileStorage) Set(taskObject *Task) error {
+	if !isValidTaskID(taskObject.ID) {
+		return maskAny(invalidTaskIDError)
+	}
+
+	if err := fs.local.Set(taskObject); err != nil {
+		return maskAny(err)
+	}
+
+	ft := fileTask{
+		ActiveStatus: taskObject.ActiveStatus,
+		FinalStatus:  taskObject.FinalStatus,
+		ID:           taskObject.ID,
+		PID:          os.Getpid(),
+	}
+	if taskObject.Error != nil {
+		ft.Error = taskObject.Error.Error()
+	}
+
+	raw, err := json.Marshal(ft)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	// Write to a temporary file within the same directory and rename it
+	// afterwards. Renaming is atomic, so readers either see the old or the new
+	// version of the task file.
+	tmpFile, err := ioutil.TempFile(fs.Dir, "."+taskObject.ID)
+	if err != nil {
+		return maskAny(err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(raw)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return maskAny(err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), fs.taskFile(taskObject.ID)); err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (fs *fileStorage) taskFile(taskID string) string {
+	return filepath.Join(fs.Dir, taskID+".json")
+}
+
+// removeExpired removes all task files older than MaxAge.
+func (fs *fileStorage) removeExpired() error {
+	if fs.MaxAge <= 0 {
+		return nil
+	}
+
+	infos, err := ioutil.ReadDir(fs.Dir)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	for _, info := range infos {
+		if info.IsDir() || time.Since(info.ModTime()) < fs.MaxAge {
+			continue
+		}
+
+		// Another process might have removed the file meanwhile.
+		err := os.Remove(filepath.Join(fs.Dir, info.Name()))
+		if err != nil && !os.IsNotExist(err) {
+			return maskAny(err)
+		}
+	}
+
+	return nil
+}
+
+// isValidTaskID checks whether the given task ID can safely be used as file
+// name.
+func isValidTaskID(taskID string) bool {
+	if taskID == "" || strings.HasPrefix(taskID, ".") {
+		return false
+	}
+
+	return !strings.ContainsAny(taskID, `/\`)
+}
+
+// processExists checks whether a process having the given ID is running.
+func processExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	// Sending signal 0 does not affect the process, but only checks whether it
+	// exists. A permission error means the process exists, but belongs to
+	// another user.
+	err := syscall.Kill(pid, syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,201 @@
+package task
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func givenFileStorageDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "inago-task-test")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir did return error: %#v", err)
+	}
+
+	return dir
+}
+
+func Test_Task_Storage_File(t *testing.T) {
+	dir := givenFileStorageDir(t)
+	defer os.RemoveAll(dir)
+
+	newConfig := DefaultFileStorageConfig()
+	newConfig.Dir = dir
+	newStorage, err := NewFileStorage(newConfig)
+	if err != nil {
+		t.Fatalf("NewFileStorage did return error: %#v", err)
+	}
+
+	_, err = newStorage.Get("task-id")
+	if !IsTaskObjectNotFound(err) {
+		t.Fatalf("Storage.Get did NOT return proper error")
+	}
+
+	_, err = newStorage.Get("../task-id")
+	if !IsTaskObjectNotFound(err) {
+		t.Fatalf("Storage.Get did NOT return proper error")
+	}
+
+	err = newStorage.Set(&Task{ID: "../task-id"})
+	if !IsInvalidTaskID(err) {
+		t.Fatalf("Storage.Set did NOT return proper error")
+	}
+
+	testCases := []*Task{
+		{
+			ActiveStatus: StatusStarted,
+			ID:           "started-id",
+		},
+		{
+			ActiveStatus: StatusStopped,
+			FinalStatus:  StatusSucceeded,
+			ID:           "succeeded-id",
+		},
+		{
+			ActiveStatus: StatusStopped,
+			Error:        fmt.Errorf("test error"),
+			FinalStatus:  StatusFailed,
+			ID:           "failed-id",
+		},
+		{
+			ActiveStatus: StatusStopped,
+			Error:        maskAny(taskCancelledError),
+			FinalStatus:  StatusCancelled,
+			ID:           "cancelled-id",
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := newStorage.Set(testCase)
+		if err != nil {
+			t.Fatalf("test case %d: Storage.Set did return error: %#v", i+1, err)
+		}
+	}
+
+	// A second storage using the same directory acts like another process
+	// looking up the tasks.
+	otherStorage, err := NewFileStorage(newConfig)
+	if err != nil {
+		t.Fatalf("NewFileStorage did return error: %#v", err)
+	}
+
+	for i, testCase := range testCases {
+		taskObject, err := otherStorage.Get(testCase.ID)
+		if err != nil {
+			t.Fatalf("test case %d: Storage.Get did return error: %#v", i+1, err)
+		}
+
+		if taskObject.ID != testCase.ID || taskObject.ActiveStatus != testCase.ActiveStatus || taskObject.FinalStatus != testCase.FinalStatus {
+			t.Fatalf("test case %d: received task object %#v differs from original task object %#v", i+1, taskObject, testCase)
+		}
+		if (taskObject.Error == nil) != (testCase.Error == nil) {
+			t.Fatalf("test case %d: received task object error %#v differs from original error %#v", i+1, taskObject.Error, testCase.Error)
+		}
+		if taskObject.Error != nil && taskObject.Error.Error() != testCase.Error.Error() {
+			t.Fatalf("test case %d: received task object error %#v differs from original error %#v", i+1, taskObject.Error, testCase.Error)
+		}
+	}
+
+	taskObject, err := otherStorage.Get("cancelled-id")
+	if err != nil {
+		t.Fatalf("Storage.Get did return error: %#v", err)
+	}
+	if !IsTaskCancelled(taskObject.Error) {
+		t.Fatalf("received task object did NOT have a proper error")
+	}
+}
+
+func Test_Task_Storage_File_Abandoned(t *testing.T) {
+	dir := givenFileStorageDir(t)
+	defer os.RemoveAll(dir)
+
+	newConfig := DefaultFileStorageConfig()
+	newConfig.Dir = dir
+	newStorage, err := NewFileStorage(newConfig)
+	if err != nil {
+		t.Fatalf("NewFileStorage did return error: %#v", err)
+	}
+
+	// The process ID is above the maximum process ID supported by Linux and
+	// thus never exists.
+	raw := `{"active_status":"started","final_status":"","id":"task-id","pid":1073741824}`
+	err = ioutil.WriteFile(filepath.Join(dir, "task-id.json"), []byte(raw), 0600)
+	if err != nil {
+		t.Fatalf("ioutil.WriteFile did return error: %#v", err)
+	}
+
+	taskObject, err := newStorage.Get("task-id")
+	if err != nil {
+		t.Fatalf("Storage.Get did return error: %#v", err)
+	}
+
+	if !HasFailedStatus(taskObject) {
+		t.Fatalf("received task object did NOT have failed status")
+	}
+	if !IsTaskAbandoned(taskObject.Error) {
+		t.Fatalf("received task object did NOT have a proper error")
+	}
+}
+
+func Test_Task_Storage_File_RemoveExpired(t *testing.T) {
+	dir := givenFileStorageDir(t)
+	defer os.RemoveAll(dir)
+
+	newConfig := DefaultFileStorageConfig()
+	newConfig.Dir = dir
+	newConfig.MaxAge = time.Hour
+	newStorage, err := NewFileStorage(newConfig)
+	if err != nil {
+		t.Fatalf("NewFileStorage did return error: %#v", err)
+	}
+
+	for _, taskID := range []string{"expired-id", "current-id"} {
+		err := newStorage.Set(&Task{ID: taskID, ActiveStatus: StatusStopped, FinalStatus: StatusSucceeded})
+		if err != nil {
+			t.Fatalf("Storage.Set did return error: %#v", err)
+		}
+	}
+
+	expired := time.Now().Add(-2 * time.Hour)
+	err = os.Chtimes(filepath.Join(dir, "expired-id.json"), expired, expired)
+	if err != nil {
+		t.Fatalf("os.Chtimes did return error: %#v", err)
+	}
+
+	otherStorage, err := NewFileStorage(newConfig)
+	if err != nil {
+		t.Fatalf("NewFileStorage did return error: %#v", err)
+	}
+
+	_, err = otherStorage.Get("expired-id")
+	if !IsTaskObjectNotFound(err) {
+		t.Fatalf("Storage.Get did NOT return proper error")
+	}
+
+	_, err = otherStorage.Get("current-id")
+	if err != nil {
+		t.Fatalf("Storage.Get did return error: %#v", err)
+	}
+}
+
+func Test_Task_Storage_File_InvalidConfig(t *testing.T) {
+	newConfig := DefaultFileStorageConfig()
+	newConfig.Dir = ""
+
+	_, err := NewFileStorage(newConfig)
+	if !IsInvalidConfig(err) {
+		t.Fatalf("NewFileStorage did NOT return proper error")
+	}
+}
+
+func Test_Task_Storage_File_DefaultDir(t *testing.T) {
+	newConfig := DefaultFileStorageConfig()
+
+	expected := filepath.Join(os.Getenv("HOME"), ".inago", "tasks")
+	if newConfig.Dir != expected {
+		t.Fatalf("DefaultFileStorageConfig.Dir = %s, expected %s", newConfig.Dir, expected)
+	}
+}
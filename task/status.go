@@ -15,12 +15,25 @@ const (
 type FinalStatus string
 
 const (
+	// StatusCancelled represents a task where the action was aborted because the
+	// task got cancelled.
+	StatusCancelled FinalStatus = "cancelled"
 	// StatusFailed represents a task where the action return an error.
 	StatusFailed FinalStatus = "failed"
 	// StatusSucceeded represents a task where the action returned nil.
 	StatusSucceeded FinalStatus = "succeeded"
 )
 
+// HasCancelledStatus determines whether a task has been cancelled or not. Note
+// that this is about a final status.
+func HasCancelledStatus(taskObject *Task) bool {
+	if taskObject.ActiveStatus == StatusStopped && taskObject.FinalStatus == StatusCancelled {
+		return true
+	}
+
+	return false
+}
+
 // HasFailedStatus determines whether a task has failed or not. Note that this
 // is about a final status.
 func HasFailedStatus(taskObject *Task) bool {
@@ -33,7 +46,7 @@ func HasFailedStatus(taskObject *Task) bool {
 
 // HasFinalStatus determines whether a task has a final status or not.
 func HasFinalStatus(taskObject *Task) bool {
-	if HasFailedStatus(taskObject) || HasSucceededStatus(taskObject) {
+	if HasCancelledStatus(taskObject) || HasFailedStatus(taskObject) || HasSucceededStatus(taskObject) {
 		return true
 	}
 
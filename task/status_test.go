@@ -50,6 +50,26 @@ func Test_Task_Status_HasFinalStatus(t *testing.T) {
 			},
 			Expected: true,
 		},
+
+		// This status combination is invalid.
+		{
+			Input: &Task{
+				ActiveStatus: StatusStarted,
+				Error:        nil,
+				FinalStatus:  StatusCancelled,
+				ID:           "",
+			},
+			Expected: false,
+		},
+		{
+			Input: &Task{
+				ActiveStatus: StatusStopped,
+				Error:        nil,
+				FinalStatus:  StatusCancelled,
+				ID:           "",
+			},
+			Expected: true,
+		},
 		{
 			Input: &Task{
 				ActiveStatus: StatusStarted,
@@ -1,6 +1,7 @@
 package task
 
 import (
+	"sync"
 	"time"
 
 	"github.com/satori/go.uuid"
@@ -37,6 +38,12 @@ type Task struct {
 // Service represents a task managing unit being able to act on task
 // objects.
 type Service interface {
+	// Cancel cancels the context of the action of the given task. Actions are
+	// expected to return as soon as their context is done. The task then
+	// reaches the final status "cancelled". Cancelling a task that already has
+	// a final status does nothing.
+	Cancel(ctx context.Context, taskID string) error
+
 	// Create creates a new task object configured with the given action. The
 	// task object is immediately returned and its corresponding action is
 	// executed asynchronously.
@@ -46,6 +53,11 @@ type Service interface {
 	// task ID.
 	FetchState(ctx context.Context, taskID string) (*Task, error)
 
+	// MarkAsCancelled marks the task object as cancelled and persists its
+	// state. The returned task object is actually the refreshed version of the
+	// provided one.
+	MarkAsCancelled(ctx context.Context, taskObject *Task) (*Task, error)
+
 	// MarkAsSucceeded marks the task object as succeeded and persists its state.
 	// The returned task object is actually the refreshed version of the provided
 	// one.
@@ -60,8 +72,8 @@ type Service interface {
 	PersistState(ctx context.Context, taskObject *Task) error
 
 	// WaitForFinalStatus blocks and waits for the given task to reach a final
-	// status. The given closer can end the waiting and thus stop blocking the
-	// call to WaitForFinalStatus.
+	// status. The given closer, as well as the given context being done, can
+	// end the waiting and thus stop blocking the call to WaitForFinalStatus.
 	WaitForFinalStatus(ctx context.Context, taskID string, closer <-chan struct{}) (*Task, error)
 }
 
@@ -92,7 +104,8 @@ func DefaultConfig() Config {
 // NewTaskService returns a new configured task service instance.
 func NewTaskService(config Config) Service {
 	newTaskService := &taskService{
-		Config: config,
+		Config:  config,
+		cancels: map[string]context.CancelFunc{},
 	}
 
 	return newTaskService
@@ -100,6 +113,32 @@ func NewTaskService(config Config) Service {
 
 type taskService struct {
 	Config
+
+	// cancels holds the cancel functions of the contexts of all tasks whose
+	// action is currently executed, indexed by task ID.
+	cancels map[string]context.CancelFunc
+	mutex   sync.Mutex
+}
+
+func (ts *taskService) Cancel(ctx context.Context, taskID string) error {
+	ts.Config.Logger.Debug(ctx, "task: cancelling task: %v", taskID)
+
+	ts.mutex.Lock()
+	cancel, ok := ts.cancels[taskID]
+	ts.mutex.Unlock()
+
+	if !ok {
+		// The task either does not exist, or its action already returned.
+		_, err := ts.FetchState(ctx, taskID)
+		if err != nil {
+			return maskAny(err)
+		}
+		return nil
+	}
+
+	cancel()
+
+	return nil
 }
 
 func (ts *taskService) Create(ctx context.Context, action Action) (*Task, error) {
@@ -107,6 +146,11 @@ func (ts *taskService) Create(ctx context.Context, action Action) (*Task, error)
 	ctx = context.WithValue(ctx, ContextTaskID, taskID)
 	ts.Config.Logger.Debug(ctx, "task: creating task")
 
+	ctx, cancel := context.WithCancel(ctx)
+	ts.mutex.Lock()
+	ts.cancels[taskID] = cancel
+	ts.mutex.Unlock()
+
 	taskObject := &Task{
 		ID:           taskID,
 		ActiveStatus: StatusStarted,
@@ -114,8 +158,26 @@ func (ts *taskService) Create(ctx context.Context, action Action) (*Task, error)
 	}
 
 	go func(ctx context.Context) {
+		defer func() {
+			ts.mutex.Lock()
+			delete(ts.cancels, taskID)
+			ts.mutex.Unlock()
+			cancel()
+		}()
+
 		ts.Config.Logger.Debug(ctx, "task: starting task action")
 		err := action(ctx)
+		if err != nil && ctx.Err() == context.Canceled {
+			// The action did not finish because the task got cancelled. The error
+			// returned is only a consequence of that.
+			ts.Config.Logger.Debug(ctx, "task: task action cancelled: %#v", err)
+			_, markErr := ts.MarkAsCancelled(ctx, taskObject)
+			if markErr != nil {
+				ts.Config.Logger.Error(ctx, "Task.MarkAsCancelled failed: %#v", maskAny(markErr))
+				return
+			}
+			return
+		}
 		if err != nil {
 			_, markErr := ts.MarkAsFailedWithError(ctx, taskObject, err)
 			if markErr != nil {
@@ -158,6 +220,21 @@ func (ts *taskService) FetchState(ctx context.Context, taskID string) (*Task, er
 	return taskObject, nil
 }
 
+func (ts *taskService) MarkAsCancelled(ctx context.Context, taskObject *Task) (*Task, error) {
+	ts.Config.Logger.Debug(ctx, "task: marking as cancelled for task: %v", taskObject.ID)
+
+	taskObject.ActiveStatus = StatusStopped
+	taskObject.Error = maskAny(taskCancelledError)
+	taskObject.FinalStatus = StatusCancelled
+
+	err := ts.PersistState(ctx, taskObject)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	return taskObject, nil
+}
+
 func (ts *taskService) MarkAsFailedWithError(ctx context.Context, taskObject *Task, err error) (*Task, error) {
 	ts.Config.Logger.Debug(ctx, "task: marking as failed for task: %v", taskObject.ID)
 
@@ -200,7 +277,8 @@ func (ts *taskService) PersistState(ctx context.Context, taskObject *Task) error
 
 // WaitForFinalStatus acts as described in the interface comments. Note that
 // both, task object and error will be nil in case the closer ends waiting for
-// the task to reach a final state.
+// the task to reach a final state. In case the given context is done, its
+// error is returned.
 func (ts *taskService) WaitForFinalStatus(ctx context.Context, taskID string, closer <-chan struct{}) (*Task, error) {
 	ts.Config.Logger.Debug(ctx, "task: waiting for final status for task: %v", taskID)
 
@@ -209,6 +287,9 @@ func (ts *taskService) WaitForFinalStatus(ctx context.Context, taskID string, cl
 		case <-closer:
 			ts.Config.Logger.Debug(ctx, "task: closer stopped wait for final status")
 			return nil, nil
+		case <-ctx.Done():
+			ts.Config.Logger.Debug(ctx, "task: context stopped wait for final status")
+			return nil, maskAny(ctx.Err())
 		case <-time.After(ts.WaitSleep):
 			taskObject, err := ts.FetchState(ctx, taskID)
 			if err != nil {
@@ -155,3 +155,84 @@ func Test_Task_TastService_Create_Wait(t *testing.T) {
 		t.Fatalf("received task object did have a final status")
 	}
 }
+
+func Test_Task_TaskService_Cancel(t *testing.T) {
+	newConfig := DefaultConfig()
+	newConfig.WaitSleep = 10 * time.Millisecond
+	newTaskService := NewTaskService(newConfig)
+
+	action := func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	}
+
+	taskObject, err := newTaskService.Create(context.Background(), action)
+	if err != nil {
+		t.Fatalf("TaskService.Create did return error: %#v", err)
+	}
+
+	err = newTaskService.Cancel(context.Background(), taskObject.ID)
+	if err != nil {
+		t.Fatalf("TaskService.Cancel did return error: %#v", err)
+	}
+
+	taskObject, err = newTaskService.WaitForFinalStatus(context.Background(), taskObject.ID, nil)
+	if err != nil {
+		t.Fatalf("TaskService.WaitForFinalStatus did return error: %#v", err)
+	}
+
+	if !HasCancelledStatus(taskObject) {
+		t.Fatalf("received task object did NOT have cancelled status: %#v", taskObject)
+	}
+
+	if !IsTaskCancelled(taskObject.Error) {
+		t.Fatalf("received task object did NOT have a proper error")
+	}
+
+	// Cancelling a finished task should do nothing.
+	err = newTaskService.Cancel(context.Background(), taskObject.ID)
+	if err != nil {
+		t.Fatalf("TaskService.Cancel did return error: %#v", err)
+	}
+
+	// Cancelling an invalid task should not work.
+	err = newTaskService.Cancel(context.Background(), "invalid")
+	if !IsTaskObjectNotFound(err) {
+		t.Fatalf("TaskService.Cancel did NOT return proper error")
+	}
+}
+
+func Test_Task_TaskService_Cancel_Succeeded(t *testing.T) {
+	newConfig := DefaultConfig()
+	newConfig.WaitSleep = 10 * time.Millisecond
+	newTaskService := NewTaskService(newConfig)
+
+	// The action ignores its context and finishes its work anyway.
+	action := func(ctx context.Context) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}
+
+	taskObject, err := newTaskService.Create(context.Background(), action)
+	if err != nil {
+		t.Fatalf("TaskService.Create did return error: %#v", err)
+	}
+
+	err = newTaskService.Cancel(context.Background(), taskObject.ID)
+	if err != nil {
+		t.Fatalf("TaskService.Cancel did return error: %#v", err)
+	}
+
+	taskObject, err = newTaskService.WaitForFinalStatus(context.Background(), taskObject.ID, nil)
+	if err != nil {
+		t.Fatalf("TaskService.WaitForFinalStatus did return error: %#v", err)
+	}
+
+	if !HasSucceededStatus(taskObject) {
+		t.Fatalf("received task object did NOT have succeeded status: %#v", taskObject)
+	}
+}